@@ -116,6 +116,7 @@ type ClusterConfigurationStatus struct {
 
 //+kubebuilder:object:root=true
 //+kubebuilder:resource:path=clusterconfigurations,scope=Namespaced
+//+kubebuilder:storageversion
 //+kubebuilder:subresource:status
 
 // ClusterConfiguration is the Schema for the clusterconfigurations API
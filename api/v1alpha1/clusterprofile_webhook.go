@@ -0,0 +1,189 @@
+/*
+Copyright 2022-23. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"fmt"
+
+	"github.com/Masterminds/semver/v3"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+
+	libsveltosv1alpha1 "github.com/projectsveltos/libsveltos/api/v1alpha1"
+)
+
+// log is for logging in this package.
+var clusterprofilelog = logf.Log.WithName("clusterprofile-resource")
+
+// unsupportedSyncModeTransitions lists, per current SyncMode, the SyncModes
+// a ClusterProfile cannot be moved to.
+// - Features already deployed in OneTime/Continuous mode are not redeployed when
+// SyncMode alone changes. Moving to ContinuousWithDriftDetection would leave matching
+// clusters without drift detection;
+// - in ContinuousWithDriftDetection mode, a detected drift causes features to be
+// redeployed. Drift detection is left in the matching clusters when SyncMode changes
+// and so moving to OneTime would not prevent features from being redeployed.
+var unsupportedSyncModeTransitions = map[SyncMode][]SyncMode{
+	SyncModeOneTime:                      {SyncModeContinuousWithDriftDetection},
+	SyncModeContinuous:                   {SyncModeContinuousWithDriftDetection},
+	SyncModeContinuousWithDriftDetection: {SyncModeOneTime},
+}
+
+func (r *ClusterProfile) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/validate-config-projectsveltos-io-v1alpha1-clusterprofile,mutating=false,failurePolicy=fail,sideEffects=None,groups=config.projectsveltos.io,resources=clusterprofiles,verbs=create;update,versions=v1alpha1,name=vclusterprofile.kb.io,admissionReviewVersions=v1
+
+var _ webhook.Validator = &ClusterProfile{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
+func (r *ClusterProfile) ValidateCreate() error {
+	clusterprofilelog.V(1).Info("validate create", "name", r.Name)
+
+	return r.toAggregateError(r.validateSpec())
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
+func (r *ClusterProfile) ValidateUpdate(old runtime.Object) error {
+	clusterprofilelog.V(1).Info("validate update", "name", r.Name)
+
+	oldClusterProfile, ok := old.(*ClusterProfile)
+	if !ok {
+		return apierrors.NewBadRequest(fmt.Sprintf("expected a ClusterProfile but got a %T", old))
+	}
+
+	allErrs := r.validateSpec()
+	allErrs = append(allErrs, r.validateSyncModeTransition(oldClusterProfile.Spec.SyncMode)...)
+
+	return r.toAggregateError(allErrs)
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
+func (r *ClusterProfile) ValidateDelete() error {
+	return nil
+}
+
+func (r *ClusterProfile) toAggregateError(allErrs field.ErrorList) error {
+	if len(allErrs) == 0 {
+		return nil
+	}
+
+	return apierrors.NewInvalid(GroupVersion.WithKind(ClusterProfileKind).GroupKind(), r.Name, allErrs)
+}
+
+func (r *ClusterProfile) validateSpec() field.ErrorList {
+	specPath := field.NewPath("spec")
+
+	var allErrs field.ErrorList
+	allErrs = append(allErrs, r.validateClusterSelector(specPath)...)
+	allErrs = append(allErrs, r.validatePolicyRefs(specPath.Child("policyRefs"))...)
+	allErrs = append(allErrs, r.validateHelmCharts(specPath.Child("helmCharts"))...)
+
+	return allErrs
+}
+
+func (r *ClusterProfile) validateClusterSelector(specPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if _, err := labels.Parse(string(r.Spec.ClusterSelector)); err != nil {
+		allErrs = append(allErrs,
+			field.Invalid(specPath.Child("clusterSelector"), r.Spec.ClusterSelector, err.Error()))
+	}
+
+	structuredSelector := r.Spec.StructuredClusterSelector
+	if structuredSelector != nil && structuredSelector.LabelSelector != nil {
+		if _, err := metav1.LabelSelectorAsSelector(structuredSelector.LabelSelector); err != nil {
+			allErrs = append(allErrs,
+				field.Invalid(specPath.Child("structuredClusterSelector", "labelSelector"),
+					structuredSelector.LabelSelector, err.Error()))
+		}
+	}
+
+	return allErrs
+}
+
+func (r *ClusterProfile) validatePolicyRefs(policyRefsPath *field.Path) field.ErrorList {
+	supportedKinds := []string{
+		string(libsveltosv1alpha1.ConfigMapReferencedResourceKind),
+		string(libsveltosv1alpha1.SecretReferencedResourceKind),
+	}
+
+	var allErrs field.ErrorList
+	for i := range r.Spec.PolicyRefs {
+		kind := r.Spec.PolicyRefs[i].Kind
+		if kind != supportedKinds[0] && kind != supportedKinds[1] {
+			allErrs = append(allErrs,
+				field.NotSupported(policyRefsPath.Index(i).Child("kind"), kind, supportedKinds))
+		}
+	}
+
+	return allErrs
+}
+
+func (r *ClusterProfile) validateHelmCharts(helmChartsPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	releases := make(map[string]bool)
+	for i := range r.Spec.HelmCharts {
+		chart := &r.Spec.HelmCharts[i]
+		chartPath := helmChartsPath.Index(i)
+
+		if (chart.RepositoryURL == "") == (chart.HelmRepositoryRef == "") {
+			allErrs = append(allErrs,
+				field.Invalid(chartPath.Child("repositoryURL"), chart.RepositoryURL,
+					"exactly one of repositoryURL and helmRepositoryRef must be set"))
+		}
+
+		if _, err := semver.NewVersion(chart.ChartVersion); err != nil {
+			if _, constraintErr := semver.NewConstraint(chart.ChartVersion); constraintErr != nil {
+				allErrs = append(allErrs,
+					field.Invalid(chartPath.Child("chartVersion"), chart.ChartVersion,
+						fmt.Sprintf("must be a semantic version or a semantic version constraint: %v", err)))
+			}
+		}
+
+		release := fmt.Sprintf("%s/%s", chart.ReleaseNamespace, chart.ReleaseName)
+		if releases[release] {
+			allErrs = append(allErrs, field.Duplicate(chartPath, release))
+		}
+		releases[release] = true
+	}
+
+	return allErrs
+}
+
+func (r *ClusterProfile) validateSyncModeTransition(oldSyncMode SyncMode) field.ErrorList {
+	for _, syncMode := range unsupportedSyncModeTransitions[oldSyncMode] {
+		if r.Spec.SyncMode == syncMode {
+			return field.ErrorList{
+				field.Forbidden(field.NewPath("spec", "syncMode"),
+					fmt.Sprintf("changing syncMode from %s to %s is not supported", oldSyncMode, syncMode)),
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,149 @@
+/*
+Copyright 2022-23. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	libsveltosv1alpha1 "github.com/projectsveltos/libsveltos/api/v1alpha1"
+	configv1alpha1 "github.com/projectsveltos/sveltos-manager/api/v1alpha1"
+)
+
+var _ = Describe("ClusterProfile webhook", func() {
+	var clusterProfile *configv1alpha1.ClusterProfile
+
+	BeforeEach(func() {
+		clusterProfile = &configv1alpha1.ClusterProfile{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "webhook-" + randomString(),
+			},
+			Spec: configv1alpha1.ClusterProfileSpec{
+				ClusterSelector: libsveltosv1alpha1.Selector("env=qa,zone=west"),
+				SyncMode:        configv1alpha1.SyncModeContinuous,
+				PolicyRefs: []libsveltosv1alpha1.PolicyRef{
+					{
+						Kind:      string(libsveltosv1alpha1.ConfigMapReferencedResourceKind),
+						Namespace: randomString(),
+						Name:      randomString(),
+					},
+				},
+				HelmCharts: []configv1alpha1.HelmChart{
+					{
+						RepositoryURL:    "https://kyverno.github.io/kyverno/",
+						RepositoryName:   "kyverno",
+						ChartName:        "kyverno/kyverno",
+						ChartVersion:     "v2.6.0",
+						ReleaseName:      "kyverno-latest",
+						ReleaseNamespace: "kyverno",
+						HelmChartAction:  configv1alpha1.HelmChartActionInstall,
+					},
+				},
+			},
+		}
+	})
+
+	AfterEach(func() {
+		Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, clusterProfile))).To(Succeed())
+	})
+
+	It("accepts a valid ClusterProfile", func() {
+		Expect(k8sClient.Create(ctx, clusterProfile)).To(Succeed())
+	})
+
+	It("rejects an invalid ClusterSelector", func() {
+		clusterProfile.Spec.ClusterSelector = libsveltosv1alpha1.Selector("env in (qa")
+		err := k8sClient.Create(ctx, clusterProfile)
+		Expect(apierrors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("spec.clusterSelector"))
+	})
+
+	It("rejects a ChartVersion which is not a semantic version", func() {
+		clusterProfile.Spec.HelmCharts[0].ChartVersion = "latest"
+		err := k8sClient.Create(ctx, clusterProfile)
+		Expect(apierrors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("spec.helmCharts[0].chartVersion"))
+	})
+
+	It("accepts a ChartVersion which is a semantic version constraint", func() {
+		clusterProfile.Spec.HelmCharts[0].ChartVersion = ">=2.5.0 <3.0.0"
+		Expect(k8sClient.Create(ctx, clusterProfile)).To(Succeed())
+	})
+
+	It("requires exactly one of RepositoryURL and HelmRepositoryRef", func() {
+		clusterProfile.Spec.HelmCharts[0].HelmRepositoryRef = randomString()
+		err := k8sClient.Create(ctx, clusterProfile)
+		Expect(apierrors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("spec.helmCharts[0].repositoryURL"))
+
+		By("Setting only HelmRepositoryRef")
+		clusterProfile.Spec.HelmCharts[0].RepositoryURL = ""
+		Expect(k8sClient.Create(ctx, clusterProfile)).To(Succeed())
+	})
+
+	It("rejects duplicated helm releases", func() {
+		chart := clusterProfile.Spec.HelmCharts[0]
+		chart.ChartVersion = "v2.5.0"
+		clusterProfile.Spec.HelmCharts = append(clusterProfile.Spec.HelmCharts, chart)
+		err := k8sClient.Create(ctx, clusterProfile)
+		Expect(apierrors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("spec.helmCharts[1]"))
+
+		By("Using a different release namespace")
+		clusterProfile.Spec.HelmCharts[1].ReleaseNamespace = randomString()
+		Expect(k8sClient.Create(ctx, clusterProfile)).To(Succeed())
+	})
+
+	It("rejects unknown PolicyRef kinds", func() {
+		clusterProfile.Spec.PolicyRefs[0].Kind = "Deployment"
+		err := k8sClient.Create(ctx, clusterProfile)
+		Expect(err).ToNot(BeNil())
+		Expect(err.Error()).To(ContainSubstring("spec.policyRefs[0].kind"))
+	})
+
+	It("rejects unsupported SyncMode transitions", func() {
+		Expect(k8sClient.Create(ctx, clusterProfile)).To(Succeed())
+
+		By("Moving from Continuous to ContinuousWithDriftDetection")
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(clusterProfile), clusterProfile)).To(Succeed())
+		clusterProfile.Spec.SyncMode = configv1alpha1.SyncModeContinuousWithDriftDetection
+		err := k8sClient.Update(ctx, clusterProfile)
+		Expect(apierrors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("spec.syncMode"))
+
+		By("Moving from Continuous to DryRun")
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(clusterProfile), clusterProfile)).To(Succeed())
+		clusterProfile.Spec.SyncMode = configv1alpha1.SyncModeDryRun
+		Expect(k8sClient.Update(ctx, clusterProfile)).To(Succeed())
+
+		By("Moving from DryRun to ContinuousWithDriftDetection")
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(clusterProfile), clusterProfile)).To(Succeed())
+		clusterProfile.Spec.SyncMode = configv1alpha1.SyncModeContinuousWithDriftDetection
+		Expect(k8sClient.Update(ctx, clusterProfile)).To(Succeed())
+
+		By("Moving from ContinuousWithDriftDetection to OneTime")
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(clusterProfile), clusterProfile)).To(Succeed())
+		clusterProfile.Spec.SyncMode = configv1alpha1.SyncModeOneTime
+		err = k8sClient.Update(ctx, clusterProfile)
+		Expect(apierrors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("spec.syncMode"))
+	})
+})
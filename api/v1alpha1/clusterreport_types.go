@@ -20,6 +20,10 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+const (
+	ClusterReportKind = "ClusterReport"
+)
+
 // HelmAction represents the type of action on a give resource or helm release
 type HelmAction string
 
@@ -56,6 +60,12 @@ type ReleaseReport struct {
 	// in the CAPI Cluster.
 	ChartVersion string `json:"chartVersion"`
 
+	// ChartRef is the reference of the helm chart pulled from an OCI
+	// registry (oci://<registry>/<repository>/<chart>:<version>).
+	// Not set for charts stored in helm repositories.
+	// +optional
+	ChartRef string `json:"chartRef,omitempty"`
+
 	// Action represent the type of operation on the Helm Chart
 	// +kubebuilder:validation:Enum=No Action;Install;Upgrade;Delete;Conflict
 	// +optional
@@ -65,6 +75,54 @@ type ReleaseReport struct {
 	// explain the action.
 	// +optional
 	Message string `json:"message,omitempty"`
+
+	// Remediation describes the remediation applied to the helm release,
+	// found in a failed or pending state, before action was taken.
+	// +optional
+	Remediation string `json:"remediation,omitempty"`
+
+	// Adopted is set if the helm release, which was not installed by this ClusterProfile,
+	// was adopted (in DryRun mode, if it would be adopted).
+	// +optional
+	Adopted bool `json:"adopted,omitempty"`
+
+	// ResourceChanges lists, in DryRun mode only, the resources the helm release
+	// would create, update or delete. It is obtained comparing the manifest of the
+	// release rendered by Helm against the managed cluster with the manifest of the
+	// currently deployed release. Helm hooks are not considered.
+	// +optional
+	ResourceChanges []ReleaseResourceChange `json:"resourceChanges,omitempty"`
+}
+
+// ReleaseResourceChange describes how a resource part of an helm release would change
+type ReleaseResourceChange struct {
+	// Name of the resource
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// Namespace of the resource. Empty for resources scoped at cluster level and for
+	// resources whose namespace is not set in the helm chart templates.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// Group of the resource
+	Group string `json:"group"`
+
+	// Version of the resource
+	Version string `json:"version"`
+
+	// Kind of the resource
+	// +kubebuilder:validation:MinLength=1
+	Kind string `json:"kind"`
+
+	// Action represent the type of change on the resource
+	// +kubebuilder:validation:Enum=Create;Update;Delete
+	Action string `json:"action"`
+
+	// Diff is the unified diff between the deployed resource and the
+	// requested one. Only set for resources which would be updated.
+	// +optional
+	Diff string `json:"diff,omitempty"`
 }
 
 type ResourceReport struct {
@@ -105,6 +163,7 @@ type ClusterReportStatus struct {
 
 //+kubebuilder:object:root=true
 //+kubebuilder:resource:path=clusterreports,scope=Namespaced
+//+kubebuilder:storageversion
 //+kubebuilder:subresource:status
 
 // ClusterReport is the Schema for the clusterreports API
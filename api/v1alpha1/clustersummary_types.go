@@ -49,7 +49,7 @@ const (
 	FeatureHelm = FeatureID("Helm")
 )
 
-// +kubebuilder:validation:Enum:=Provisioning;Provisioned;Failed;Removing;Removed
+// +kubebuilder:validation:Enum:=Provisioning;Provisioned;Failed;Removing;Removed;WaitingForWindow
 type FeatureStatus string
 
 const (
@@ -71,6 +71,10 @@ const (
 
 	// FeatureStatusRemoved indicates that feature is removed
 	FeatureStatusRemoved = FeatureStatus("Removed")
+
+	// FeatureStatusWaitingForWindow indicates that feature configuration
+	// needs to be deployed but that is held till next sync window opens
+	FeatureStatusWaitingForWindow = FeatureStatus("WaitingForWindow")
 )
 
 // FeatureSummary contains a summary of the state of a workload
@@ -103,6 +107,11 @@ type FeatureSummary struct {
 	// LastAppliedTime is the time feature was last reconciled
 	// +optional
 	LastAppliedTime *metav1.Time `json:"lastAppliedTime,omitempty"`
+
+	// DeployedGeneration is the ClusterSummary generation whose Spec feature Hash
+	// and Status were last evaluated against
+	// +optional
+	DeployedGeneration int64 `json:"deployedGeneration,omitempty"`
 }
 
 // HelChartStatus specifies whether ClusterSummary is successfully managing
@@ -136,6 +145,32 @@ type HelmChartSummary struct {
 	// chart or there is a conflict
 	// +optional
 	ConflictMessage string `json:"conflictMessage,omitempty"`
+
+	// RemediationAttempts is the number of remediation attempts since
+	// helm release was last successfully deployed
+	// +optional
+	RemediationAttempts int32 `json:"remediationAttempts,omitempty"`
+
+	// LastRemediation describes the last remediation applied to the helm release
+	// +optional
+	LastRemediation string `json:"lastRemediation,omitempty"`
+
+	// PostRenderersHash is the hash of the (instantiated) post renderers the helm
+	// release was last deployed with
+	// +optional
+	PostRenderersHash string `json:"postRenderersHash,omitempty"`
+
+	// ResolvedChartVersion is the chart version the HelmChart ChartVersion constraint
+	// was last resolved to. Not set when ChartVersion is not a constraint.
+	// +optional
+	ResolvedChartVersion string `json:"resolvedChartVersion,omitempty"`
+
+	// AdoptionPending is set when ClusterSummary starts managing the helm release and
+	// reset once the helm release is installed, or allowed to be adopted, by ClusterSummary.
+	// Helm releases listed with AdoptionPending not set, and not marked as managed by any
+	// ClusterSummary, were installed by Sveltos versions which did not mark helm releases.
+	// +optional
+	AdoptionPending bool `json:"adoptionPending,omitempty"`
 }
 
 // ClusterSummarySpec defines the desired state of ClusterSummary
@@ -155,8 +190,75 @@ type ClusterSummarySpec struct {
 	ClusterProfileSpec ClusterProfileSpec `json:"clusterProfileSpec,omitempty"`
 }
 
+const (
+	// PausedCondition indicates Sveltos/CAPI Cluster or ClusterSummary is paused.
+	// No feature is deployed while paused.
+	PausedCondition = "Paused"
+
+	// ClusterReachableCondition indicates Sveltos/CAPI Cluster exists and is ready
+	// to be configured.
+	ClusterReachableCondition = "ClusterReachable"
+
+	// DependenciesProvisionedCondition indicates all ClusterProfiles listed in DependsOn
+	// are provisioned in the cluster. No feature is deployed till then.
+	DependenciesProvisionedCondition = "DependenciesProvisioned"
+
+	// HelmConflictCondition indicates at least one helm chart cannot be managed
+	// because another ClusterSummary is already managing it.
+	HelmConflictCondition = "HelmConflict"
+
+	// ResourcesAppliedCondition indicates Resources feature is provisioned.
+	ResourcesAppliedCondition = "ResourcesApplied"
+
+	// HelmAppliedCondition indicates Helm feature is provisioned.
+	HelmAppliedCondition = "HelmApplied"
+)
+
+const (
+	// PausedReason is used when Sveltos/CAPI Cluster or ClusterSummary is paused.
+	PausedReason = "Paused"
+
+	// NotPausedReason is used when neither Sveltos/CAPI Cluster nor ClusterSummary is paused.
+	NotPausedReason = "NotPaused"
+
+	// ClusterReadyReason is used when Sveltos/CAPI Cluster is ready to be configured.
+	ClusterReadyReason = "ClusterReady"
+
+	// ClusterNotReadyReason is used when Sveltos/CAPI Cluster is not ready to be configured yet.
+	ClusterNotReadyReason = "ClusterNotReady"
+
+	// ClusterNotFoundReason is used when Sveltos/CAPI Cluster does not exist.
+	ClusterNotFoundReason = "ClusterNotFound"
+
+	// DependenciesProvisionedReason is used when all ClusterProfiles listed in DependsOn are provisioned.
+	DependenciesProvisionedReason = "DependenciesProvisioned"
+
+	// WaitingForDependenciesReason is used when features are held till all ClusterProfiles listed
+	// in DependsOn are provisioned.
+	WaitingForDependenciesReason = "WaitingForDependencies"
+
+	// HelmChartConflictReason is used when at least one helm chart is managed by another ClusterSummary.
+	HelmChartConflictReason = "HelmChartConflict"
+
+	// NoConflictReason is used when all helm charts are managed by this ClusterSummary.
+	NoConflictReason = "NoConflict"
+
+	// WaitingForSyncWindowReason is used when feature changes are held till next sync window opens.
+	WaitingForSyncWindowReason = "WaitingForSyncWindow"
+
+	// NothingToApplyReason is used when feature is not configured.
+	NothingToApplyReason = "NothingToApply"
+)
+
 // ClusterSummaryStatus defines the observed state of ClusterSummary
 type ClusterSummaryStatus struct {
+	// ObservedGeneration is the most recent ClusterSummary generation
+	// whose features were all evaluated by the controller. It is not updated
+	// while ClusterSummary is paused, cluster is not reachable or dependencies
+	// are not provisioned.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
 	// FeatureSummaries reports the status of each workload cluster feature
 	// directly managed by ClusterProfile.
 	// +listType=atomic
@@ -168,11 +270,27 @@ type ClusterSummaryStatus struct {
 	// +listType=atomic
 	// +optional
 	HelmReleaseSummaries []HelmChartSummary `json:"helmReleaseSummaries,omitempty"`
+
+	// NextSyncTime, when set, indicates when changes currently held because of
+	// ClusterProfile SyncWindows will be evaluated again
+	// +optional
+	NextSyncTime *metav1.Time `json:"nextSyncTime,omitempty"`
+
+	// Conditions reports Ready, Paused, ClusterReachable, HelmConflict,
+	// ResourcesApplied and HelmApplied conditions.
+	// +listType=map
+	// +listMapKey=type
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
 //+kubebuilder:object:root=true
 //+kubebuilder:resource:path=clustersummaries,scope=Namespaced
+//+kubebuilder:storageversion
 //+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].status"
+//+kubebuilder:printcolumn:name="Reason",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].reason"
+//+kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
 
 // ClusterSummary is the Schema for the clustersummaries API
 type ClusterSummary struct {
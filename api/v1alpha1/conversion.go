@@ -0,0 +1,57 @@
+/*
+Copyright 2022-23. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// v1alpha1 is the storage version. All other versions are converted
+// to and from it.
+
+// Hub marks ClusterProfile as a conversion hub.
+func (*ClusterProfile) Hub() {}
+
+// Hub marks ClusterSummary as a conversion hub.
+func (*ClusterSummary) Hub() {}
+
+// Hub marks ClusterConfiguration as a conversion hub.
+func (*ClusterConfiguration) Hub() {}
+
+// Hub marks ClusterReport as a conversion hub.
+func (*ClusterReport) Hub() {}
+
+// SetupWebhookWithManager registers ClusterSummary conversion webhook
+func (r *ClusterSummary) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		Complete()
+}
+
+// SetupWebhookWithManager registers ClusterConfiguration conversion webhook
+func (r *ClusterConfiguration) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		Complete()
+}
+
+// SetupWebhookWithManager registers ClusterReport conversion webhook
+func (r *ClusterReport) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		Complete()
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2022-23. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	HelmRepositoryKind = "HelmRepository"
+)
+
+const (
+	// HelmRepositoryUsernameKey is the key, in the HelmRepository Secret, containing
+	// the username used for basic authentication
+	HelmRepositoryUsernameKey = "username"
+
+	// HelmRepositoryPasswordKey is the key, in the HelmRepository Secret, containing
+	// the password used for basic authentication
+	HelmRepositoryPasswordKey = "password"
+
+	// HelmRepositoryBearerTokenKey is the key, in the HelmRepository Secret, containing
+	// the token used to authenticate against OCI registries
+	HelmRepositoryBearerTokenKey = "bearerToken"
+
+	// HelmRepositoryCAKey is the key, in the HelmRepository Secret, containing the
+	// PEM encoded CA bundle used to verify the helm repository certificate
+	HelmRepositoryCAKey = "ca.crt"
+
+	// HelmRepositoryCertKey is the key, in the HelmRepository Secret, containing the
+	// PEM encoded client certificate
+	HelmRepositoryCertKey = "tls.crt"
+
+	// HelmRepositoryKeyKey is the key, in the HelmRepository Secret, containing the
+	// PEM encoded client key
+	HelmRepositoryKeyKey = "tls.key"
+)
+
+// HelmRepositorySpec defines the desired state of HelmRepository
+type HelmRepositorySpec struct {
+	// URL is the URL of the helm repository. Both helm repositories serving an
+	// index file (http:// or https://) and OCI registries (oci://) are supported
+	// +kubebuilder:validation:MinLength=1
+	URL string `json:"url"`
+
+	// SecretRef references a Secret, in the management cluster, containing the credentials
+	// and TLS material used to access the helm repository. Following keys are considered:
+	// - username and password for basic authentication;
+	// - bearerToken for token authentication (OCI registries only);
+	// - ca.crt, tls.crt and tls.key for TLS (helm repositories serving an index file only).
+	// +optional
+	SecretRef *corev1.ObjectReference `json:"secretRef,omitempty"`
+
+	// InsecureSkipTLSVerify, if set, helm repository certificate is not verified.
+	// Considered only for helm repositories serving an index file.
+	// +optional
+	InsecureSkipTLSVerify bool `json:"insecureSkipTLSVerify,omitempty"`
+
+	// PassCredentialsAll, if set, credentials are passed to all domains (for instance
+	// when charts are served by a different domain than the index file)
+	// +optional
+	PassCredentialsAll bool `json:"passCredentialsAll,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:resource:path=helmrepositories,scope=Cluster
+//+kubebuilder:printcolumn:name="URL",type="string",JSONPath=".spec.url",description="Helm repository URL"
+
+// HelmRepository is the Schema for the helmrepositories API
+type HelmRepository struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec HelmRepositorySpec `json:"spec,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// HelmRepositoryList contains a list of HelmRepository
+type HelmRepositoryList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []HelmRepository `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&HelmRepository{}, &HelmRepositoryList{})
+}
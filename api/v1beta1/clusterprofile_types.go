@@ -0,0 +1,799 @@
+/*
+Copyright 2022. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	libsveltosv1alpha1 "github.com/projectsveltos/libsveltos/api/v1alpha1"
+)
+
+const (
+	// ClusterProfileFinalizer allows ClusterProfileReconciler to clean up resources associated with
+	// ClusterProfile before removing it from the apiserver.
+	ClusterProfileFinalizer = "clusterprofilefinalizer.projectsveltos.io"
+
+	ClusterProfileKind = "ClusterProfile"
+
+	// AdminLabel can be set on ClusterProfile to indicate which admin is creating it.
+	// AdminLabel used along with RoleRequest is Sveltos solution for multi tenancy.
+	AdminLabel = "projectsveltos.io/admin-name"
+)
+
+// SyncMode specifies how features are synced in a workload cluster.
+// +kubebuilder:validation:Enum:=OneTime;Continuous;ContinuousWithDriftDetection;DryRun
+type SyncMode string
+
+const (
+	// SyncModeOneTime indicates feature sync should happen only once
+	SyncModeOneTime = SyncMode("OneTime")
+
+	// SyncModeContinuous indicates feature sync should continuously happen
+	SyncModeContinuous = SyncMode("Continuous")
+
+	// SyncModeContinuousWithDriftDetection indicates feature sync should continuously happen
+	// if configuration drift is detected in the managed cluster, it will be overrid
+	SyncModeContinuousWithDriftDetection = SyncMode("ContinuousWithDriftDetection")
+
+	// SyncModeDryRun indicates feature sync should continuously happen
+	// no feature will be updated in the CAPI Cluster though.
+	SyncModeDryRun = SyncMode("DryRun")
+)
+
+// HelmChartAction specifies action on an helm chart
+// +kubebuilder:validation:Enum:=Install;Uninstall
+type HelmChartAction string
+
+const (
+	// HelmChartActionInstall will cause Helm chart to be installed
+	HelmChartActionInstall = HelmChartAction("Install")
+
+	// HelmChartActionUninstall will cause Helm chart to be removed
+	HelmChartActionUninstall = HelmChartAction("Uninstall")
+)
+
+// HelmStorageDriver is the Helm storage backend used to store release information
+// +kubebuilder:validation:Enum:=secret;configmap
+type HelmStorageDriver string
+
+const (
+	// HelmStorageDriverSecret stores release information in Secrets
+	HelmStorageDriverSecret = HelmStorageDriver("secret")
+
+	// HelmStorageDriverConfigMap stores release information in ConfigMaps
+	HelmStorageDriverConfigMap = HelmStorageDriver("configmap")
+)
+
+// HelmAdoptionPolicy specifies what happens when an helm release, with same name and
+// namespace, already exists in the cluster but it was not installed by Sveltos (for
+// instance it was installed manually while bootstrapping the cluster)
+// +kubebuilder:validation:Enum:=Refuse;AdoptIfSameChart;ForceAdopt
+type HelmAdoptionPolicy string
+
+const (
+	// HelmAdoptionPolicyRefuse will cause the existing helm release to be left untouched.
+	// Deployment fails until the helm release is removed
+	HelmAdoptionPolicyRefuse = HelmAdoptionPolicy("Refuse")
+
+	// HelmAdoptionPolicyAdoptIfSameChart will cause the existing helm release to be adopted
+	// only if it was installed from a chart with the same name
+	HelmAdoptionPolicyAdoptIfSameChart = HelmAdoptionPolicy("AdoptIfSameChart")
+
+	// HelmAdoptionPolicyForceAdopt will cause the existing helm release to be adopted
+	// whichever chart it was installed from
+	HelmAdoptionPolicyForceAdopt = HelmAdoptionPolicy("ForceAdopt")
+)
+
+// HelmInstallOptions are the options applied when a Helm release is installed
+type HelmInstallOptions struct {
+	// Wait, if set, waits until all Pods, PVCs, Services, and minimum number of Pods of
+	// a Deployment, StatefulSet, or ReplicaSet are in a ready state before marking the
+	// release as successful. It will wait for as long as Timeout
+	// +optional
+	Wait bool `json:"wait,omitempty"`
+
+	// WaitForJobs, if set and Wait is also set, waits until all Jobs have been completed
+	// before marking the release as successful
+	// +optional
+	WaitForJobs bool `json:"waitForJobs,omitempty"`
+
+	// Timeout is the time to wait for any individual Kubernetes operation (like Jobs
+	// for hooks). Default to 5m
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+
+	// Atomic, if set, deletes the release on failure. Wait is automatically
+	// set if Atomic is set
+	// +optional
+	Atomic bool `json:"atomic,omitempty"`
+
+	// SkipCRDs, if set, no CRDs are installed
+	// +optional
+	SkipCRDs bool `json:"skipCRDs,omitempty"`
+
+	// DisableHooks prevents hooks from running during install
+	// +optional
+	DisableHooks bool `json:"disableHooks,omitempty"`
+
+	// Replace re-uses the given name, only if that name is a deleted release
+	// which remains in the history
+	// +optional
+	Replace bool `json:"replace,omitempty"`
+
+	// DisableOpenAPIValidation, if set, the installation process will not validate
+	// rendered templates against the Kubernetes OpenAPI Schema
+	// +optional
+	DisableOpenAPIValidation bool `json:"disableOpenAPIValidation,omitempty"`
+}
+
+// HelmUpgradeOptions are the options applied when a Helm release is upgraded
+type HelmUpgradeOptions struct {
+	// Wait, if set, waits until all Pods, PVCs, Services, and minimum number of Pods of
+	// a Deployment, StatefulSet, or ReplicaSet are in a ready state before marking the
+	// release as successful. It will wait for as long as Timeout
+	// +optional
+	Wait bool `json:"wait,omitempty"`
+
+	// WaitForJobs, if set and Wait is also set, waits until all Jobs have been completed
+	// before marking the release as successful
+	// +optional
+	WaitForJobs bool `json:"waitForJobs,omitempty"`
+
+	// Timeout is the time to wait for any individual Kubernetes operation (like Jobs
+	// for hooks). Default to 5m
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+
+	// Atomic, if set, rolls back changes made in case of failed upgrade. Wait
+	// is automatically set if Atomic is set
+	// +optional
+	Atomic bool `json:"atomic,omitempty"`
+
+	// SkipCRDs, if set, no CRDs are upgraded
+	// +optional
+	SkipCRDs bool `json:"skipCRDs,omitempty"`
+
+	// DisableHooks prevents hooks from running during upgrade
+	// +optional
+	DisableHooks bool `json:"disableHooks,omitempty"`
+
+	// Force forces resource updates through a replacement strategy
+	// +optional
+	Force bool `json:"force,omitempty"`
+
+	// CleanupOnFail, if set, allows deletion of new resources created in this upgrade
+	// when upgrade fails
+	// +optional
+	CleanupOnFail bool `json:"cleanupOnFail,omitempty"`
+
+	// DisableOpenAPIValidation, if set, the upgrade process will not validate
+	// rendered templates against the Kubernetes OpenAPI Schema
+	// +optional
+	DisableOpenAPIValidation bool `json:"disableOpenAPIValidation,omitempty"`
+}
+
+// HelmUninstallOptions are the options applied when a Helm release is uninstalled
+type HelmUninstallOptions struct {
+	// Wait, if set, waits until all the resources are deleted before returning.
+	// It will wait for as long as Timeout
+	// +optional
+	Wait bool `json:"wait,omitempty"`
+
+	// Timeout is the time to wait for any individual Kubernetes operation (like Jobs
+	// for hooks). Default to 5m
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+
+	// DisableHooks prevents hooks from running during uninstall
+	// +optional
+	DisableHooks bool `json:"disableHooks,omitempty"`
+
+	// KeepHistory, if set, removes all associated resources and marks the release
+	// as deleted, but retains the release history
+	// +optional
+	KeepHistory bool `json:"keepHistory,omitempty"`
+}
+
+// ValuesFrom references a key of a ConfigMap or Secret, in the management cluster,
+// containing values for a Helm release
+type ValuesFrom struct {
+	// Kind of the resource. Supported kinds are: ConfigMap and Secret.
+	// Secret must be of type addons.projectsveltos.io/cluster-profile
+	// +kubebuilder:validation:Enum=ConfigMap;Secret
+	Kind string `json:"kind"`
+
+	// Namespace of the referenced resource.
+	// Namespace can be left empty. In such a case, namespace will
+	// be implicit set to cluster's namespace.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// Name of the referenced resource.
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// Key is the key, in the referenced resource, containing the values (YAML)
+	// +kubebuilder:default:=values.yaml
+	// +optional
+	Key string `json:"key,omitempty"`
+
+	// Template indicates whether values are a Go template. When set, values are
+	// instantiated the same way inline values are.
+	// +optional
+	Template bool `json:"template,omitempty"`
+
+	// Optional indicates whether a missing resource or key is ignored.
+	// When not set, a missing resource or key is an error.
+	// +optional
+	Optional bool `json:"optional,omitempty"`
+}
+
+// HelmOptions are the options applied to the Helm actions taken on a Helm chart
+type HelmOptions struct {
+	// StorageDriver is the Helm storage backend used to store release information
+	// in the managed cluster.
+	// +kubebuilder:default:=secret
+	// +optional
+	StorageDriver HelmStorageDriver `json:"storageDriver,omitempty"`
+
+	// Install are the options applied when Helm release is installed
+	// +optional
+	Install *HelmInstallOptions `json:"install,omitempty"`
+
+	// Upgrade are the options applied when Helm release is upgraded
+	// +optional
+	Upgrade *HelmUpgradeOptions `json:"upgrade,omitempty"`
+
+	// Uninstall are the options applied when Helm release is uninstalled
+	// +optional
+	Uninstall *HelmUninstallOptions `json:"uninstall,omitempty"`
+}
+
+// HelmRemediation defines how an helm release found in a failed or pending
+// state is recovered
+type HelmRemediation struct {
+	// Retries is the maximum number of remediation attempts for an helm release
+	// in failed or pending state. Once retries are exhausted, no further remediation
+	// is attempted till the helm release is successfully deployed again.
+	// +kubebuilder:default:=3
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	Retries int32 `json:"retries,omitempty"`
+
+	// RollbackOnUpgradeFailure, if set, rolls back an helm release whose upgrade
+	// failed (or is stuck pending) to its last deployed revision
+	// +optional
+	RollbackOnUpgradeFailure bool `json:"rollbackOnUpgradeFailure,omitempty"`
+
+	// UninstallOnInstallFailure, if set, uninstalls an helm release whose install
+	// failed (or is stuck pending) so that it can be installed again
+	// +optional
+	UninstallOnInstallFailure bool `json:"uninstallOnInstallFailure,omitempty"`
+
+	// MaxHistory limits the maximum number of revisions saved per helm release.
+	// Use 0 for no limit.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxHistory int32 `json:"maxHistory,omitempty"`
+}
+
+// PatchSelector selects the resources a patch is applied to.
+// Resources must match all fields which are set.
+type PatchSelector struct {
+	// Group of the resources
+	// +optional
+	Group string `json:"group,omitempty"`
+
+	// Version of the resources
+	// +optional
+	Version string `json:"version,omitempty"`
+
+	// Kind of the resources
+	// +optional
+	Kind string `json:"kind,omitempty"`
+
+	// Namespace of the resources. It is a regular expression.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// Name of the resources. It is a regular expression.
+	// +optional
+	Name string `json:"name,omitempty"`
+
+	// LabelSelector is a label selector the resources must match
+	// +optional
+	LabelSelector string `json:"labelSelector,omitempty"`
+
+	// AnnotationSelector is an annotation selector the resources must match
+	// +optional
+	AnnotationSelector string `json:"annotationSelector,omitempty"`
+}
+
+// JSON6902Patch is a JSON6902 patch applied to the selected resources
+type JSON6902Patch struct {
+	// Target selects the resources the patch is applied to
+	Target PatchSelector `json:"target"`
+
+	// Patch contains the JSON6902 operations, in YAML or JSON format
+	// +kubebuilder:validation:MinLength=1
+	Patch string `json:"patch"`
+}
+
+// HelmPostRenderer modifies, using Kustomize, the manifests rendered by Helm
+// before those are deployed
+type HelmPostRenderer struct {
+	// PatchesStrategicMerge contains strategic merge patches. Each patch
+	// identifies the resource it is applied to by apiVersion, kind, name and namespace.
+	// +optional
+	PatchesStrategicMerge []string `json:"patchesStrategicMerge,omitempty"`
+
+	// PatchesJSON6902 contains JSON6902 patches
+	// +optional
+	PatchesJSON6902 []JSON6902Patch `json:"patchesJson6902,omitempty"`
+
+	// Template indicates whether patches are templates. If set, patches are
+	// instantiated using the same resources available to ValuesTemplate.
+	// +optional
+	Template bool `json:"template,omitempty"`
+}
+
+// HelmChartVerify defines how the provenance of an helm chart is verified
+type HelmChartVerify struct {
+	// KeyringSecretRef references a Secret, in the management cluster, containing the
+	// public keyring (keyring key) used to verify the chart provenance file.
+	// Keyring must be in binary format (as exported by gpg --export).
+	KeyringSecretRef corev1.ObjectReference `json:"keyringSecretRef"`
+}
+
+type HelmChart struct {
+	// RepositoryURL is the URL helm chart repository.
+	// Charts stored in OCI registries are referenced using the oci:// scheme
+	// (for instance oci://registry-1.docker.io/bitnamicharts).
+	// Either RepositoryURL or HelmRepositoryRef must be set.
+	// +kubebuilder:validation:MinLength=1
+	// +optional
+	RepositoryURL string `json:"repositoryURL,omitempty"`
+
+	// HelmRepositoryRef is the name of the HelmRepository helm chart is stored in.
+	// When set, URL, credentials and TLS configuration of the HelmRepository are used.
+	// Either RepositoryURL or HelmRepositoryRef must be set.
+	// +optional
+	HelmRepositoryRef string `json:"helmRepositoryRef,omitempty"`
+
+	// RepositoryName is the name helm chart repository
+	// +kubebuilder:validation:MinLength=1
+	RepositoryName string `json:"repositoryName"`
+
+	// ChartName is the chart name
+	// +kubebuilder:validation:MinLength=1
+	ChartName string `json:"chartName"`
+
+	// ChartVersion is the chart version. It can also be a semantic version constraint
+	// (for instance "~1.12" or ">=2.0 <3.0"). In such a case, the latest chart version
+	// matching the constraint is deployed and the helm release is upgraded whenever a newer
+	// matching version is published.
+	// +kubebuilder:validation:MinLength=1
+	ChartVersion string `json:"chartVersion"`
+
+	// ReleaseName is the chart release
+	// +kubebuilder:validation:MinLength=1
+	ReleaseName string `json:"releaseName"`
+
+	// ReleaseNamespace is the namespace release will be installed
+	// +kubebuilder:validation:MinLength=1
+	ReleaseNamespace string `json:"releaseNamespace"`
+
+	// ValuesTemplate holds the values for this Helm release. It is a Go template
+	// which, once instantiated, is parsed as YAML.
+	// Go templating with the values from the referenced CAPI Cluster.
+	// Currently following can be referenced:
+	// - Cluster => CAPI Cluster for instance
+	// - KubeadmControlPlane => the CAPI Cluster controlPlaneRef
+	// - InfrastructureProvider => the CAPI cluster infrastructure provider
+	// - SecretRef => store any confindetial information in a Secret, set SecretRef then reference it
+	// +optional
+	ValuesTemplate string `json:"valuesTemplate,omitempty"`
+
+	// ValuesObject holds values for this Helm release as a structured object.
+	// Contrary to ValuesTemplate, it is not a template.
+	// Values are merged in the following order, a value set later overriding the
+	// one set earlier: ValuesFrom, ValuesTemplate (once instantiated), ValuesObject.
+	// Merged values are validated against the chart values.schema.json, if any.
+	// +kubebuilder:validation:Type=object
+	// +optional
+	ValuesObject *apiextensionsv1.JSON `json:"valuesObject,omitempty"`
+
+	// ValuesFrom references ConfigMaps and Secrets, in the management cluster, containing
+	// values for this Helm release. Values are merged in order, a value set by a later
+	// entry overriding the one set by an earlier entry. ValuesTemplate and ValuesObject, if any, are
+	// merged afterwards so they take precedence over ValuesFrom.
+	// +optional
+	ValuesFrom []ValuesFrom `json:"valuesFrom,omitempty"`
+
+	// SecretRef contains confidential data that needs to be used as values for templates
+	SecretRef *corev1.ObjectReference `json:"secretRef,omitempty"`
+
+	// CredentialsSecretRef references a Secret, in the management cluster, containing
+	// the credentials (username and password keys) used to pull the chart from an
+	// OCI registry
+	// +optional
+	CredentialsSecretRef *corev1.ObjectReference `json:"credentialsSecretRef,omitempty"`
+
+	// HelmChartAction is the action that will be taken on the helm chart
+	// +kubebuilder:default:=Install
+	// +optional
+	HelmChartAction HelmChartAction `json:"helmChartAction,omitempty"`
+
+	// Options are the options applied to the Helm install, upgrade and
+	// uninstall actions
+	// +optional
+	Options *HelmOptions `json:"options,omitempty"`
+
+	// Remediation defines how the helm release is recovered when found in a failed
+	// or pending state. If not set, no remediation is attempted
+	// +optional
+	Remediation *HelmRemediation `json:"remediation,omitempty"`
+
+	// PostRenderers modify the manifests rendered by Helm before those are deployed.
+	// Post renderers are applied in order. Any change to a post renderer (or, for
+	// templated post renderers, to the instantiated patches) triggers an upgrade.
+	// +optional
+	PostRenderers []HelmPostRenderer `json:"postRenderers,omitempty"`
+
+	// AdoptionPolicy defines whether an helm release, already existing in the cluster
+	// but not installed by this ClusterProfile, is adopted. An adopted helm release is
+	// upgraded, so that it is marked as managed by Sveltos. Helm releases installed by
+	// Sveltos versions which did not mark helm releases are not adopted: those are
+	// still managed by the ClusterProfile which installed them.
+	// If not set, AdoptIfSameChart is used.
+	// +optional
+	AdoptionPolicy HelmAdoptionPolicy `json:"adoptionPolicy,omitempty"`
+
+	// Verify, if set, requires the provenance file of the chart to be verified, using
+	// the referenced keyring, before the chart is installed or upgraded. Install or
+	// upgrade fails if chart is not signed or signature does not verify.
+	// +optional
+	Verify *HelmChartVerify `json:"verify,omitempty"`
+}
+
+// StopMatchingBehavior indicates what will happen when Cluster stops matching
+// a ClusterProfile. By default, withdrawpolicies, deployed Helm charts and Kubernetes
+// resources will be removed from Cluster. LeavePolicy instead leaves Helm charts
+// and Kubernetes policies in the Cluster.
+type StopMatchingBehavior string
+
+// Define the StopMatchingBehavior constants.
+const (
+	WithdrawPolicies StopMatchingBehavior = "WithdrawPolicies"
+	LeavePolicies    StopMatchingBehavior = "LeavePolicies"
+)
+
+// RolloutStrategy controls how a change to ClusterProfile Spec is propagated
+// to the matching clusters.
+type RolloutStrategy struct {
+	// MaxUpdate is the maximum number of matching clusters that can be updated
+	// at the same time. Value can be an absolute number (ex: 5) or a percentage
+	// of the matching clusters (ex: 10%). Absolute number is calculated from
+	// percentage by rounding up. Next batch of clusters is updated only once all
+	// clusters in the current batch are provisioned.
+	// If not set, all matching clusters are updated at once.
+	// +kubebuilder:validation:XIntOrString
+	// +optional
+	MaxUpdate *intstr.IntOrString `json:"maxUpdate,omitempty"`
+
+	// FailureBudget is the maximum number of updated clusters which can fail to
+	// be provisioned before rollout is halted. Value can be an absolute number (ex: 2)
+	// or a percentage of the matching clusters (ex: 10%). Absolute number is calculated
+	// from percentage by rounding down.
+	// If not set, rollout is halted as soon as one cluster fails.
+	// +kubebuilder:validation:XIntOrString
+	// +optional
+	FailureBudget *intstr.IntOrString `json:"failureBudget,omitempty"`
+}
+
+// SyncWindowKind specifies whether changes can be deployed while a sync window is open
+// +kubebuilder:validation:Enum:=Allow;Deny
+type SyncWindowKind string
+
+const (
+	// SyncWindowKindAllow indicates changes can only be deployed while window is open
+	SyncWindowKindAllow = SyncWindowKind("Allow")
+
+	// SyncWindowKindDeny indicates changes cannot be deployed while window is open
+	SyncWindowKindDeny = SyncWindowKind("Deny")
+)
+
+// StructuredClusterSelector selects clusters based on label set-based requirements
+// and/or on a CEL expression evaluated against the cluster object.
+type StructuredClusterSelector struct {
+	// LabelSelector selects clusters based on their labels. Contrary to ClusterSelector,
+	// it supports set-based requirements (matchExpressions).
+	// +optional
+	LabelSelector *metav1.LabelSelector `json:"labelSelector,omitempty"`
+
+	// CELExpression is a CEL expression evaluated against each Sveltos/CAPI Cluster.
+	// Following variables are available:
+	// - object: the Cluster/SveltosCluster;
+	// - spec: the Cluster/SveltosCluster spec;
+	// - status: the Cluster/SveltosCluster status.
+	// Expression must evaluate to a boolean. A cluster for which the evaluation fails
+	// (for instance because a referenced field is not set) does not match.
+	// Example: object.spec.infrastructureRef.kind == "AWSCluster"
+	// +optional
+	CELExpression string `json:"celExpression,omitempty"`
+}
+
+// SyncWindow defines a recurring time window when changes can (or cannot)
+// be deployed in the matching clusters.
+type SyncWindow struct {
+	// Kind indicates whether changes are allowed or denied while window is open
+	// +kubebuilder:default:=Allow
+	// +optional
+	Kind SyncWindowKind `json:"kind,omitempty"`
+
+	// Schedule, in Cron format, indicates when window opens.
+	// For instance "0 22 * * 1-5" opens window at 22:00 every weekday.
+	// +kubebuilder:validation:MinLength=1
+	Schedule string `json:"schedule"`
+
+	// Duration indicates how long window stays open (ex: 2h)
+	Duration metav1.Duration `json:"duration"`
+
+	// TimeZone is the name of the time zone (ex: Europe/Rome) Schedule
+	// is evaluated in. Defaults to UTC.
+	// +optional
+	TimeZone string `json:"timeZone,omitempty"`
+}
+
+// ClusterProfileSpec defines the desired state of ClusterProfile
+type ClusterProfileSpec struct {
+	// ClusterSelector identifies clusters to associate to.
+	ClusterSelector libsveltosv1alpha1.Selector `json:"clusterSelector"`
+
+	// ClusterRefs references Sveltos/CAPI Clusters ClusterProfile is associated to
+	// regardless of ClusterSelector. Each reference must contain namespace, name, kind
+	// and apiVersion.
+	// +optional
+	ClusterRefs []corev1.ObjectReference `json:"clusterRefs,omitempty"`
+
+	// ExcludeClusterRefs references Sveltos/CAPI Clusters ClusterProfile is never
+	// associated to, even if matching ClusterSelector or listed in ClusterRefs.
+	// +optional
+	ExcludeClusterRefs []corev1.ObjectReference `json:"excludeClusterRefs,omitempty"`
+
+	// StructuredClusterSelector further restricts the clusters matching ClusterSelector.
+	// A cluster matches only if it matches both ClusterSelector and StructuredClusterSelector.
+	// +optional
+	StructuredClusterSelector *StructuredClusterSelector `json:"structuredClusterSelector,omitempty"`
+
+	// SyncMode specifies how features are synced in a matching workload cluster.
+	// - OneTime means, first time a workload cluster matches the ClusterProfile,
+	// features will be deployed in such cluster. Any subsequent feature configuration
+	// change won't be applied into the matching workload clusters;
+	// - Continuous means first time a workload cluster matches the ClusterProfile,
+	// features will be deployed in such a cluster. Any subsequent feature configuration
+	// change will be applied into the matching workload clusters.
+	// - DryRun means no change will be propagated to any matching cluster. A report
+	// instead will be generated summarizing what would happen in any matching cluster
+	// because of the changes made to ClusterProfile while in DryRun mode.
+	// +kubebuilder:default:=Continuous
+	// +optional
+	SyncMode SyncMode `json:"syncMode,omitempty"`
+
+	// StopMatchingBehavior indicates what behavior should be when a Cluster stop matching
+	// the ClusterProfile. By default all deployed Helm charts and Kubernetes resources will
+	// be withdrawn from Cluster. Setting StopMatchingBehavior to LeavePolicies will instead
+	// leave ClusterProfile deployed policies in the Cluster.
+	// +kubebuilder:default:=WithdrawPolicies
+	// +optional
+	StopMatchingBehavior StopMatchingBehavior `json:"stopMatchingBehavior,omitempty"`
+
+	// PolicyRefs references all the ConfigMaps/Secrets containing kubernetes resources
+	// that need to be deployed in the matching CAPI clusters.
+	// +optional
+	PolicyRefs []libsveltosv1alpha1.PolicyRef `json:"policyRefs,omitempty"`
+
+	// Helm charts
+	HelmCharts []HelmChart `json:"helmCharts,omitempty"`
+
+	// DependsOn lists the ClusterProfiles this ClusterProfile depends on. In any matching
+	// cluster, features are deployed only once features of all the ClusterProfiles listed here
+	// are provisioned in that very same cluster.
+	// Cyclic dependencies are not allowed.
+	// +optional
+	DependsOn []string `json:"dependsOn,omitempty"`
+
+	// SyncWindows restrict when changes are deployed in the matching clusters.
+	// Changes are never deployed while a Deny window is open. If at least one
+	// Allow window is defined, changes are deployed only while an Allow window is open.
+	// Changes are held otherwise. SyncWindows are ignored in DryRun mode.
+	// +optional
+	SyncWindows []SyncWindow `json:"syncWindows,omitempty"`
+
+	// RolloutStrategy, when set, causes any change to ClusterProfile Spec to be
+	// propagated to matching clusters in batches.
+	// RolloutStrategy is only honored when SyncMode is Continuous or ContinuousWithDriftDetection.
+	// +optional
+	RolloutStrategy *RolloutStrategy `json:"rolloutStrategy,omitempty"`
+}
+
+const (
+	// ReadyCondition indicates all features are provisioned.
+	ReadyCondition = "Ready"
+
+	// ProgressingCondition indicates features are being provisioned.
+	ProgressingCondition = "Progressing"
+
+	// DegradedCondition indicates at least one feature failed to be provisioned.
+	DegradedCondition = "Degraded"
+)
+
+const (
+	// ProvisionedReason is used when all features are provisioned in all matching clusters.
+	ProvisionedReason = "Provisioned"
+
+	// ProvisioningReason is used when features are being provisioned in at least one matching cluster.
+	ProvisioningReason = "Provisioning"
+
+	// ProvisioningFailedReason is used when at least one feature failed to be provisioned
+	// in at least one matching cluster.
+	ProvisioningFailedReason = "ProvisioningFailed"
+
+	// InvalidSpecReason is used when ClusterProfile Spec cannot be processed
+	// (for instance because of a dependency cycle or an invalid selector).
+	InvalidSpecReason = "InvalidSpec"
+)
+
+// FeatureClusterCount reports, for a feature, the number of matching clusters
+// in each provisioning state.
+type FeatureClusterCount struct {
+	// FeatureID is the feature.
+	FeatureID FeatureID `json:"featureID"`
+
+	// Provisioning is the number of clusters where the feature is being provisioned.
+	Provisioning int32 `json:"provisioning"`
+
+	// Provisioned is the number of clusters where the feature is provisioned.
+	Provisioned int32 `json:"provisioned"`
+
+	// Failed is the number of clusters where the feature failed to be provisioned.
+	Failed int32 `json:"failed"`
+}
+
+// ClusterProfileClusterStatus reports the state of a matching cluster.
+type ClusterProfileClusterStatus struct {
+	// ClusterRef references the matching cluster.
+	ClusterRef corev1.ObjectReference `json:"clusterRef"`
+
+	// ObservedGeneration is the most recent ClusterProfile generation whose features
+	// are all provisioned in the cluster. Zero if none was provisioned yet.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
+
+// FailingCluster reports a matching cluster where a feature failed to be provisioned.
+type FailingCluster struct {
+	// ClusterRef references the matching cluster.
+	ClusterRef corev1.ObjectReference `json:"clusterRef"`
+
+	// FeatureID is the feature that failed to be provisioned.
+	FeatureID FeatureID `json:"featureID"`
+
+	// FailureMessage provides more information about the failure.
+	// +optional
+	FailureMessage *string `json:"failureMessage,omitempty"`
+}
+
+// RolloutStatus summarizes the progress of ClusterProfile Spec being propagated
+// to matching clusters.
+type RolloutStatus struct {
+	// UpdatedClusters is the number of matching clusters whose ClusterSummary
+	// has current ClusterProfile Spec
+	UpdatedClusters int32 `json:"updatedClusters"`
+
+	// ProvisionedClusters is the number of updated clusters where all features
+	// are provisioned
+	ProvisionedClusters int32 `json:"provisionedClusters"`
+
+	// FailedClusters is the number of updated clusters where at least one
+	// feature failed to be provisioned
+	FailedClusters int32 `json:"failedClusters"`
+
+	// PendingClusters is the number of matching clusters still waiting to
+	// receive current ClusterProfile Spec
+	PendingClusters int32 `json:"pendingClusters"`
+
+	// Halted is true when rollout has been stopped because FailedClusters
+	// exceeded the failure budget
+	// +optional
+	Halted bool `json:"halted,omitempty"`
+
+	// HaltReason indicates why rollout has been halted
+	// +optional
+	HaltReason *string `json:"haltReason,omitempty"`
+}
+
+// ClusterProfileStatus defines the observed state of ClusterProfile
+type ClusterProfileStatus struct {
+	// MatchingClusterRefs reference all the cluster-api Cluster currently matching
+	// ClusterProfile ClusterSelector
+	// +optional
+	MatchingClusterRefs []corev1.ObjectReference `json:"matchingClusterRefs,omitempty"`
+
+	// RolloutStatus reports the progress of the rollout. It is only set when
+	// ClusterProfile Spec has a RolloutStrategy.
+	// +optional
+	RolloutStatus *RolloutStatus `json:"rolloutStatus,omitempty"`
+
+	// FailureMessage provides more information if ClusterProfile cannot be processed
+	// (for instance because of a dependency cycle)
+	// +optional
+	FailureMessage *string `json:"failureMessage,omitempty"`
+
+	// FeatureClusterCounts reports, for each feature, the number of matching clusters
+	// where the feature is provisioning, provisioned or failed.
+	// +optional
+	FeatureClusterCounts []FeatureClusterCount `json:"featureClusterCounts,omitempty"`
+
+	// ClusterStatuses reports, for each matching cluster, the most recent ClusterProfile
+	// generation fully provisioned in the cluster.
+	// +optional
+	ClusterStatuses []ClusterProfileClusterStatus `json:"clusterStatuses,omitempty"`
+
+	// FailingClusters lists the matching clusters where at least one feature failed
+	// to be provisioned.
+	// +optional
+	FailingClusters []FailingCluster `json:"failingClusters,omitempty"`
+
+	// Conditions contains the Ready, Progressing and Degraded conditions.
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:resource:path=clusterprofiles,scope=Cluster
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].status"
+//+kubebuilder:printcolumn:name="Reason",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].reason"
+//+kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// ClusterProfile is the Schema for the clusterprofiles API
+type ClusterProfile struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ClusterProfileSpec   `json:"spec,omitempty"`
+	Status ClusterProfileStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// ClusterProfileList contains a list of ClusterProfile
+type ClusterProfileList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ClusterProfile `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ClusterProfile{}, &ClusterProfileList{})
+}
@@ -0,0 +1,188 @@
+/*
+Copyright 2022. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	ClusterReportKind = "ClusterReport"
+)
+
+// HelmAction represents the type of action on a give resource or helm release
+type HelmAction string
+
+// Define the HelmAction constants.
+const (
+	NoHelmAction        HelmAction = "No Action"
+	InstallHelmAction   HelmAction = "Install"
+	UpgradeHelmAction   HelmAction = "Upgrade"
+	UninstallHelmAction HelmAction = "Delete"
+	ConflictHelmAction  HelmAction = "Conflict"
+)
+
+type ResourceAction string
+
+// Define the Action constants.
+const (
+	NoResourceAction       ResourceAction = "No Action"
+	CreateResourceAction   ResourceAction = "Create"
+	UpdateResourceAction   ResourceAction = "Update"
+	DeleteResourceAction   ResourceAction = "Delete"
+	ConflictResourceAction ResourceAction = "Conflict"
+)
+
+type ReleaseReport struct {
+	// ReleaseName of the release deployed in the CAPI Cluster.
+	// +kubebuilder:validation:MinLength=1
+	ReleaseName string `json:"releaseName"`
+
+	// Namespace where release is deployed in the CAPI Cluster.
+	// +kubebuilder:validation:MinLength=1
+	ReleaseNamespace string `json:"releaseNamespace"`
+
+	// ChartVersion is the version of the helm chart deployed
+	// in the CAPI Cluster.
+	ChartVersion string `json:"chartVersion"`
+
+	// ChartRef is the reference of the helm chart pulled from an OCI
+	// registry (oci://<registry>/<repository>/<chart>:<version>).
+	// Not set for charts stored in helm repositories.
+	// +optional
+	ChartRef string `json:"chartRef,omitempty"`
+
+	// Action represent the type of operation on the Helm Chart
+	// +kubebuilder:validation:Enum=No Action;Install;Upgrade;Delete;Conflict
+	// +optional
+	Action HelmAction `json:"action,omitempty"`
+
+	// Message is for any message that needs to added to better
+	// explain the action.
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// Remediation describes the remediation applied to the helm release,
+	// found in a failed or pending state, before action was taken.
+	// +optional
+	Remediation string `json:"remediation,omitempty"`
+
+	// Adopted is set if the helm release, which was not installed by this ClusterProfile,
+	// was adopted (in DryRun mode, if it would be adopted).
+	// +optional
+	Adopted bool `json:"adopted,omitempty"`
+
+	// ResourceChanges lists, in DryRun mode only, the resources the helm release
+	// would create, update or delete. It is obtained comparing the manifest of the
+	// release rendered by Helm against the managed cluster with the manifest of the
+	// currently deployed release. Helm hooks are not considered.
+	// +optional
+	ResourceChanges []ReleaseResourceChange `json:"resourceChanges,omitempty"`
+}
+
+// ReleaseResourceChange describes how a resource part of an helm release would change
+type ReleaseResourceChange struct {
+	// Name of the resource
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// Namespace of the resource. Empty for resources scoped at cluster level and for
+	// resources whose namespace is not set in the helm chart templates.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// Group of the resource
+	Group string `json:"group"`
+
+	// Version of the resource
+	Version string `json:"version"`
+
+	// Kind of the resource
+	// +kubebuilder:validation:MinLength=1
+	Kind string `json:"kind"`
+
+	// Action represent the type of change on the resource
+	// +kubebuilder:validation:Enum=Create;Update;Delete
+	Action ResourceAction `json:"action"`
+
+	// Diff is the unified diff between the deployed resource and the
+	// requested one. Only set for resources which would be updated.
+	// +optional
+	Diff string `json:"diff,omitempty"`
+}
+
+type ResourceReport struct {
+	// Resource contains information about Kubernetes Resource
+	Resource Resource `json:"resource"`
+
+	// Action represent the type of operation on the Kubernetes resource.
+	// +kubebuilder:validation:Enum=No Action;Create;Update;Delete;Conflict
+	Action ResourceAction `json:"action,omitempty"`
+
+	// Message is for any message that needs to added to better
+	// explain the action.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// ClusterReportSpec defines the desired state of ClusterReport
+type ClusterReportSpec struct {
+	// ClusterNamespace is the namespace of the CAPI Cluster this
+	// ClusterReport is for.
+	ClusterNamespace string `json:"clusterNamespace"`
+
+	// ClusterName is the name of the CAPI Cluster this ClusterReport
+	// is for.
+	ClusterName string `json:"clusterName"`
+}
+
+// ClusterReportStatus defines the observed state of ClusterReport
+type ClusterReportStatus struct {
+	// ReleaseReports contains report on helm releases
+	// +optional
+	ReleaseReports []ReleaseReport `json:"releaseReports,omitempty"`
+
+	// ResourceReports contains report on Kubernetes resources
+	// +optional
+	ResourceReports []ResourceReport `json:"resourceReports,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:resource:path=clusterreports,scope=Namespaced
+//+kubebuilder:subresource:status
+
+// ClusterReport is the Schema for the clusterreports API
+type ClusterReport struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ClusterReportSpec   `json:"spec,omitempty"`
+	Status ClusterReportStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// ClusterReportList contains a list of ClusterReport
+type ClusterReportList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ClusterReport `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ClusterReport{}, &ClusterReportList{})
+}
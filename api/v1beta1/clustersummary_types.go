@@ -0,0 +1,306 @@
+/*
+Copyright 2022-23. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	libsveltosv1alpha1 "github.com/projectsveltos/libsveltos/api/v1alpha1"
+)
+
+const (
+	// ClusterSummaryFinalizer allows ClusterSummaryReconciler to clean up resources associated with
+	// ClusterSummary before removing it from the apiserver.
+	ClusterSummaryFinalizer = "clustersummaryfinalizer.projectsveltos.io"
+
+	ClusterSummaryKind = "ClusterSummary"
+)
+
+// +kubebuilder:validation:Enum:=Resources;Helm
+type FeatureID string
+
+const (
+	// FeatureResources is the identifier for generic Resources feature
+	FeatureResources = FeatureID("Resources")
+
+	// FeatureHelm is the identifier for Helm feature
+	FeatureHelm = FeatureID("Helm")
+)
+
+// +kubebuilder:validation:Enum:=Provisioning;Provisioned;Failed;Removing;Removed;WaitingForWindow
+type FeatureStatus string
+
+const (
+	// FeatureStatusProvisioning indicates that feature is being
+	// provisioned in the workload cluster
+	FeatureStatusProvisioning = FeatureStatus("Provisioning")
+
+	// FeatureStatusProvisioned indicates that feature has being
+	// provisioned in the workload cluster
+	FeatureStatusProvisioned = FeatureStatus("Provisioned")
+
+	// FeatureStatusFailed indicates that configuring the feature
+	// in the workload cluster failed
+	FeatureStatusFailed = FeatureStatus("Failed")
+
+	// FeatureStatusRemoving indicates that feature is being
+	// removed
+	FeatureStatusRemoving = FeatureStatus("Removing")
+
+	// FeatureStatusRemoved indicates that feature is removed
+	FeatureStatusRemoved = FeatureStatus("Removed")
+
+	// FeatureStatusWaitingForWindow indicates that feature configuration
+	// needs to be deployed but that is held till next sync window opens
+	FeatureStatusWaitingForWindow = FeatureStatus("WaitingForWindow")
+)
+
+// FeatureSummary contains a summary of the state of a workload
+// cluster feature.
+type FeatureSummary struct {
+	// FeatureID is an indentifier of the feature whose status is reported
+	FeatureID FeatureID `json:"featureID"`
+
+	// Hash represents of a unique value for a feature at a fixed point in
+	// time
+	// +optional
+	Hash []byte `json:"hash,omitempty"`
+
+	// Status represents the state of the feature in the workload cluster
+	Status FeatureStatus `json:"status"`
+
+	// FailureReason indicates the type of error that occurred.
+	// +optional
+	FailureReason *string `json:"failureReason,omitempty"`
+
+	// FailureMessage provides more information about the error.
+	// +optional
+	FailureMessage *string `json:"failureMessage,omitempty"`
+
+	// DeployedGroupVersionKind contains all GroupVersionKinds deployed in the workload
+	// cluster because of this feature. Each element has format kind.version.group
+	// +optional
+	DeployedGroupVersionKind []string `json:"deployedGroupVersionKind,omitempty"`
+
+	// LastAppliedTime is the time feature was last reconciled
+	// +optional
+	LastAppliedTime *metav1.Time `json:"lastAppliedTime,omitempty"`
+
+	// DeployedGeneration is the ClusterSummary generation whose Spec feature Hash
+	// and Status were last evaluated against
+	// +optional
+	DeployedGeneration int64 `json:"deployedGeneration,omitempty"`
+}
+
+// HelChartStatus specifies whether ClusterSummary is successfully managing
+// an helm chart or not
+// +kubebuilder:validation:Enum:=Managing;Conflict
+type HelmChartStatus string
+
+const (
+	// HelChartStatusManaging indicates helm chart is successfully being managed
+	HelChartStatusManaging = HelmChartStatus("Managing")
+
+	// HelChartStatusConflict indicates there is a conflict with another
+	// ClusterSummary to manage the helm chart
+	HelChartStatusConflict = HelmChartStatus("Conflict")
+)
+
+type HelmChartSummary struct {
+	// ReleaseName is the chart release
+	// +kubebuilder:validation:MinLength=1
+	ReleaseName string `json:"releaseName"`
+
+	// ReleaseNamespace is the namespace release will be installed
+	// +kubebuilder:validation:MinLength=1
+	ReleaseNamespace string `json:"releaseNamespace"`
+
+	// Status indicates whether ClusterSummary can manage the helm
+	// chart or there is a conflict
+	Status HelmChartStatus `json:"status"`
+
+	// Status indicates whether ClusterSummary can manage the helm
+	// chart or there is a conflict
+	// +optional
+	ConflictMessage string `json:"conflictMessage,omitempty"`
+
+	// RemediationAttempts is the number of remediation attempts since
+	// helm release was last successfully deployed
+	// +optional
+	RemediationAttempts int32 `json:"remediationAttempts,omitempty"`
+
+	// LastRemediation describes the last remediation applied to the helm release
+	// +optional
+	LastRemediation string `json:"lastRemediation,omitempty"`
+
+	// PostRenderersHash is the hash of the (instantiated) post renderers the helm
+	// release was last deployed with
+	// +optional
+	PostRenderersHash string `json:"postRenderersHash,omitempty"`
+
+	// ResolvedChartVersion is the chart version the HelmChart ChartVersion constraint
+	// was last resolved to. Not set when ChartVersion is not a constraint.
+	// +optional
+	ResolvedChartVersion string `json:"resolvedChartVersion,omitempty"`
+
+	// AdoptionPending is set when ClusterSummary starts managing the helm release and
+	// reset once the helm release is installed, or allowed to be adopted, by ClusterSummary.
+	// Helm releases listed with AdoptionPending not set, and not marked as managed by any
+	// ClusterSummary, were installed by Sveltos versions which did not mark helm releases.
+	// +optional
+	AdoptionPending bool `json:"adoptionPending,omitempty"`
+}
+
+// ClusterSummarySpec defines the desired state of ClusterSummary
+type ClusterSummarySpec struct {
+	// ClusterNamespace is the namespace of the workload Cluster this
+	// ClusterSummary is for.
+	ClusterNamespace string `json:"clusterNamespace"`
+
+	// ClusterName is the name of the workload Cluster this ClusterSummary is for.
+	ClusterName string `json:"clusterName"`
+
+	// ClusterType is the type of Cluster
+	ClusterType libsveltosv1alpha1.ClusterType `json:"clusterType"`
+
+	// ClusterProfileSpec represent the configuration that will be applied to
+	// the workload cluster.
+	ClusterProfileSpec ClusterProfileSpec `json:"clusterProfileSpec,omitempty"`
+}
+
+const (
+	// PausedCondition indicates Sveltos/CAPI Cluster or ClusterSummary is paused.
+	// No feature is deployed while paused.
+	PausedCondition = "Paused"
+
+	// ClusterReachableCondition indicates Sveltos/CAPI Cluster exists and is ready
+	// to be configured.
+	ClusterReachableCondition = "ClusterReachable"
+
+	// DependenciesProvisionedCondition indicates all ClusterProfiles listed in DependsOn
+	// are provisioned in the cluster. No feature is deployed till then.
+	DependenciesProvisionedCondition = "DependenciesProvisioned"
+
+	// HelmConflictCondition indicates at least one helm chart cannot be managed
+	// because another ClusterSummary is already managing it.
+	HelmConflictCondition = "HelmConflict"
+
+	// ResourcesAppliedCondition indicates Resources feature is provisioned.
+	ResourcesAppliedCondition = "ResourcesApplied"
+
+	// HelmAppliedCondition indicates Helm feature is provisioned.
+	HelmAppliedCondition = "HelmApplied"
+)
+
+const (
+	// PausedReason is used when Sveltos/CAPI Cluster or ClusterSummary is paused.
+	PausedReason = "Paused"
+
+	// NotPausedReason is used when neither Sveltos/CAPI Cluster nor ClusterSummary is paused.
+	NotPausedReason = "NotPaused"
+
+	// ClusterReadyReason is used when Sveltos/CAPI Cluster is ready to be configured.
+	ClusterReadyReason = "ClusterReady"
+
+	// ClusterNotReadyReason is used when Sveltos/CAPI Cluster is not ready to be configured yet.
+	ClusterNotReadyReason = "ClusterNotReady"
+
+	// ClusterNotFoundReason is used when Sveltos/CAPI Cluster does not exist.
+	ClusterNotFoundReason = "ClusterNotFound"
+
+	// DependenciesProvisionedReason is used when all ClusterProfiles listed in DependsOn are provisioned.
+	DependenciesProvisionedReason = "DependenciesProvisioned"
+
+	// WaitingForDependenciesReason is used when features are held till all ClusterProfiles listed
+	// in DependsOn are provisioned.
+	WaitingForDependenciesReason = "WaitingForDependencies"
+
+	// HelmChartConflictReason is used when at least one helm chart is managed by another ClusterSummary.
+	HelmChartConflictReason = "HelmChartConflict"
+
+	// NoConflictReason is used when all helm charts are managed by this ClusterSummary.
+	NoConflictReason = "NoConflict"
+
+	// WaitingForSyncWindowReason is used when feature changes are held till next sync window opens.
+	WaitingForSyncWindowReason = "WaitingForSyncWindow"
+
+	// NothingToApplyReason is used when feature is not configured.
+	NothingToApplyReason = "NothingToApply"
+)
+
+// ClusterSummaryStatus defines the observed state of ClusterSummary
+type ClusterSummaryStatus struct {
+	// ObservedGeneration is the most recent ClusterSummary generation
+	// whose features were all evaluated by the controller. It is not updated
+	// while ClusterSummary is paused, cluster is not reachable or dependencies
+	// are not provisioned.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// FeatureSummaries reports the status of each workload cluster feature
+	// directly managed by ClusterProfile.
+	// +listType=atomic
+	// +optional
+	FeatureSummaries []FeatureSummary `json:"featureSummaries,omitempty"`
+
+	// HelmReleaseSummaries reports the status of each helm chart
+	// directly managed by ClusterProfile.
+	// +listType=atomic
+	// +optional
+	HelmReleaseSummaries []HelmChartSummary `json:"helmReleaseSummaries,omitempty"`
+
+	// NextSyncTime, when set, indicates when changes currently held because of
+	// ClusterProfile SyncWindows will be evaluated again
+	// +optional
+	NextSyncTime *metav1.Time `json:"nextSyncTime,omitempty"`
+
+	// Conditions reports Ready, Paused, ClusterReachable, HelmConflict,
+	// ResourcesApplied and HelmApplied conditions.
+	// +listType=map
+	// +listMapKey=type
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:resource:path=clustersummaries,scope=Namespaced
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].status"
+//+kubebuilder:printcolumn:name="Reason",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].reason"
+//+kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// ClusterSummary is the Schema for the clustersummaries API
+type ClusterSummary struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ClusterSummarySpec   `json:"spec,omitempty"`
+	Status ClusterSummaryStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// ClusterSummaryList contains a list of ClusterSummary
+type ClusterSummaryList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ClusterSummary `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ClusterSummary{}, &ClusterSummaryList{})
+}
@@ -0,0 +1,191 @@
+/*
+Copyright 2022-23. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"encoding/json"
+
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+
+	"github.com/projectsveltos/sveltos-manager/api/v1alpha1"
+)
+
+// Fields which have the same JSON name in v1alpha1 and v1beta1 are converted by
+// serializing the source to JSON and deserializing it into the destination.
+// Only fields renamed in v1beta1 are converted explicitly:
+// - HelmChart Values (v1beta1 ValuesTemplate);
+// - ClusterProfileStatus MatchingClusterRefs (serialized as matchingClusters in v1alpha1);
+// - ClusterProfileResource Features (serialized as Features in v1alpha1);
+// - ReleaseReport ReleaseName (serialized as chartName in v1alpha1).
+
+func convertThroughJSON(src, dst interface{}) error {
+	data, err := json.Marshal(src)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, dst)
+}
+
+func convertHelmChartsTo(src []HelmChart, dst []v1alpha1.HelmChart) {
+	for i := range src {
+		dst[i].Values = src[i].ValuesTemplate
+	}
+}
+
+func convertHelmChartsFrom(src []v1alpha1.HelmChart, dst []HelmChart) {
+	for i := range src {
+		dst[i].ValuesTemplate = src[i].Values
+	}
+}
+
+// ConvertTo converts this ClusterProfile to the Hub version (v1alpha1).
+func (src *ClusterProfile) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*v1alpha1.ClusterProfile)
+	dst.ObjectMeta = src.ObjectMeta
+
+	if err := convertThroughJSON(&src.Spec, &dst.Spec); err != nil {
+		return err
+	}
+	convertHelmChartsTo(src.Spec.HelmCharts, dst.Spec.HelmCharts)
+
+	if err := convertThroughJSON(&src.Status, &dst.Status); err != nil {
+		return err
+	}
+	dst.Status.MatchingClusterRefs = src.Status.MatchingClusterRefs
+
+	return nil
+}
+
+// ConvertFrom converts from the Hub version (v1alpha1) to this version.
+func (dst *ClusterProfile) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*v1alpha1.ClusterProfile)
+	dst.ObjectMeta = src.ObjectMeta
+
+	if err := convertThroughJSON(&src.Spec, &dst.Spec); err != nil {
+		return err
+	}
+	convertHelmChartsFrom(src.Spec.HelmCharts, dst.Spec.HelmCharts)
+
+	if err := convertThroughJSON(&src.Status, &dst.Status); err != nil {
+		return err
+	}
+	dst.Status.MatchingClusterRefs = src.Status.MatchingClusterRefs
+
+	return nil
+}
+
+// ConvertTo converts this ClusterSummary to the Hub version (v1alpha1).
+func (src *ClusterSummary) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*v1alpha1.ClusterSummary)
+	dst.ObjectMeta = src.ObjectMeta
+
+	if err := convertThroughJSON(&src.Spec, &dst.Spec); err != nil {
+		return err
+	}
+	convertHelmChartsTo(src.Spec.ClusterProfileSpec.HelmCharts, dst.Spec.ClusterProfileSpec.HelmCharts)
+
+	return convertThroughJSON(&src.Status, &dst.Status)
+}
+
+// ConvertFrom converts from the Hub version (v1alpha1) to this version.
+func (dst *ClusterSummary) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*v1alpha1.ClusterSummary)
+	dst.ObjectMeta = src.ObjectMeta
+
+	if err := convertThroughJSON(&src.Spec, &dst.Spec); err != nil {
+		return err
+	}
+	convertHelmChartsFrom(src.Spec.ClusterProfileSpec.HelmCharts, dst.Spec.ClusterProfileSpec.HelmCharts)
+
+	return convertThroughJSON(&src.Status, &dst.Status)
+}
+
+// ConvertTo converts this ClusterConfiguration to the Hub version (v1alpha1).
+func (src *ClusterConfiguration) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*v1alpha1.ClusterConfiguration)
+	dst.ObjectMeta = src.ObjectMeta
+
+	if err := convertThroughJSON(&src.Status, &dst.Status); err != nil {
+		return err
+	}
+	for i := range src.Status.ClusterProfileResources {
+		var features []v1alpha1.Feature
+		if err := convertThroughJSON(src.Status.ClusterProfileResources[i].Features, &features); err != nil {
+			return err
+		}
+		dst.Status.ClusterProfileResources[i].Features = features
+	}
+
+	return nil
+}
+
+// ConvertFrom converts from the Hub version (v1alpha1) to this version.
+func (dst *ClusterConfiguration) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*v1alpha1.ClusterConfiguration)
+	dst.ObjectMeta = src.ObjectMeta
+
+	if err := convertThroughJSON(&src.Status, &dst.Status); err != nil {
+		return err
+	}
+	for i := range src.Status.ClusterProfileResources {
+		var features []Feature
+		if err := convertThroughJSON(src.Status.ClusterProfileResources[i].Features, &features); err != nil {
+			return err
+		}
+		dst.Status.ClusterProfileResources[i].Features = features
+	}
+
+	return nil
+}
+
+// ConvertTo converts this ClusterReport to the Hub version (v1alpha1).
+func (src *ClusterReport) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*v1alpha1.ClusterReport)
+	dst.ObjectMeta = src.ObjectMeta
+
+	if err := convertThroughJSON(&src.Spec, &dst.Spec); err != nil {
+		return err
+	}
+
+	if err := convertThroughJSON(&src.Status, &dst.Status); err != nil {
+		return err
+	}
+	for i := range src.Status.ReleaseReports {
+		dst.Status.ReleaseReports[i].ReleaseName = src.Status.ReleaseReports[i].ReleaseName
+	}
+
+	return nil
+}
+
+// ConvertFrom converts from the Hub version (v1alpha1) to this version.
+func (dst *ClusterReport) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*v1alpha1.ClusterReport)
+	dst.ObjectMeta = src.ObjectMeta
+
+	if err := convertThroughJSON(&src.Spec, &dst.Spec); err != nil {
+		return err
+	}
+
+	if err := convertThroughJSON(&src.Status, &dst.Status); err != nil {
+		return err
+	}
+	for i := range src.Status.ReleaseReports {
+		dst.Status.ReleaseReports[i].ReleaseName = src.Status.ReleaseReports[i].ReleaseName
+	}
+
+	return nil
+}
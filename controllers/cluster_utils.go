@@ -18,7 +18,6 @@ package controllers
 
 import (
 	"context"
-	"os"
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/types"
@@ -124,16 +123,10 @@ func getKubernetesRestConfigForAdmin(ctx context.Context, c client.Client, clust
 		return nil, err
 	}
 
-	kubeconfig, err := clusterproxy.CreateKubeconfig(logger, kubeconfigContent)
+	config, err := clientcmd.RESTConfigFromKubeConfig(kubeconfigContent)
 	if err != nil {
-		return nil, err
-	}
-	defer os.Remove(kubeconfig)
-
-	config, err := clientcmd.BuildConfigFromFlags("", kubeconfig)
-	if err != nil {
-		logger.Error(err, "BuildConfigFromFlags")
-		return nil, errors.Wrap(err, "BuildConfigFromFlags")
+		logger.Error(err, "RESTConfigFromKubeConfig")
+		return nil, errors.Wrap(err, "RESTConfigFromKubeConfig")
 	}
 
 	return config, nil
@@ -203,3 +196,18 @@ func getClusterType(cluster *corev1.ObjectReference) libsveltosv1alpha1.ClusterT
 	}
 	return clusterType
 }
+
+// getClusterRef returns the ObjectReference for a Sveltos/CAPI Cluster
+func getClusterRef(clusterNamespace, clusterName string, clusterType libsveltosv1alpha1.ClusterType) *corev1.ObjectReference {
+	ref := &corev1.ObjectReference{
+		Namespace:  clusterNamespace,
+		Name:       clusterName,
+		Kind:       "Cluster",
+		APIVersion: clusterv1.GroupVersion.String(),
+	}
+	if clusterType == libsveltosv1alpha1.ClusterTypeSveltos {
+		ref.Kind = libsveltosv1alpha1.SveltosClusterKind
+		ref.APIVersion = libsveltosv1alpha1.GroupVersion.String()
+	}
+	return ref
+}
@@ -20,13 +20,13 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"strings"
 	"sync"
 
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/util/retry"
@@ -37,6 +37,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	"sigs.k8s.io/controller-runtime/pkg/source"
 
@@ -55,12 +56,15 @@ type ClusterProfileReconciler struct {
 	ConcurrentReconciles int
 	// use a Mutex to update Map as MaxConcurrentReconciles is higher than one
 	Mux sync.Mutex
-	// key: Sveltos/CAPI Cluster; value: set of all ClusterProfiles matching the Cluster
+	// key: Sveltos/CAPI Cluster; value: set of all ClusterProfiles matching or referencing (ClusterRefs) the Cluster
 	ClusterMap map[corev1.ObjectReference]*libsveltosset.Set
-	// key: ClusterProfile; value: set of Sveltos/CAPI Clusters matched
+	// key: ClusterProfile; value: set of Sveltos/CAPI Clusters matched or referenced (ClusterRefs)
 	ClusterProfileMap map[corev1.ObjectReference]*libsveltosset.Set
 	// key: ClusterProfile; value ClusterProfile Selector
 	ClusterProfiles map[corev1.ObjectReference]libsveltosv1alpha1.Selector
+	// set of ClusterProfiles selecting clusters with a CEL expression. As CEL expression can evaluate
+	// Sveltos/CAPI Cluster status, Cluster status changes are watched while this set is not empty.
+	CELClusterProfiles libsveltosset.Set
 
 	// Reason for the two maps:
 	// ClusterProfile, via ClusterSelector, matches Sveltos/CAPI Clusters based on Cluster labels.
@@ -91,7 +95,8 @@ type ClusterProfileReconciler struct {
 //+kubebuilder:rbac:groups=config.projectsveltos.io,resources=clusterprofiles,verbs=get;list;watch;update;patch
 //+kubebuilder:rbac:groups=config.projectsveltos.io,resources=clusterprofiles/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=config.projectsveltos.io,resources=clusterprofiles/finalizers,verbs=update;patch
-//+kubebuilder:rbac:groups=config.projectsveltos.io,resources=clustersummaries,verbs=get;list;update;create;delete
+//+kubebuilder:rbac:groups=config.projectsveltos.io,resources=clustersummaries,verbs=get;list;watch;update;create;delete
+//+kubebuilder:rbac:groups=config.projectsveltos.io,resources=clustersummaries/status,verbs=get;update
 //+kubebuilder:rbac:groups=config.projectsveltos.io,resources=clusterreports,verbs=get;list;update;create;watch;delete
 //+kubebuilder:rbac:groups=config.projectsveltos.io,resources=clusterconfigurations,verbs=get;list;update;create;watch;delete
 //+kubebuilder:rbac:groups=cluster.x-k8s.io,resources=clusters,verbs=get;watch;list
@@ -210,6 +215,31 @@ func (r *ClusterProfileReconciler) reconcileNormal(
 		}
 	}
 
+	// Reject any change introducing a dependency cycle. ClusterSummaries are left untouched.
+	cycle, err := getDependencyCycle(ctx, r.Client, clusterProfileScope.ClusterProfile)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if cycle != nil {
+		failureMessage := fmt.Sprintf("dependency cycle detected: %s", strings.Join(cycle, " -> "))
+		logger.V(logs.LogInfo).Info(failureMessage)
+		clusterProfileScope.SetFailureMessage(&failureMessage)
+		setClusterProfileInvalidSpecConditions(clusterProfileScope, failureMessage)
+		// Cycle might be fixed by changing any other ClusterProfile. Keep checking.
+		return reconcile.Result{Requeue: true, RequeueAfter: normalRequeueAfter}, nil
+	}
+	clusterProfileScope.SetFailureMessage(nil)
+
+	// An invalid selector cannot match any cluster. ClusterSummaries are left untouched
+	// till selectors are fixed.
+	if _, err := getClusterMatcher(clusterProfileScope.ClusterProfile); err != nil {
+		failureMessage := err.Error()
+		logger.V(logs.LogInfo).Info(failureMessage)
+		clusterProfileScope.SetFailureMessage(&failureMessage)
+		setClusterProfileInvalidSpecConditions(clusterProfileScope, failureMessage)
+		return reconcile.Result{}, nil
+	}
+
 	matchingCluster, err := r.getMatchingClusters(ctx, clusterProfileScope)
 	if err != nil {
 		return reconcile.Result{}, err
@@ -247,6 +277,19 @@ func (r *ClusterProfileReconciler) reconcileNormal(
 		return reconcile.Result{}, err
 	}
 
+	// Aggregate ClusterSummary statuses
+	if err := r.updateClusterProfileStatus(ctx, clusterProfileScope); err != nil {
+		logger.V(logs.LogInfo).Error(err, "failed to update ClusterProfile status")
+		return reconcile.Result{}, err
+	}
+
+	// ClusterSummary status changes trigger a reconciliation. Still, while a rollout is in progress
+	// (or halted) keep checking ClusterSummary statuses so rollout never gets stuck on a missed event.
+	if !isRolloutCompleted(clusterProfileScope.ClusterProfile.Status.RolloutStatus) {
+		logger.V(logs.LogInfo).Info("Rollout in progress")
+		return reconcile.Result{Requeue: true, RequeueAfter: normalRequeueAfter}, nil
+	}
+
 	logger.V(logs.LogInfo).Info("Reconcile success")
 	return reconcile.Result{}, nil
 }
@@ -270,7 +313,20 @@ func (r *ClusterProfileReconciler) SetupWithManager(mgr ctrl.Manager) (controlle
 	// one or more ClusterProfiles need to be reconciled.
 	err = c.Watch(&source.Kind{Type: &libsveltosv1alpha1.SveltosCluster{}},
 		handler.EnqueueRequestsFromMapFunc(r.requeueClusterProfileForCluster),
-		SveltosClusterPredicates(mgr.GetLogger().WithValues("predicate", "sveltosclusterpredicate")),
+		predicate.Or(
+			SveltosClusterPredicates(mgr.GetLogger().WithValues("predicate", "sveltosclusterpredicate")),
+			ClusterStatusPredicates(r.isCELSelectorUsed, mgr.GetLogger().WithValues("predicate", "clusterstatuspredicate")),
+		),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// When ClusterSummary status changes, according to ClusterSummaryPredicates,
+	// ClusterProfile owning it needs to be reconciled to update its aggregated status.
+	err = c.Watch(&source.Kind{Type: &configv1alpha1.ClusterSummary{}},
+		handler.EnqueueRequestsFromMapFunc(r.requeueClusterProfileForClusterSummary),
+		ClusterSummaryPredicates(mgr.GetLogger().WithValues("predicate", "clustersummarypredicate")),
 	)
 
 	return c, err
@@ -281,7 +337,10 @@ func (r *ClusterProfileReconciler) WatchForCAPI(mgr ctrl.Manager, c controller.C
 	// one or more ClusterProfiles need to be reconciled.
 	if err := c.Watch(&source.Kind{Type: &clusterv1.Cluster{}},
 		handler.EnqueueRequestsFromMapFunc(r.requeueClusterProfileForCluster),
-		ClusterPredicates(mgr.GetLogger().WithValues("predicate", "clusterpredicate")),
+		predicate.Or(
+			ClusterPredicates(mgr.GetLogger().WithValues("predicate", "clusterpredicate")),
+			ClusterStatusPredicates(r.isCELSelectorUsed, mgr.GetLogger().WithValues("predicate", "clusterstatuspredicate")),
+		),
 	); err != nil {
 		return err
 	}
@@ -313,32 +372,128 @@ func (r *ClusterProfileReconciler) addFinalizer(ctx context.Context, clusterProf
 }
 
 // getMatchingClusters returns all Sveltos/CAPI Clusters currently matching ClusterProfile.Spec.ClusterSelector
+// (and ClusterProfile.Spec.StructuredClusterSelector if set)
+// or referenced by ClusterProfile.Spec.ClusterRefs. Clusters referenced by ClusterProfile.Spec.ExcludeClusterRefs
+// are never returned.
 func (r *ClusterProfileReconciler) getMatchingClusters(ctx context.Context, clusterProfileScope *scope.ClusterProfileScope,
 ) ([]corev1.ObjectReference, error) {
 
 	matching := make([]corev1.ObjectReference, 0)
 
-	parsedSelector, _ := labels.Parse(clusterProfileScope.GetSelector())
+	matcher, err := getClusterMatcher(clusterProfileScope.ClusterProfile)
+	if err != nil {
+		return nil, err
+	}
 
-	tmpMatching, err := r.getMatchingCAPIClusters(ctx, clusterProfileScope, parsedSelector)
+	tmpMatching, err := r.getMatchingCAPIClusters(ctx, clusterProfileScope, matcher)
 	if err != nil {
 		return nil, err
 	}
 
 	matching = append(matching, tmpMatching...)
 
-	tmpMatching, err = r.getMatchingSveltosClusters(ctx, clusterProfileScope, parsedSelector)
+	tmpMatching, err = r.getMatchingSveltosClusters(ctx, clusterProfileScope, matcher)
 	if err != nil {
 		return nil, err
 	}
 
 	matching = append(matching, tmpMatching...)
 
-	return matching, nil
+	tmpMatching, err = r.getReferencedClusters(ctx, clusterProfileScope)
+	if err != nil {
+		return nil, err
+	}
+
+	matchingSet := &libsveltosset.Set{}
+	for i := range matching {
+		matchingSet.Insert(&matching[i])
+	}
+	for i := range tmpMatching {
+		if !matchingSet.Has(&tmpMatching[i]) {
+			matchingSet.Insert(&tmpMatching[i])
+			matching = append(matching, tmpMatching[i])
+		}
+	}
+
+	return removeExcludedClusters(matching, clusterProfileScope.ClusterProfile.Spec.ExcludeClusterRefs), nil
+}
+
+// getReferencedClusters returns all existing Sveltos/CAPI Clusters listed in ClusterProfile.Spec.ClusterRefs.
+// References to clusters not existing (or being deleted) yet are ignored.
+func (r *ClusterProfileReconciler) getReferencedClusters(ctx context.Context, clusterProfileScope *scope.ClusterProfileScope,
+) ([]corev1.ObjectReference, error) {
+
+	referenced := make([]corev1.ObjectReference, 0)
+
+	for i := range clusterProfileScope.ClusterProfile.Spec.ClusterRefs {
+		ref := &clusterProfileScope.ClusterProfile.Spec.ClusterRefs[i]
+		if !isSupportedClusterRef(ref) {
+			clusterProfileScope.Logger.V(logs.LogInfo).Info(fmt.Sprintf("ignoring unsupported cluster reference %s %s/%s",
+				ref.Kind, ref.Namespace, ref.Name))
+			continue
+		}
+
+		cluster, err := getCluster(ctx, r.Client, ref.Namespace, ref.Name, getClusterType(ref))
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			clusterProfileScope.Logger.Error(err, fmt.Sprintf("failed to get cluster %s/%s", ref.Namespace, ref.Name))
+			return nil, err
+		}
+
+		if !cluster.GetDeletionTimestamp().IsZero() {
+			// Only existing cluster can match
+			continue
+		}
+
+		referenced = append(referenced, corev1.ObjectReference{
+			Kind:       ref.Kind,
+			Namespace:  ref.Namespace,
+			Name:       ref.Name,
+			APIVersion: ref.APIVersion,
+		})
+	}
+
+	return referenced, nil
+}
+
+// isSupportedClusterRef returns true if ref points to either a CAPI Cluster or a SveltosCluster
+func isSupportedClusterRef(ref *corev1.ObjectReference) bool {
+	switch ref.APIVersion {
+	case clusterv1.GroupVersion.String():
+		return ref.Kind == "Cluster"
+	case libsveltosv1alpha1.GroupVersion.String():
+		return ref.Kind == libsveltosv1alpha1.SveltosClusterKind
+	default:
+		return false
+	}
+}
+
+// removeExcludedClusters returns clusters minus all clusters listed in excluded
+func removeExcludedClusters(clusters, excluded []corev1.ObjectReference) []corev1.ObjectReference {
+	if len(excluded) == 0 {
+		return clusters
+	}
+
+	excludedSet := &libsveltosset.Set{}
+	for i := range excluded {
+		excludedSet.Insert(&corev1.ObjectReference{Kind: excluded[i].Kind, Namespace: excluded[i].Namespace,
+			Name: excluded[i].Name, APIVersion: excluded[i].APIVersion})
+	}
+
+	result := make([]corev1.ObjectReference, 0, len(clusters))
+	for i := range clusters {
+		if !excludedSet.Has(&clusters[i]) {
+			result = append(result, clusters[i])
+		}
+	}
+
+	return result
 }
 
 func (r *ClusterProfileReconciler) getMatchingCAPIClusters(ctx context.Context, clusterProfileScope *scope.ClusterProfileScope,
-	parsedSelector labels.Selector) ([]corev1.ObjectReference, error) {
+	matcher *clusterMatcher) ([]corev1.ObjectReference, error) {
 
 	clusterList := &clusterv1.ClusterList{}
 	if err := r.List(ctx, clusterList); err != nil {
@@ -357,7 +512,7 @@ func (r *ClusterProfileReconciler) getMatchingCAPIClusters(ctx context.Context,
 		}
 
 		addTypeInformationToObject(r.Scheme, cluster)
-		if parsedSelector.Matches(labels.Set(cluster.Labels)) {
+		if matcher.matches(cluster, clusterProfileScope.Logger) {
 			matching = append(matching, corev1.ObjectReference{
 				Kind:       cluster.Kind,
 				Namespace:  cluster.Namespace,
@@ -371,7 +526,7 @@ func (r *ClusterProfileReconciler) getMatchingCAPIClusters(ctx context.Context,
 }
 
 func (r *ClusterProfileReconciler) getMatchingSveltosClusters(ctx context.Context, clusterProfileScope *scope.ClusterProfileScope,
-	parsedSelector labels.Selector) ([]corev1.ObjectReference, error) {
+	matcher *clusterMatcher) ([]corev1.ObjectReference, error) {
 
 	clusterList := &libsveltosv1alpha1.SveltosClusterList{}
 	if err := r.List(ctx, clusterList); err != nil {
@@ -390,7 +545,7 @@ func (r *ClusterProfileReconciler) getMatchingSveltosClusters(ctx context.Contex
 		}
 
 		addTypeInformationToObject(r.Scheme, cluster)
-		if parsedSelector.Matches(labels.Set(cluster.Labels)) {
+		if matcher.matches(cluster, clusterProfileScope.Logger) {
 			matching = append(matching, corev1.ObjectReference{
 				Kind:       cluster.Kind,
 				Namespace:  cluster.Namespace,
@@ -503,8 +658,11 @@ func (r *ClusterProfileReconciler) cleanClusterReports(ctx context.Context,
 
 // updateClusterSummaries for each Sveltos/CAPI Cluster currently matching ClusterProfile:
 // - creates corresponding ClusterSummary if one does not exist already
-// - updates (eventually) corresponding ClusterSummary if one already exists
+// - updates (eventually) corresponding ClusterSummary if one already exists. If ClusterProfile has a
+// RolloutStrategy, ClusterSummaries are updated in batches.
 func (r *ClusterProfileReconciler) updateClusterSummaries(ctx context.Context, clusterProfileScope *scope.ClusterProfileScope) error {
+	// existing contains all ready clusters for which a ClusterSummary already exists
+	existing := make([]corev1.ObjectReference, 0)
 	for i := range clusterProfileScope.ClusterProfile.Status.MatchingClusterRefs {
 		cluster := clusterProfileScope.ClusterProfile.Status.MatchingClusterRefs[i]
 		ready, err := clusterproxy.IsClusterReadyToBeConfigured(ctx, r.Client, &cluster, clusterProfileScope.Logger)
@@ -534,6 +692,9 @@ func (r *ClusterProfileReconciler) updateClusterSummaries(ctx context.Context, c
 					cluster.Namespace, cluster.Name)
 				return err
 			}
+		} else if isRolloutControlled(clusterProfileScope) {
+			// ClusterSummary will be updated, if needed, as part of the rollout
+			existing = append(existing, cluster)
 		} else {
 			err = r.updateClusterSummary(ctx, clusterProfileScope, &cluster)
 			if err != nil {
@@ -544,7 +705,12 @@ func (r *ClusterProfileReconciler) updateClusterSummaries(ctx context.Context, c
 		}
 	}
 
-	return nil
+	if !isRolloutControlled(clusterProfileScope) {
+		clusterProfileScope.SetRolloutStatus(nil)
+		return nil
+	}
+
+	return r.rolloutClusterSummaries(ctx, clusterProfileScope, existing)
 }
 
 // cleanClusterSummaries finds all ClusterSummary currently owned by ClusterProfile.
@@ -955,6 +1121,7 @@ func (r *ClusterProfileReconciler) cleanMaps(clusterProfileScope *scope.ClusterP
 
 	delete(r.ClusterProfileMap, *clusterProfileInfo)
 	delete(r.ClusterProfiles, *clusterProfileInfo)
+	r.CELClusterProfiles.Erase(clusterProfileInfo)
 
 	for i := range r.ClusterMap {
 		clusterProfileSet := r.ClusterMap[i]
@@ -963,9 +1130,15 @@ func (r *ClusterProfileReconciler) cleanMaps(clusterProfileScope *scope.ClusterP
 }
 
 func (r *ClusterProfileReconciler) updateMaps(clusterProfileScope *scope.ClusterProfileScope) {
+	// Clusters explicitly referenced are tracked even when not matching yet (for instance because
+	// they do not exist yet). So ClusterProfile is reconciled as soon as any of those is created.
+	referencedClusters := make([]corev1.ObjectReference, 0)
+	referencedClusters = append(referencedClusters, clusterProfileScope.ClusterProfile.Status.MatchingClusterRefs...)
+	referencedClusters = append(referencedClusters, clusterProfileScope.ClusterProfile.Spec.ClusterRefs...)
+
 	currentClusters := &libsveltosset.Set{}
-	for i := range clusterProfileScope.ClusterProfile.Status.MatchingClusterRefs {
-		cluster := clusterProfileScope.ClusterProfile.Status.MatchingClusterRefs[i]
+	for i := range referencedClusters {
+		cluster := referencedClusters[i]
 		clusterInfo := &corev1.ObjectReference{Namespace: cluster.Namespace, Name: cluster.Name, Kind: cluster.Kind, APIVersion: cluster.APIVersion}
 		currentClusters.Insert(clusterInfo)
 	}
@@ -981,9 +1154,9 @@ func (r *ClusterProfileReconciler) updateMaps(clusterProfileScope *scope.Cluster
 		toBeRemoved = v.Difference(currentClusters)
 	}
 
-	// For each currently matching Cluster, add ClusterProfile as consumer
-	for i := range clusterProfileScope.ClusterProfile.Status.MatchingClusterRefs {
-		cluster := clusterProfileScope.ClusterProfile.Status.MatchingClusterRefs[i]
+	// For each currently matching or referenced Cluster, add ClusterProfile as consumer
+	for i := range referencedClusters {
+		cluster := referencedClusters[i]
 		clusterInfo := &corev1.ObjectReference{Namespace: cluster.Namespace, Name: cluster.Name, Kind: cluster.Kind, APIVersion: cluster.APIVersion}
 		r.getClusterMapForEntry(clusterInfo).Insert(clusterProfileInfo)
 	}
@@ -997,6 +1170,21 @@ func (r *ClusterProfileReconciler) updateMaps(clusterProfileScope *scope.Cluster
 	// Update list of WorklaodRoles currently referenced by ClusterSummary
 	r.ClusterProfileMap[*clusterProfileInfo] = currentClusters
 	r.ClusterProfiles[*clusterProfileInfo] = clusterProfileScope.ClusterProfile.Spec.ClusterSelector
+
+	structuredSelector := clusterProfileScope.ClusterProfile.Spec.StructuredClusterSelector
+	if structuredSelector != nil && structuredSelector.CELExpression != "" {
+		r.CELClusterProfiles.Insert(clusterProfileInfo)
+	} else {
+		r.CELClusterProfiles.Erase(clusterProfileInfo)
+	}
+}
+
+// isCELSelectorUsed returns true if any ClusterProfile selects clusters with a CEL expression
+func (r *ClusterProfileReconciler) isCELSelectorUsed() bool {
+	r.Mux.Lock()
+	defer r.Mux.Unlock()
+
+	return r.CELClusterProfiles.Len() != 0
 }
 
 func (r *ClusterProfileReconciler) getClusterMapForEntry(entry *corev1.ObjectReference) *libsveltosset.Set {
@@ -389,6 +389,69 @@ var _ = Describe("ClusterProfile: Reconciler", func() {
 				Kind: sveltosCluster.Kind, APIVersion: sveltosCluster.APIVersion}))
 	})
 
+	It("getMatchingClusters considers ClusterRefs and ExcludeClusterRefs", func() {
+		sveltosCluster := &libsveltosv1alpha1.SveltosCluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      randomString(),
+				Namespace: randomString(),
+				Labels:    matchingCluster.Labels,
+			},
+		}
+
+		initObjects := []client.Object{
+			clusterProfile,
+			matchingCluster,
+			sveltosCluster,
+			nonMatchingCluster,
+		}
+
+		Expect(addTypeInformationToObject(scheme, matchingCluster))
+		Expect(addTypeInformationToObject(scheme, nonMatchingCluster))
+		Expect(addTypeInformationToObject(scheme, sveltosCluster))
+
+		nonMatchingRef := corev1.ObjectReference{Namespace: nonMatchingCluster.Namespace, Name: nonMatchingCluster.Name,
+			Kind: nonMatchingCluster.Kind, APIVersion: nonMatchingCluster.APIVersion}
+		sveltosClusterRef := corev1.ObjectReference{Namespace: sveltosCluster.Namespace, Name: sveltosCluster.Name,
+			Kind: sveltosCluster.Kind, APIVersion: sveltosCluster.APIVersion}
+		matchingRef := corev1.ObjectReference{Namespace: matchingCluster.Namespace, Name: matchingCluster.Name,
+			Kind: matchingCluster.Kind, APIVersion: matchingCluster.APIVersion}
+
+		clusterProfile.Spec.ClusterRefs = []corev1.ObjectReference{
+			nonMatchingRef,
+			matchingRef, // also matching ClusterSelector. Must be returned only once
+			{Namespace: namespace, Name: randomString(), Kind: clusterKind, APIVersion: clusterv1.GroupVersion.String()}, // does not exist
+			{Namespace: namespace, Name: randomString(), Kind: "ConfigMap", APIVersion: "v1"},                            // not a cluster
+		}
+		clusterProfile.Spec.ExcludeClusterRefs = []corev1.ObjectReference{
+			sveltosClusterRef,
+		}
+
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(initObjects...).Build()
+
+		reconciler := getClusterProfileReconciler(c)
+
+		clusterProfileScope, err := scope.NewClusterProfileScope(scope.ClusterProfileScopeParams{
+			Client:         c,
+			Logger:         logger,
+			ClusterProfile: clusterProfile,
+			ControllerName: "clusterprofile",
+		})
+		Expect(err).To(BeNil())
+
+		matches, err := controllers.GetMatchingClusters(reconciler, context.TODO(), clusterProfileScope)
+		Expect(err).To(BeNil())
+		Expect(len(matches)).To(Equal(2))
+		Expect(matches).To(ContainElement(matchingRef))
+		Expect(matches).To(ContainElement(nonMatchingRef))
+
+		By("Excluding a cluster referenced in ClusterRefs")
+		clusterProfile.Spec.ExcludeClusterRefs = append(clusterProfile.Spec.ExcludeClusterRefs, nonMatchingRef)
+		matches, err = controllers.GetMatchingClusters(reconciler, context.TODO(), clusterProfileScope)
+		Expect(err).To(BeNil())
+		Expect(len(matches)).To(Equal(1))
+		Expect(matches).To(ContainElement(matchingRef))
+	})
+
 	It("CreateClusterSummary creates ClusterSummary with proper fields", func() {
 		initObjects := []client.Object{
 			clusterProfile,
@@ -26,6 +26,7 @@ import (
 
 	libsveltosv1alpha1 "github.com/projectsveltos/libsveltos/api/v1alpha1"
 	logs "github.com/projectsveltos/libsveltos/lib/logsettings"
+	configv1alpha1 "github.com/projectsveltos/sveltos-manager/api/v1alpha1"
 )
 
 // ClusterPredicates predicates for v1Cluster. ClusterProfileReconciler watches v1Cluster events
@@ -59,6 +60,14 @@ func ClusterPredicates(logger logr.Logger) predicate.Funcs {
 				return true
 			}
 
+			// Topology (Kubernetes version for instance) can be used by ClusterProfile StructuredClusterSelector
+			if !reflect.DeepEqual(oldCluster.Spec.Topology, newCluster.Spec.Topology) {
+				log.V(logs.LogVerbose).Info(
+					"Cluster topology changed. Will attempt to reconcile associated ClusterProfiles.",
+				)
+				return true
+			}
+
 			// otherwise, return false
 			log.V(logs.LogVerbose).Info(
 				"Cluster did not match expected conditions.  Will not attempt to reconcile associated ClusterProfiles.")
@@ -184,6 +193,93 @@ func SveltosClusterPredicates(logger logr.Logger) predicate.Funcs {
 	}
 }
 
+// ClusterStatusPredicates predicates for Sveltos/CAPI Cluster status. ClusterProfileReconciler reconciles itself
+// when a Cluster status changes and isCELSelectorUsed reports that a ClusterProfile selects clusters with a CEL
+// expression (which can evaluate Cluster status). All other events are handled by SveltosClusterPredicates
+// and ClusterPredicates.
+func ClusterStatusPredicates(isCELSelectorUsed func() bool, logger logr.Logger) predicate.Funcs {
+	return predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			log := logger.WithValues("predicate", "updateEvent",
+				"namespace", e.ObjectNew.GetNamespace(),
+				"cluster", e.ObjectNew.GetName(),
+			)
+
+			if !isCELSelectorUsed() {
+				return false
+			}
+
+			statusChanged := false
+			switch newCluster := e.ObjectNew.(type) {
+			case *clusterv1.Cluster:
+				oldCluster, ok := e.ObjectOld.(*clusterv1.Cluster)
+				statusChanged = !ok || !reflect.DeepEqual(oldCluster.Status, newCluster.Status)
+			case *libsveltosv1alpha1.SveltosCluster:
+				oldCluster, ok := e.ObjectOld.(*libsveltosv1alpha1.SveltosCluster)
+				statusChanged = !ok || !reflect.DeepEqual(oldCluster.Status, newCluster.Status)
+			}
+
+			if statusChanged {
+				log.V(logs.LogVerbose).Info(
+					"Cluster status changed. Will attempt to reconcile ClusterProfiles using a CEL selector.")
+			}
+			return statusChanged
+		},
+		CreateFunc: func(e event.CreateEvent) bool {
+			return false
+		},
+		DeleteFunc: func(e event.DeleteEvent) bool {
+			return false
+		},
+		GenericFunc: func(e event.GenericEvent) bool {
+			return false
+		},
+	}
+}
+
+// ClusterSummaryPredicates predicates for ClusterSummary. ClusterProfileReconciler watches ClusterSummary events
+// and react to those by reconciling the owning ClusterProfile based on following predicates
+func ClusterSummaryPredicates(logger logr.Logger) predicate.Funcs {
+	return predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			newClusterSummary := e.ObjectNew.(*configv1alpha1.ClusterSummary)
+			oldClusterSummary := e.ObjectOld.(*configv1alpha1.ClusterSummary)
+			log := logger.WithValues("predicate", "updateEvent",
+				"namespace", newClusterSummary.Namespace,
+				"clustersummary", newClusterSummary.Name,
+			)
+
+			if oldClusterSummary == nil {
+				log.V(logs.LogVerbose).Info("Old ClusterSummary is nil. Reconcile ClusterProfile")
+				return true
+			}
+
+			if !reflect.DeepEqual(oldClusterSummary.Status.FeatureSummaries, newClusterSummary.Status.FeatureSummaries) {
+				log.V(logs.LogVerbose).Info(
+					"ClusterSummary feature summaries changed. Will attempt to reconcile associated ClusterProfile.",
+				)
+				return true
+			}
+
+			// otherwise, return false
+			log.V(logs.LogVerbose).Info(
+				"ClusterSummary did not match expected conditions.  Will not attempt to reconcile associated ClusterProfile.")
+			return false
+		},
+		CreateFunc: func(e event.CreateEvent) bool {
+			// ClusterSummary is created by ClusterProfileReconciler. No need to reconcile.
+			return false
+		},
+		DeleteFunc: func(e event.DeleteEvent) bool {
+			// ClusterSummary is deleted only when cluster stops matching. ClusterProfile was reconciled already.
+			return false
+		},
+		GenericFunc: func(e event.GenericEvent) bool {
+			return false
+		},
+	}
+}
+
 // MachinePredicates predicates for v1Machine. ClusterProfileReconciler watches v1Machine events
 // and react to those by reconciling itself based on following predicates
 func MachinePredicates(logger logr.Logger) predicate.Funcs {
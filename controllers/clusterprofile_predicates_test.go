@@ -27,6 +27,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/event"
 
 	libsveltosv1alpha1 "github.com/projectsveltos/libsveltos/api/v1alpha1"
+	configv1alpha1 "github.com/projectsveltos/sveltos-manager/api/v1alpha1"
 	"github.com/projectsveltos/sveltos-manager/controllers"
 )
 
@@ -189,6 +190,52 @@ var _ = Describe("ClusterProfile Predicates: SvelotsClusterPredicates", func() {
 	})
 })
 
+var _ = Describe("ClusterProfile Predicates: ClusterStatusPredicates", func() {
+	It("Update reprocesses on Cluster status changes only when a CEL selector is used", func() {
+		celSelectorUsed := false
+		clusterStatusPredicate := controllers.ClusterStatusPredicates(func() bool { return celSelectorUsed }, klogr.New())
+
+		cluster := &clusterv1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      upstreamClusterNamePrefix + randomString(),
+				Namespace: "predicates" + randomString(),
+			},
+			Status: clusterv1.ClusterStatus{ControlPlaneReady: true},
+		}
+		oldCluster := cluster.DeepCopy()
+		oldCluster.Status.ControlPlaneReady = false
+
+		e := event.UpdateEvent{
+			ObjectNew: cluster,
+			ObjectOld: oldCluster,
+		}
+		Expect(clusterStatusPredicate.Update(e)).To(BeFalse())
+
+		celSelectorUsed = true
+		Expect(clusterStatusPredicate.Update(e)).To(BeTrue())
+
+		e.ObjectOld = cluster.DeepCopy()
+		Expect(clusterStatusPredicate.Update(e)).To(BeFalse())
+
+		By("Sveltos Cluster status changes")
+		sveltosCluster := &libsveltosv1alpha1.SveltosCluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      upstreamClusterNamePrefix + randomString(),
+				Namespace: "predicates" + randomString(),
+			},
+			Status: libsveltosv1alpha1.SveltosClusterStatus{Ready: true},
+		}
+		oldSveltosCluster := sveltosCluster.DeepCopy()
+		oldSveltosCluster.Status.Ready = false
+
+		e = event.UpdateEvent{
+			ObjectNew: sveltosCluster,
+			ObjectOld: oldSveltosCluster,
+		}
+		Expect(clusterStatusPredicate.Update(e)).To(BeTrue())
+	})
+})
+
 var _ = Describe("ClusterProfile Predicates: ClusterPredicates", func() {
 	var logger logr.Logger
 	var cluster *clusterv1.Cluster
@@ -322,6 +369,90 @@ var _ = Describe("ClusterProfile Predicates: ClusterPredicates", func() {
 		result := clusterPredicate.Update(e)
 		Expect(result).To(BeTrue())
 	})
+	It("Update reprocesses when v1Cluster topology changes", func() {
+		clusterPredicate := controllers.ClusterPredicates(logger)
+
+		cluster.Spec.Topology = &clusterv1.Topology{Class: randomString(), Version: "v1.25.3"}
+
+		oldCluster := &clusterv1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      cluster.Name,
+				Namespace: cluster.Namespace,
+			},
+		}
+		oldCluster.Spec.Topology = &clusterv1.Topology{Class: cluster.Spec.Topology.Class, Version: "v1.24.7"}
+
+		e := event.UpdateEvent{
+			ObjectNew: cluster,
+			ObjectOld: oldCluster,
+		}
+
+		result := clusterPredicate.Update(e)
+		Expect(result).To(BeTrue())
+	})
+})
+
+var _ = Describe("ClusterProfile Predicates: ClusterSummaryPredicates", func() {
+	var logger logr.Logger
+	var clusterSummary *configv1alpha1.ClusterSummary
+
+	BeforeEach(func() {
+		logger = klogr.New()
+		clusterSummary = &configv1alpha1.ClusterSummary{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      randomString(),
+				Namespace: "predicates" + randomString(),
+			},
+		}
+	})
+
+	It("Update reprocesses when ClusterSummary feature summaries change", func() {
+		clusterSummaryPredicate := controllers.ClusterSummaryPredicates(logger)
+
+		clusterSummary.Status.FeatureSummaries = []configv1alpha1.FeatureSummary{
+			{FeatureID: configv1alpha1.FeatureHelm, Status: configv1alpha1.FeatureStatusProvisioned},
+		}
+
+		oldClusterSummary := &configv1alpha1.ClusterSummary{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      clusterSummary.Name,
+				Namespace: clusterSummary.Namespace,
+			},
+			Status: configv1alpha1.ClusterSummaryStatus{
+				FeatureSummaries: []configv1alpha1.FeatureSummary{
+					{FeatureID: configv1alpha1.FeatureHelm, Status: configv1alpha1.FeatureStatusProvisioning},
+				},
+			},
+		}
+
+		e := event.UpdateEvent{
+			ObjectNew: clusterSummary,
+			ObjectOld: oldClusterSummary,
+		}
+
+		result := clusterSummaryPredicate.Update(e)
+		Expect(result).To(BeTrue())
+	})
+	It("Update does not reprocess when ClusterSummary feature summaries do not change", func() {
+		clusterSummaryPredicate := controllers.ClusterSummaryPredicates(logger)
+
+		clusterSummary.Labels = map[string]string{randomString(): randomString()}
+
+		oldClusterSummary := &configv1alpha1.ClusterSummary{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      clusterSummary.Name,
+				Namespace: clusterSummary.Namespace,
+			},
+		}
+
+		e := event.UpdateEvent{
+			ObjectNew: clusterSummary,
+			ObjectOld: oldClusterSummary,
+		}
+
+		result := clusterSummaryPredicate.Update(e)
+		Expect(result).To(BeFalse())
+	})
 })
 
 var _ = Describe("ClusterProfile Predicates: MachinePredicates", func() {
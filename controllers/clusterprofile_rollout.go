@@ -0,0 +1,322 @@
+/*
+Copyright 2022-23. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	logs "github.com/projectsveltos/libsveltos/lib/logsettings"
+	configv1alpha1 "github.com/projectsveltos/sveltos-manager/api/v1alpha1"
+	"github.com/projectsveltos/sveltos-manager/pkg/scope"
+)
+
+// A ClusterProfile with a RolloutStrategy does not propagate a change of its deployable Spec (what is deployed
+// in a cluster: PolicyRefs, HelmCharts, SyncMode and StopMatchingBehavior) to all ClusterSummaries at once.
+// Instead:
+// - ClusterSummaries already having current ClusterProfile deployable Spec are considered updated. Each updated
+// ClusterSummary is either provisioned (all features are provisioned), failed (at least one feature failed) or
+// in progress. A ClusterSummary whose features were not deployed with its current Spec yet (for instance cluster
+// is paused, not reachable or waiting for dependencies) is in progress;
+// - at most MaxUpdate ClusterSummaries can be in progress at any given time. Once a ClusterSummary is provisioned
+// (or failed) next ClusterSummary gets the new deployable Spec;
+// - if the number of failed ClusterSummaries exceeds the FailureBudget, rollout is halted. No more ClusterSummary
+// gets the new deployable Spec till either ClusterSummaries recover or ClusterProfile Spec is changed again.
+// Annotations (paused annotation for instance) and all other Spec fields are always propagated to all ClusterSummaries.
+
+// isRolloutControlled returns true if changes to ClusterProfile Spec must be propagated to ClusterSummaries
+// following the ClusterProfile RolloutStrategy
+func isRolloutControlled(clusterProfileScope *scope.ClusterProfileScope) bool {
+	return clusterProfileScope.ClusterProfile.Spec.RolloutStrategy != nil &&
+		clusterProfileScope.IsContinuousSync()
+}
+
+// isRolloutCompleted returns true if all matching clusters have current ClusterProfile Spec
+// and are either provisioned or failed (within failure budget).
+func isRolloutCompleted(rolloutStatus *configv1alpha1.RolloutStatus) bool {
+	if rolloutStatus == nil {
+		return true
+	}
+
+	if rolloutStatus.Halted || rolloutStatus.PendingClusters != 0 {
+		return false
+	}
+
+	return rolloutStatus.UpdatedClusters == rolloutStatus.ProvisionedClusters+rolloutStatus.FailedClusters
+}
+
+// getRolloutMaxUpdate returns the maximum number of ClusterSummaries that can be in progress at the same time.
+// If not specified, there is no limit.
+func getRolloutMaxUpdate(rolloutStrategy *configv1alpha1.RolloutStrategy, total int) (int, error) {
+	if rolloutStrategy.MaxUpdate == nil {
+		return total, nil
+	}
+
+	maxUpdate, err := intstr.GetScaledValueFromIntOrPercent(rolloutStrategy.MaxUpdate, total, true)
+	if err != nil {
+		return 0, errors.Wrap(err, "invalid RolloutStrategy MaxUpdate")
+	}
+
+	// Always allow progress
+	if maxUpdate < 1 {
+		maxUpdate = 1
+	}
+
+	return maxUpdate, nil
+}
+
+// getRolloutFailureBudget returns the maximum number of ClusterSummaries that can fail before rollout is halted.
+// If not specified, no failure is tolerated.
+func getRolloutFailureBudget(rolloutStrategy *configv1alpha1.RolloutStrategy, total int) (int, error) {
+	if rolloutStrategy.FailureBudget == nil {
+		return 0, nil
+	}
+
+	failureBudget, err := intstr.GetScaledValueFromIntOrPercent(rolloutStrategy.FailureBudget, total, false)
+	if err != nil {
+		return 0, errors.Wrap(err, "invalid RolloutStrategy FailureBudget")
+	}
+
+	return failureBudget, nil
+}
+
+// setDeployableSpec copies, from src to dst, the ClusterProfile Spec fields defining what is deployed in a cluster
+func setDeployableSpec(dst, src *configv1alpha1.ClusterProfileSpec) {
+	dst.SyncMode = src.SyncMode
+	dst.StopMatchingBehavior = src.StopMatchingBehavior
+	dst.PolicyRefs = src.PolicyRefs
+	dst.HelmCharts = src.HelmCharts
+}
+
+// hasDeployableSpec returns true if ClusterSummary has the current ClusterProfile deployable Spec.
+// Fields only used to select clusters or to decide when to deploy (DependsOn, SyncWindows, RolloutStrategy)
+// are ignored.
+func hasDeployableSpec(clusterProfile *configv1alpha1.ClusterProfile,
+	clusterSummary *configv1alpha1.ClusterSummary) bool {
+
+	current := configv1alpha1.ClusterProfileSpec{}
+	setDeployableSpec(&current, &clusterProfile.Spec)
+	deployed := configv1alpha1.ClusterProfileSpec{}
+	setDeployableSpec(&deployed, &clusterSummary.Spec.ClusterProfileSpec)
+	return reflect.DeepEqual(current, deployed)
+}
+
+// isClusterSummaryStatusCurrent returns true if ClusterSummary Status reflects its current Spec, i.e.
+// ClusterSummary controller has processed the most recent ClusterSummary generation
+func isClusterSummaryStatusCurrent(clusterSummary *configv1alpha1.ClusterSummary) bool {
+	return clusterSummary.Status.ObservedGeneration == clusterSummary.Generation
+}
+
+// isClusterSummaryDeployed returns true if every feature ClusterSummary needs was deployed (or failed
+// to be deployed) with ClusterSummary current Spec. A feature status left over by a previous Spec (for
+// instance because cluster is paused or not reachable) does not count.
+func isClusterSummaryDeployed(clusterSummary *configv1alpha1.ClusterSummary) bool {
+	isFeatureDeployed := func(featureID configv1alpha1.FeatureID) bool {
+		for i := range clusterSummary.Status.FeatureSummaries {
+			fs := &clusterSummary.Status.FeatureSummaries[i]
+			if fs.FeatureID == featureID {
+				return fs.DeployedGeneration == clusterSummary.Generation
+			}
+		}
+		return false
+	}
+
+	if len(clusterSummary.Spec.ClusterProfileSpec.PolicyRefs) != 0 &&
+		!isFeatureDeployed(configv1alpha1.FeatureResources) {
+
+		return false
+	}
+
+	if len(clusterSummary.Spec.ClusterProfileSpec.HelmCharts) != 0 &&
+		!isFeatureDeployed(configv1alpha1.FeatureHelm) {
+
+		return false
+	}
+
+	return true
+}
+
+// isClusterSummaryFailed returns true if at least one feature failed to be provisioned
+func isClusterSummaryFailed(clusterSummary *configv1alpha1.ClusterSummary) bool {
+	for i := range clusterSummary.Status.FeatureSummaries {
+		if clusterSummary.Status.FeatureSummaries[i].Status == configv1alpha1.FeatureStatusFailed {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isClusterSummaryProvisioned returns true if all features ClusterSummary needs are provisioned
+func isClusterSummaryProvisioned(clusterSummary *configv1alpha1.ClusterSummary) bool {
+	isFeatureProvisioned := func(featureID configv1alpha1.FeatureID) bool {
+		for i := range clusterSummary.Status.FeatureSummaries {
+			fs := &clusterSummary.Status.FeatureSummaries[i]
+			if fs.FeatureID == featureID {
+				return fs.Status == configv1alpha1.FeatureStatusProvisioned
+			}
+		}
+		return false
+	}
+
+	if len(clusterSummary.Spec.ClusterProfileSpec.PolicyRefs) != 0 &&
+		!isFeatureProvisioned(configv1alpha1.FeatureResources) {
+
+		return false
+	}
+
+	if len(clusterSummary.Spec.ClusterProfileSpec.HelmCharts) != 0 &&
+		!isFeatureProvisioned(configv1alpha1.FeatureHelm) {
+
+		return false
+	}
+
+	return true
+}
+
+// rolloutClusterSummaries propagates ClusterProfile Spec to the ClusterSummaries of the passed clusters
+// following ClusterProfile RolloutStrategy. ClusterProfile Status is updated with rollout progress.
+func (r *ClusterProfileReconciler) rolloutClusterSummaries(ctx context.Context,
+	clusterProfileScope *scope.ClusterProfileScope, clusters []corev1.ObjectReference) error {
+
+	logger := clusterProfileScope.Logger
+	clusterProfile := clusterProfileScope.ClusterProfile
+
+	// Always consider clusters in the same order, so batches are consistent across reconciliations
+	sort.Slice(clusters, func(i, j int) bool {
+		if clusters[i].Namespace != clusters[j].Namespace {
+			return clusters[i].Namespace < clusters[j].Namespace
+		}
+		if clusters[i].Name != clusters[j].Name {
+			return clusters[i].Name < clusters[j].Name
+		}
+		return clusters[i].Kind < clusters[j].Kind
+	})
+
+	rolloutStatus := &configv1alpha1.RolloutStatus{}
+	inProgress := 0
+	outdated := make([]*corev1.ObjectReference, 0)
+	for i := range clusters {
+		cluster := &clusters[i]
+		clusterSummary, err := getClusterSummary(ctx, r.Client, clusterProfileScope.Name(), cluster.Namespace, cluster.Name)
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return err
+		}
+
+		if !hasDeployableSpec(clusterProfile, clusterSummary) {
+			outdated = append(outdated, cluster)
+			continue
+		}
+
+		rolloutStatus.UpdatedClusters++
+		switch {
+		case !isClusterSummaryStatusCurrent(clusterSummary) || !isClusterSummaryDeployed(clusterSummary):
+			inProgress++
+		case isClusterSummaryFailed(clusterSummary):
+			rolloutStatus.FailedClusters++
+		case isClusterSummaryProvisioned(clusterSummary):
+			rolloutStatus.ProvisionedClusters++
+		default:
+			inProgress++
+		}
+
+		// Deployable Spec is current. This only propagates annotations and other Spec fields, if changed.
+		err = r.updateClusterSummary(ctx, clusterProfileScope, cluster)
+		if err != nil {
+			return err
+		}
+	}
+
+	maxUpdate, err := getRolloutMaxUpdate(clusterProfile.Spec.RolloutStrategy, len(clusters))
+	if err != nil {
+		return err
+	}
+
+	failureBudget, err := getRolloutFailureBudget(clusterProfile.Spec.RolloutStrategy, len(clusters))
+	if err != nil {
+		return err
+	}
+
+	toUpdate := 0
+	if int(rolloutStatus.FailedClusters) > failureBudget {
+		reason := fmt.Sprintf("%d cluster(s) failed to be provisioned. Failure budget is %d",
+			rolloutStatus.FailedClusters, failureBudget)
+		logger.V(logs.LogInfo).Info(fmt.Sprintf("rollout halted: %s", reason))
+		rolloutStatus.Halted = true
+		rolloutStatus.HaltReason = &reason
+	} else if maxUpdate > inProgress {
+		toUpdate = maxUpdate - inProgress
+		if toUpdate > len(outdated) {
+			toUpdate = len(outdated)
+		}
+	}
+
+	for i := range outdated {
+		cluster := outdated[i]
+		if i < toUpdate {
+			logger.V(logs.LogDebug).Info(fmt.Sprintf("rollout: updating ClusterSummary for cluster %s/%s",
+				cluster.Namespace, cluster.Name))
+			if err := r.updateClusterSummary(ctx, clusterProfileScope, cluster); err != nil {
+				return err
+			}
+			rolloutStatus.UpdatedClusters++
+			continue
+		}
+
+		// ClusterSummary is not part of current batch. Deployable Spec is left untouched.
+		if err := r.updatePendingClusterSummary(ctx, clusterProfileScope, cluster); err != nil {
+			return err
+		}
+		rolloutStatus.PendingClusters++
+	}
+
+	clusterProfileScope.SetRolloutStatus(rolloutStatus)
+	return nil
+}
+
+// updatePendingClusterSummary updates, if necessary, ClusterSummary annotations and Spec leaving the
+// deployable Spec untouched.
+func (r *ClusterProfileReconciler) updatePendingClusterSummary(ctx context.Context,
+	clusterProfileScope *scope.ClusterProfileScope, cluster *corev1.ObjectReference) error {
+
+	clusterSummary, err := getClusterSummary(ctx, r.Client, clusterProfileScope.Name(), cluster.Namespace, cluster.Name)
+	if err != nil {
+		return err
+	}
+
+	clusterProfileSpec := *clusterProfileScope.ClusterProfile.Spec.DeepCopy()
+	setDeployableSpec(&clusterProfileSpec, &clusterSummary.Spec.ClusterProfileSpec)
+
+	if reflect.DeepEqual(clusterProfileSpec, clusterSummary.Spec.ClusterProfileSpec) &&
+		reflect.DeepEqual(clusterProfileScope.ClusterProfile.Annotations, clusterSummary.Annotations) {
+		return nil
+	}
+
+	clusterSummary.Annotations = clusterProfileScope.ClusterProfile.Annotations
+	clusterSummary.Spec.ClusterProfileSpec = clusterProfileSpec
+	return r.Update(ctx, clusterSummary)
+}
@@ -0,0 +1,397 @@
+/*
+Copyright 2022-23. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers_test
+
+import (
+	"context"
+	"reflect"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/klog/v2/klogr"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	libsveltosv1alpha1 "github.com/projectsveltos/libsveltos/api/v1alpha1"
+	configv1alpha1 "github.com/projectsveltos/sveltos-manager/api/v1alpha1"
+	"github.com/projectsveltos/sveltos-manager/controllers"
+	"github.com/projectsveltos/sveltos-manager/pkg/scope"
+)
+
+var _ = Describe("ClusterProfile: rollout", func() {
+	var clusterProfile *configv1alpha1.ClusterProfile
+	var namespace string
+
+	BeforeEach(func() {
+		namespace = "rollout" + randomString()
+
+		clusterProfile = &configv1alpha1.ClusterProfile{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: clusterProfileNamePrefix + randomString(),
+			},
+			Spec: configv1alpha1.ClusterProfileSpec{
+				ClusterSelector: selector,
+				SyncMode:        configv1alpha1.SyncModeContinuous,
+				PolicyRefs: []libsveltosv1alpha1.PolicyRef{
+					{
+						Kind:      string(libsveltosv1alpha1.ConfigMapReferencedResourceKind),
+						Namespace: namespace,
+						Name:      randomString(),
+					},
+				},
+			},
+		}
+	})
+
+	// prepareClusterSummaries creates a ClusterSummary per cluster. First upToDate ClusterSummaries
+	// have current ClusterProfile Spec and features in featureStatus. All other ClusterSummaries have
+	// an outdated Spec.
+	prepareClusterSummaries := func(numOfClusters, upToDate int,
+		featureStatus configv1alpha1.FeatureStatus) ([]corev1.ObjectReference, []client.Object) {
+
+		clusters := make([]corev1.ObjectReference, numOfClusters)
+		objects := make([]client.Object, numOfClusters)
+		for i := 0; i < numOfClusters; i++ {
+			clusterName := upstreamClusterNamePrefix + randomString()
+			clusters[i] = corev1.ObjectReference{
+				Namespace: namespace, Name: clusterName,
+				Kind: clusterKind, APIVersion: clusterv1.GroupVersion.String(),
+			}
+
+			clusterSummary := &configv1alpha1.ClusterSummary{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      controllers.GetClusterSummaryName(clusterProfile.Name, clusterName, false),
+					Namespace: namespace,
+				},
+				Spec: configv1alpha1.ClusterSummarySpec{
+					ClusterNamespace: namespace,
+					ClusterName:      clusterName,
+					ClusterType:      libsveltosv1alpha1.ClusterTypeCapi,
+					ClusterProfileSpec: configv1alpha1.ClusterProfileSpec{
+						SyncMode: configv1alpha1.SyncModeContinuous,
+					},
+				},
+			}
+			if i < upToDate {
+				clusterSummary.Spec.ClusterProfileSpec = clusterProfile.Spec
+				clusterSummary.Status.FeatureSummaries = []configv1alpha1.FeatureSummary{
+					{FeatureID: configv1alpha1.FeatureResources, Status: featureStatus},
+				}
+			}
+			addLabelsToClusterSummary(clusterSummary, clusterProfile.Name, namespace, clusterName)
+			objects[i] = clusterSummary
+		}
+
+		return clusters, objects
+	}
+
+	countUpdatedClusterSummaries := func(c client.Client) int {
+		clusterSummaryList := &configv1alpha1.ClusterSummaryList{}
+		Expect(c.List(context.TODO(), clusterSummaryList, client.InNamespace(namespace))).To(Succeed())
+		updated := 0
+		for i := range clusterSummaryList.Items {
+			if reflect.DeepEqual(clusterSummaryList.Items[i].Spec.ClusterProfileSpec, clusterProfile.Spec) {
+				updated++
+			}
+		}
+		return updated
+	}
+
+	It("getRolloutMaxUpdate and getRolloutFailureBudget scale percentages", func() {
+		maxUpdate := intstr.FromString("10%")
+		failureBudget := intstr.FromString("10%")
+		rolloutStrategy := &configv1alpha1.RolloutStrategy{
+			MaxUpdate:     &maxUpdate,
+			FailureBudget: &failureBudget,
+		}
+
+		value, err := controllers.GetRolloutMaxUpdate(rolloutStrategy, 15)
+		Expect(err).To(BeNil())
+		Expect(value).To(Equal(2))
+
+		value, err = controllers.GetRolloutFailureBudget(rolloutStrategy, 15)
+		Expect(err).To(BeNil())
+		Expect(value).To(Equal(1))
+
+		By("MaxUpdate is never less than one")
+		maxUpdate = intstr.FromInt(0)
+		value, err = controllers.GetRolloutMaxUpdate(rolloutStrategy, 15)
+		Expect(err).To(BeNil())
+		Expect(value).To(Equal(1))
+
+		By("Not setting MaxUpdate means no limit and not setting FailureBudget means no failure is tolerated")
+		rolloutStrategy = &configv1alpha1.RolloutStrategy{}
+		value, err = controllers.GetRolloutMaxUpdate(rolloutStrategy, 15)
+		Expect(err).To(BeNil())
+		Expect(value).To(Equal(15))
+		value, err = controllers.GetRolloutFailureBudget(rolloutStrategy, 15)
+		Expect(err).To(BeNil())
+		Expect(value).To(Equal(0))
+
+		By("Invalid values are reported")
+		maxUpdate = intstr.FromString("ten")
+		rolloutStrategy.MaxUpdate = &maxUpdate
+		_, err = controllers.GetRolloutMaxUpdate(rolloutStrategy, 15)
+		Expect(err).ToNot(BeNil())
+	})
+
+	It("isClusterSummaryProvisioned returns true only when all needed features are provisioned", func() {
+		clusterSummary := &configv1alpha1.ClusterSummary{
+			Spec: configv1alpha1.ClusterSummarySpec{
+				ClusterProfileSpec: clusterProfile.Spec,
+			},
+		}
+		Expect(controllers.IsClusterSummaryProvisioned(clusterSummary)).To(BeFalse())
+
+		clusterSummary.Status.FeatureSummaries = []configv1alpha1.FeatureSummary{
+			{FeatureID: configv1alpha1.FeatureResources, Status: configv1alpha1.FeatureStatusProvisioning},
+		}
+		Expect(controllers.IsClusterSummaryProvisioned(clusterSummary)).To(BeFalse())
+
+		clusterSummary.Status.FeatureSummaries[0].Status = configv1alpha1.FeatureStatusProvisioned
+		Expect(controllers.IsClusterSummaryProvisioned(clusterSummary)).To(BeTrue())
+
+		clusterSummary.Spec.ClusterProfileSpec.HelmCharts = []configv1alpha1.HelmChart{
+			{ReleaseName: randomString(), ReleaseNamespace: randomString()},
+		}
+		Expect(controllers.IsClusterSummaryProvisioned(clusterSummary)).To(BeFalse())
+	})
+
+	It("isRolloutCompleted returns true only when all clusters are updated and provisioned or failed", func() {
+		Expect(controllers.IsRolloutCompleted(nil)).To(BeTrue())
+
+		rolloutStatus := &configv1alpha1.RolloutStatus{
+			UpdatedClusters: 3, ProvisionedClusters: 2, FailedClusters: 1,
+		}
+		Expect(controllers.IsRolloutCompleted(rolloutStatus)).To(BeTrue())
+
+		rolloutStatus.PendingClusters = 1
+		Expect(controllers.IsRolloutCompleted(rolloutStatus)).To(BeFalse())
+
+		rolloutStatus.PendingClusters = 0
+		rolloutStatus.ProvisionedClusters = 1
+		Expect(controllers.IsRolloutCompleted(rolloutStatus)).To(BeFalse())
+
+		rolloutStatus.ProvisionedClusters = 2
+		rolloutStatus.Halted = true
+		Expect(controllers.IsRolloutCompleted(rolloutStatus)).To(BeFalse())
+	})
+
+	It("rolloutClusterSummaries updates at most MaxUpdate ClusterSummaries at a time", func() {
+		maxUpdate := intstr.FromInt(2)
+		clusterProfile.Spec.RolloutStrategy = &configv1alpha1.RolloutStrategy{MaxUpdate: &maxUpdate}
+
+		// One ClusterSummary is already updated but still being provisioned. So only
+		// one more can be updated.
+		clusters, initObjects := prepareClusterSummaries(5, 1, configv1alpha1.FeatureStatusProvisioning)
+		initObjects = append(initObjects, clusterProfile)
+
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(initObjects...).Build()
+		reconciler := getClusterProfileReconciler(c)
+
+		clusterProfileScope, err := scope.NewClusterProfileScope(scope.ClusterProfileScopeParams{
+			Client:         c,
+			Logger:         klogr.New(),
+			ClusterProfile: clusterProfile,
+			ControllerName: "clusterprofile",
+		})
+		Expect(err).To(BeNil())
+
+		Expect(controllers.RolloutClusterSummaries(reconciler, context.TODO(), clusterProfileScope, clusters)).To(Succeed())
+		Expect(countUpdatedClusterSummaries(c)).To(Equal(2))
+
+		rolloutStatus := clusterProfile.Status.RolloutStatus
+		Expect(rolloutStatus).ToNot(BeNil())
+		Expect(rolloutStatus.UpdatedClusters).To(Equal(int32(2)))
+		Expect(rolloutStatus.PendingClusters).To(Equal(int32(3)))
+		Expect(rolloutStatus.Halted).To(BeFalse())
+		Expect(controllers.IsRolloutCompleted(rolloutStatus)).To(BeFalse())
+
+		By("Marking all updated ClusterSummaries as provisioned next batch is updated")
+		clusterSummaryList := &configv1alpha1.ClusterSummaryList{}
+		Expect(c.List(context.TODO(), clusterSummaryList, client.InNamespace(namespace))).To(Succeed())
+		for i := range clusterSummaryList.Items {
+			cs := &clusterSummaryList.Items[i]
+			if reflect.DeepEqual(cs.Spec.ClusterProfileSpec, clusterProfile.Spec) {
+				cs.Status.FeatureSummaries = []configv1alpha1.FeatureSummary{
+					{FeatureID: configv1alpha1.FeatureResources, Status: configv1alpha1.FeatureStatusProvisioned},
+				}
+				Expect(c.Status().Update(context.TODO(), cs)).To(Succeed())
+			}
+		}
+
+		Expect(controllers.RolloutClusterSummaries(reconciler, context.TODO(), clusterProfileScope, clusters)).To(Succeed())
+		Expect(countUpdatedClusterSummaries(c)).To(Equal(4))
+		Expect(clusterProfile.Status.RolloutStatus.ProvisionedClusters).To(Equal(int32(2)))
+		Expect(clusterProfile.Status.RolloutStatus.PendingClusters).To(Equal(int32(1)))
+	})
+
+	It("rolloutClusterSummaries considers only deployable Spec and ClusterSummary observed generation", func() {
+		maxUpdate := intstr.FromInt(1)
+		clusterProfile.Spec.RolloutStrategy = &configv1alpha1.RolloutStrategy{MaxUpdate: &maxUpdate}
+
+		clusters, initObjects := prepareClusterSummaries(2, 2, configv1alpha1.FeatureStatusProvisioned)
+		initObjects = append(initObjects, clusterProfile)
+
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(initObjects...).Build()
+		reconciler := getClusterProfileReconciler(c)
+
+		clusterProfileScope, err := scope.NewClusterProfileScope(scope.ClusterProfileScopeParams{
+			Client:         c,
+			Logger:         klogr.New(),
+			ClusterProfile: clusterProfile,
+			ControllerName: "clusterprofile",
+		})
+		Expect(err).To(BeNil())
+
+		By("Changing only non deployable Spec fields all ClusterSummaries are updated at once")
+		clusterProfile.Spec.DependsOn = []string{randomString()}
+		Expect(controllers.RolloutClusterSummaries(reconciler, context.TODO(), clusterProfileScope, clusters)).To(Succeed())
+		Expect(countUpdatedClusterSummaries(c)).To(Equal(2))
+		Expect(clusterProfile.Status.RolloutStatus.ProvisionedClusters).To(Equal(int32(2)))
+		Expect(clusterProfile.Status.RolloutStatus.PendingClusters).To(Equal(int32(0)))
+
+		By("Changing deployable Spec only one ClusterSummary gets it. Others get non deployable Spec fields")
+		clusterProfile.Spec.PolicyRefs[0].Name = randomString()
+		clusterProfile.Spec.DependsOn = []string{randomString()}
+		Expect(controllers.RolloutClusterSummaries(reconciler, context.TODO(), clusterProfileScope, clusters)).To(Succeed())
+		Expect(countUpdatedClusterSummaries(c)).To(Equal(1))
+		Expect(clusterProfile.Status.RolloutStatus.PendingClusters).To(Equal(int32(1)))
+
+		clusterSummaryList := &configv1alpha1.ClusterSummaryList{}
+		Expect(c.List(context.TODO(), clusterSummaryList, client.InNamespace(namespace))).To(Succeed())
+		for i := range clusterSummaryList.Items {
+			Expect(clusterSummaryList.Items[i].Spec.ClusterProfileSpec.DependsOn).To(Equal(clusterProfile.Spec.DependsOn))
+		}
+
+		By("Status of ClusterSummary not reflecting its current generation is not considered")
+		for i := range clusterSummaryList.Items {
+			cs := &clusterSummaryList.Items[i]
+			if reflect.DeepEqual(cs.Spec.ClusterProfileSpec, clusterProfile.Spec) {
+				cs.Generation = 2
+				Expect(c.Update(context.TODO(), cs)).To(Succeed())
+				cs.Status.ObservedGeneration = 1
+				Expect(c.Status().Update(context.TODO(), cs)).To(Succeed())
+			}
+		}
+		Expect(controllers.RolloutClusterSummaries(reconciler, context.TODO(), clusterProfileScope, clusters)).To(Succeed())
+		Expect(countUpdatedClusterSummaries(c)).To(Equal(1))
+		Expect(clusterProfile.Status.RolloutStatus.ProvisionedClusters).To(Equal(int32(0)))
+		Expect(clusterProfile.Status.RolloutStatus.PendingClusters).To(Equal(int32(1)))
+	})
+
+	It("rolloutClusterSummaries does not advance while a ClusterSummary in the batch was not deployed with its Spec", func() {
+		maxUpdate := intstr.FromInt(1)
+		clusterProfile.Spec.RolloutStrategy = &configv1alpha1.RolloutStrategy{MaxUpdate: &maxUpdate}
+
+		clusters, initObjects := prepareClusterSummaries(3, 1, configv1alpha1.FeatureStatusProvisioned)
+		initObjects = append(initObjects, clusterProfile)
+
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(initObjects...).Build()
+		reconciler := getClusterProfileReconciler(c)
+
+		clusterProfileScope, err := scope.NewClusterProfileScope(scope.ClusterProfileScopeParams{
+			Client:         c,
+			Logger:         klogr.New(),
+			ClusterProfile: clusterProfile,
+			ControllerName: "clusterprofile",
+		})
+		Expect(err).To(BeNil())
+
+		// Updated ClusterSummary is paused: its generation was observed but features still report
+		// the outcome of the previous Spec.
+		clusterSummaryList := &configv1alpha1.ClusterSummaryList{}
+		Expect(c.List(context.TODO(), clusterSummaryList, client.InNamespace(namespace))).To(Succeed())
+		var paused *configv1alpha1.ClusterSummary
+		for i := range clusterSummaryList.Items {
+			if reflect.DeepEqual(clusterSummaryList.Items[i].Spec.ClusterProfileSpec, clusterProfile.Spec) {
+				paused = &clusterSummaryList.Items[i]
+			}
+		}
+		Expect(paused).ToNot(BeNil())
+		paused.Annotations = map[string]string{clusterv1.PausedAnnotation: "true"}
+		paused.Generation = 2
+		Expect(c.Update(context.TODO(), paused)).To(Succeed())
+		paused.Status.ObservedGeneration = 2
+		paused.Status.FeatureSummaries[0].DeployedGeneration = 1
+		Expect(c.Status().Update(context.TODO(), paused)).To(Succeed())
+
+		Expect(controllers.RolloutClusterSummaries(reconciler, context.TODO(), clusterProfileScope, clusters)).To(Succeed())
+		Expect(countUpdatedClusterSummaries(c)).To(Equal(1))
+		Expect(clusterProfile.Status.RolloutStatus.ProvisionedClusters).To(Equal(int32(0)))
+		Expect(clusterProfile.Status.RolloutStatus.FailedClusters).To(Equal(int32(0)))
+		Expect(clusterProfile.Status.RolloutStatus.PendingClusters).To(Equal(int32(2)))
+
+		By("Once features are deployed with current Spec, rollout moves to next batch")
+		Expect(c.Get(context.TODO(), types.NamespacedName{Namespace: paused.Namespace, Name: paused.Name},
+			paused)).To(Succeed())
+		paused.Status.FeatureSummaries[0].DeployedGeneration = 2
+		Expect(c.Status().Update(context.TODO(), paused)).To(Succeed())
+
+		Expect(controllers.RolloutClusterSummaries(reconciler, context.TODO(), clusterProfileScope, clusters)).To(Succeed())
+		Expect(countUpdatedClusterSummaries(c)).To(Equal(2))
+		Expect(clusterProfile.Status.RolloutStatus.ProvisionedClusters).To(Equal(int32(1)))
+		Expect(clusterProfile.Status.RolloutStatus.PendingClusters).To(Equal(int32(1)))
+	})
+
+	It("rolloutClusterSummaries halts rollout when failures exceed failure budget", func() {
+		maxUpdate := intstr.FromInt(3)
+		failureBudget := intstr.FromInt(1)
+		clusterProfile.Spec.RolloutStrategy = &configv1alpha1.RolloutStrategy{
+			MaxUpdate:     &maxUpdate,
+			FailureBudget: &failureBudget,
+		}
+		clusterProfile.Annotations = map[string]string{randomString(): randomString()}
+
+		clusters, initObjects := prepareClusterSummaries(5, 2, configv1alpha1.FeatureStatusFailed)
+		initObjects = append(initObjects, clusterProfile)
+
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(initObjects...).Build()
+		reconciler := getClusterProfileReconciler(c)
+
+		clusterProfileScope, err := scope.NewClusterProfileScope(scope.ClusterProfileScopeParams{
+			Client:         c,
+			Logger:         klogr.New(),
+			ClusterProfile: clusterProfile,
+			ControllerName: "clusterprofile",
+		})
+		Expect(err).To(BeNil())
+
+		Expect(controllers.RolloutClusterSummaries(reconciler, context.TODO(), clusterProfileScope, clusters)).To(Succeed())
+		Expect(countUpdatedClusterSummaries(c)).To(Equal(2))
+
+		rolloutStatus := clusterProfile.Status.RolloutStatus
+		Expect(rolloutStatus).ToNot(BeNil())
+		Expect(rolloutStatus.Halted).To(BeTrue())
+		Expect(rolloutStatus.HaltReason).ToNot(BeNil())
+		Expect(rolloutStatus.FailedClusters).To(Equal(int32(2)))
+		Expect(rolloutStatus.PendingClusters).To(Equal(int32(3)))
+
+		By("Annotations are propagated even when rollout is halted")
+		clusterSummaryList := &configv1alpha1.ClusterSummaryList{}
+		Expect(c.List(context.TODO(), clusterSummaryList, client.InNamespace(namespace))).To(Succeed())
+		for i := range clusterSummaryList.Items {
+			Expect(reflect.DeepEqual(clusterSummaryList.Items[i].Annotations, clusterProfile.Annotations)).To(BeTrue())
+		}
+	})
+})
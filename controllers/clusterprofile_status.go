@@ -0,0 +1,171 @@
+/*
+Copyright 2022-23. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	configv1alpha1 "github.com/projectsveltos/sveltos-manager/api/v1alpha1"
+	"github.com/projectsveltos/sveltos-manager/pkg/scope"
+)
+
+// updateClusterProfileStatus aggregates the status of the ClusterSummaries created for each matching
+// cluster into ClusterProfile Status:
+// - per feature, number of clusters where feature is provisioning, provisioned or failed;
+// - per cluster, most recent ClusterProfile generation fully provisioned;
+// - clusters where at least one feature failed;
+// - Ready, Progressing and Degraded conditions.
+func (r *ClusterProfileReconciler) updateClusterProfileStatus(ctx context.Context,
+	clusterProfileScope *scope.ClusterProfileScope) error {
+
+	clusterProfile := clusterProfileScope.ClusterProfile
+
+	previousGenerations := make(map[corev1.ObjectReference]int64)
+	for i := range clusterProfile.Status.ClusterStatuses {
+		clusterStatus := &clusterProfile.Status.ClusterStatuses[i]
+		previousGenerations[clusterStatus.ClusterRef] = clusterStatus.ObservedGeneration
+	}
+
+	featureClusterCounts := make(map[configv1alpha1.FeatureID]*configv1alpha1.FeatureClusterCount)
+	getFeatureClusterCount := func(featureID configv1alpha1.FeatureID) *configv1alpha1.FeatureClusterCount {
+		if _, ok := featureClusterCounts[featureID]; !ok {
+			featureClusterCounts[featureID] = &configv1alpha1.FeatureClusterCount{FeatureID: featureID}
+		}
+		return featureClusterCounts[featureID]
+	}
+
+	clusterStatuses := make([]configv1alpha1.ClusterProfileClusterStatus, 0)
+	failingClusters := make([]configv1alpha1.FailingCluster, 0)
+	inProgress, failed := 0, 0
+
+	for i := range clusterProfile.Status.MatchingClusterRefs {
+		ref := clusterProfile.Status.MatchingClusterRefs[i]
+		clusterRef := corev1.ObjectReference{Namespace: ref.Namespace, Name: ref.Name, Kind: ref.Kind, APIVersion: ref.APIVersion}
+		observedGeneration := previousGenerations[clusterRef]
+
+		clusterSummary, err := getClusterSummary(ctx, r.Client, clusterProfile.Name, ref.Namespace, ref.Name)
+		if err != nil {
+			if !apierrors.IsNotFound(err) {
+				return err
+			}
+			// ClusterSummary is not created yet (for instance cluster is not ready yet)
+			inProgress++
+			clusterStatuses = append(clusterStatuses, configv1alpha1.ClusterProfileClusterStatus{
+				ClusterRef: clusterRef, ObservedGeneration: observedGeneration})
+			continue
+		}
+
+		for j := range clusterSummary.Status.FeatureSummaries {
+			fs := &clusterSummary.Status.FeatureSummaries[j]
+			switch fs.Status {
+			case configv1alpha1.FeatureStatusProvisioned:
+				getFeatureClusterCount(fs.FeatureID).Provisioned++
+			case configv1alpha1.FeatureStatusFailed:
+				getFeatureClusterCount(fs.FeatureID).Failed++
+				failingClusters = append(failingClusters, configv1alpha1.FailingCluster{
+					ClusterRef: clusterRef, FeatureID: fs.FeatureID, FailureMessage: fs.FailureMessage})
+			case configv1alpha1.FeatureStatusProvisioning, configv1alpha1.FeatureStatusWaitingForWindow:
+				getFeatureClusterCount(fs.FeatureID).Provisioning++
+			}
+		}
+
+		// ClusterSummary Status is considered only once ClusterSummary controller has processed its current Spec.
+		// In OneTime mode, ClusterProfile Spec changes are never propagated to existing ClusterSummaries.
+		isStatusCurrent := isClusterSummaryStatusCurrent(clusterSummary)
+		isUpToDate := isStatusCurrent &&
+			(clusterProfileScope.IsOneTimeSync() || hasDeployableSpec(clusterProfile, clusterSummary))
+		// A ClusterSummary waiting for its dependencies is not provisioned, whatever its features report.
+		isWaitingForDependencies := meta.IsStatusConditionFalse(clusterSummary.Status.Conditions,
+			configv1alpha1.DependenciesProvisionedCondition)
+
+		switch {
+		case isStatusCurrent && isClusterSummaryFailed(clusterSummary):
+			failed++
+		case isUpToDate && !isWaitingForDependencies && isClusterSummaryProvisioned(clusterSummary):
+			if !clusterProfileScope.IsOneTimeSync() || observedGeneration == 0 {
+				observedGeneration = clusterProfile.Generation
+			}
+		default:
+			inProgress++
+		}
+
+		clusterStatuses = append(clusterStatuses, configv1alpha1.ClusterProfileClusterStatus{
+			ClusterRef: clusterRef, ObservedGeneration: observedGeneration})
+	}
+
+	counts := make([]configv1alpha1.FeatureClusterCount, 0, len(featureClusterCounts))
+	for featureID := range featureClusterCounts {
+		counts = append(counts, *featureClusterCounts[featureID])
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		return counts[i].FeatureID < counts[j].FeatureID
+	})
+
+	clusterProfileScope.SetFeatureClusterCounts(counts)
+	clusterProfileScope.SetClusterStatuses(clusterStatuses)
+	clusterProfileScope.SetFailingClusters(failingClusters)
+	setClusterProfileConditions(clusterProfileScope, inProgress, failed)
+
+	return nil
+}
+
+// setClusterProfileConditions sets Ready, Progressing and Degraded conditions given the number of
+// matching clusters where features are still being provisioned and where at least one feature failed.
+func setClusterProfileConditions(clusterProfileScope *scope.ClusterProfileScope, inProgress, failed int) {
+	reason := configv1alpha1.ProvisionedReason
+	message := "all matching clusters are provisioned"
+	switch {
+	case failed != 0:
+		reason = configv1alpha1.ProvisioningFailedReason
+		message = fmt.Sprintf("%d cluster(s) failed to be provisioned. %d cluster(s) being provisioned",
+			failed, inProgress)
+	case inProgress != 0:
+		reason = configv1alpha1.ProvisioningReason
+		message = fmt.Sprintf("%d cluster(s) being provisioned", inProgress)
+	}
+
+	toConditionStatus := func(b bool) metav1.ConditionStatus {
+		if b {
+			return metav1.ConditionTrue
+		}
+		return metav1.ConditionFalse
+	}
+
+	clusterProfileScope.SetCondition(configv1alpha1.ReadyCondition,
+		toConditionStatus(failed == 0 && inProgress == 0), reason, message)
+	clusterProfileScope.SetCondition(configv1alpha1.ProgressingCondition,
+		toConditionStatus(inProgress != 0), reason, message)
+	clusterProfileScope.SetCondition(configv1alpha1.DegradedCondition,
+		toConditionStatus(failed != 0), reason, message)
+}
+
+// setClusterProfileInvalidSpecConditions sets conditions when ClusterProfile Spec cannot be processed.
+func setClusterProfileInvalidSpecConditions(clusterProfileScope *scope.ClusterProfileScope, message string) {
+	clusterProfileScope.SetCondition(configv1alpha1.ReadyCondition, metav1.ConditionFalse,
+		configv1alpha1.InvalidSpecReason, message)
+	clusterProfileScope.SetCondition(configv1alpha1.ProgressingCondition, metav1.ConditionFalse,
+		configv1alpha1.InvalidSpecReason, message)
+	clusterProfileScope.SetCondition(configv1alpha1.DegradedCondition, metav1.ConditionTrue,
+		configv1alpha1.InvalidSpecReason, message)
+}
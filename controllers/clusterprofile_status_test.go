@@ -0,0 +1,200 @@
+/*
+Copyright 2022-23. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2/klogr"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	libsveltosv1alpha1 "github.com/projectsveltos/libsveltos/api/v1alpha1"
+	configv1alpha1 "github.com/projectsveltos/sveltos-manager/api/v1alpha1"
+	"github.com/projectsveltos/sveltos-manager/controllers"
+	"github.com/projectsveltos/sveltos-manager/pkg/scope"
+)
+
+var _ = Describe("ClusterProfile: aggregated status", func() {
+	var clusterProfile *configv1alpha1.ClusterProfile
+	var namespace string
+
+	BeforeEach(func() {
+		namespace = "status" + randomString()
+
+		clusterProfile = &configv1alpha1.ClusterProfile{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:       clusterProfileNamePrefix + randomString(),
+				Generation: 2,
+			},
+			Spec: configv1alpha1.ClusterProfileSpec{
+				ClusterSelector: selector,
+				SyncMode:        configv1alpha1.SyncModeContinuous,
+				PolicyRefs: []libsveltosv1alpha1.PolicyRef{
+					{
+						Kind:      string(libsveltosv1alpha1.ConfigMapReferencedResourceKind),
+						Namespace: namespace,
+						Name:      randomString(),
+					},
+				},
+			},
+		}
+	})
+
+	getClusterSummary := func(clusterName string, status configv1alpha1.FeatureStatus,
+		failureMessage *string) *configv1alpha1.ClusterSummary {
+
+		clusterSummary := &configv1alpha1.ClusterSummary{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      controllers.GetClusterSummaryName(clusterProfile.Name, clusterName, false),
+				Namespace: namespace,
+			},
+			Spec: configv1alpha1.ClusterSummarySpec{
+				ClusterNamespace:   namespace,
+				ClusterName:        clusterName,
+				ClusterType:        libsveltosv1alpha1.ClusterTypeCapi,
+				ClusterProfileSpec: clusterProfile.Spec,
+			},
+			Status: configv1alpha1.ClusterSummaryStatus{
+				FeatureSummaries: []configv1alpha1.FeatureSummary{
+					{FeatureID: configv1alpha1.FeatureResources, Status: status, FailureMessage: failureMessage},
+				},
+			},
+		}
+		addLabelsToClusterSummary(clusterSummary, clusterProfile.Name, namespace, clusterName)
+		return clusterSummary
+	}
+
+	getClusterRef := func() corev1.ObjectReference {
+		return corev1.ObjectReference{
+			Namespace: namespace, Name: upstreamClusterNamePrefix + randomString(),
+			Kind: clusterKind, APIVersion: clusterv1.GroupVersion.String(),
+		}
+	}
+
+	It("updateClusterProfileStatus aggregates ClusterSummary statuses", func() {
+		provisionedCluster := getClusterRef()
+		failedCluster := getClusterRef()
+		notReadyCluster := getClusterRef()
+
+		clusterProfile.Status.MatchingClusterRefs = []corev1.ObjectReference{
+			provisionedCluster, failedCluster, notReadyCluster,
+		}
+
+		failureMessage := randomString()
+		provisioned := getClusterSummary(provisionedCluster.Name, configv1alpha1.FeatureStatusProvisioned, nil)
+		failed := getClusterSummary(failedCluster.Name, configv1alpha1.FeatureStatusFailed, &failureMessage)
+
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(clusterProfile, provisioned, failed).Build()
+
+		reconciler := getClusterProfileReconciler(c)
+		clusterProfileScope, err := scope.NewClusterProfileScope(scope.ClusterProfileScopeParams{
+			Client:         c,
+			Logger:         klogr.New(),
+			ClusterProfile: clusterProfile,
+			ControllerName: "clusterprofile",
+		})
+		Expect(err).To(BeNil())
+
+		Expect(controllers.UpdateClusterProfileStatus(reconciler, context.TODO(), clusterProfileScope)).To(Succeed())
+
+		status := &clusterProfile.Status
+		Expect(status.FeatureClusterCounts).To(ConsistOf(configv1alpha1.FeatureClusterCount{
+			FeatureID: configv1alpha1.FeatureResources, Provisioned: 1, Failed: 1}))
+		Expect(status.FailingClusters).To(ConsistOf(configv1alpha1.FailingCluster{
+			ClusterRef: failedCluster, FeatureID: configv1alpha1.FeatureResources, FailureMessage: &failureMessage}))
+		Expect(status.ClusterStatuses).To(ConsistOf(
+			configv1alpha1.ClusterProfileClusterStatus{ClusterRef: provisionedCluster, ObservedGeneration: 2},
+			configv1alpha1.ClusterProfileClusterStatus{ClusterRef: failedCluster},
+			configv1alpha1.ClusterProfileClusterStatus{ClusterRef: notReadyCluster},
+		))
+		Expect(meta.IsStatusConditionFalse(status.Conditions, configv1alpha1.ReadyCondition)).To(BeTrue())
+		Expect(meta.IsStatusConditionTrue(status.Conditions, configv1alpha1.ProgressingCondition)).To(BeTrue())
+		Expect(meta.IsStatusConditionTrue(status.Conditions, configv1alpha1.DegradedCondition)).To(BeTrue())
+
+		By("Changing ClusterProfile Spec. Observed generation is not moved till new Spec is provisioned")
+		clusterProfile.Generation = 3
+		clusterProfile.Spec.StopMatchingBehavior = configv1alpha1.LeavePolicies
+		clusterProfile.Status.MatchingClusterRefs = []corev1.ObjectReference{provisionedCluster}
+		Expect(controllers.UpdateClusterProfileStatus(reconciler, context.TODO(), clusterProfileScope)).To(Succeed())
+		Expect(status.ClusterStatuses).To(ConsistOf(
+			configv1alpha1.ClusterProfileClusterStatus{ClusterRef: provisionedCluster, ObservedGeneration: 2}))
+		Expect(status.FailingClusters).To(BeEmpty())
+		Expect(meta.IsStatusConditionFalse(status.Conditions, configv1alpha1.ReadyCondition)).To(BeTrue())
+		Expect(meta.IsStatusConditionFalse(status.Conditions, configv1alpha1.DegradedCondition)).To(BeTrue())
+
+		By("New Spec is not processed by ClusterSummary controller yet. Observed generation is not moved")
+		Expect(c.Get(context.TODO(), client.ObjectKeyFromObject(provisioned), provisioned)).To(Succeed())
+		provisioned.Spec.ClusterProfileSpec = clusterProfile.Spec
+		provisioned.Generation = 2
+		Expect(c.Update(context.TODO(), provisioned)).To(Succeed())
+		provisioned.Status.ObservedGeneration = 1
+		Expect(c.Status().Update(context.TODO(), provisioned)).To(Succeed())
+		Expect(controllers.UpdateClusterProfileStatus(reconciler, context.TODO(), clusterProfileScope)).To(Succeed())
+		Expect(status.ClusterStatuses).To(ConsistOf(
+			configv1alpha1.ClusterProfileClusterStatus{ClusterRef: provisionedCluster, ObservedGeneration: 2}))
+		Expect(meta.IsStatusConditionTrue(status.Conditions, configv1alpha1.ProgressingCondition)).To(BeTrue())
+
+		By("New Spec is provisioned")
+		provisioned.Status.ObservedGeneration = 2
+		Expect(c.Status().Update(context.TODO(), provisioned)).To(Succeed())
+		Expect(controllers.UpdateClusterProfileStatus(reconciler, context.TODO(), clusterProfileScope)).To(Succeed())
+		Expect(status.ClusterStatuses).To(ConsistOf(
+			configv1alpha1.ClusterProfileClusterStatus{ClusterRef: provisionedCluster, ObservedGeneration: 3}))
+		Expect(meta.IsStatusConditionTrue(status.Conditions, configv1alpha1.ReadyCondition)).To(BeTrue())
+		Expect(meta.IsStatusConditionFalse(status.Conditions, configv1alpha1.ProgressingCondition)).To(BeTrue())
+		ready := meta.FindStatusCondition(status.Conditions, configv1alpha1.ReadyCondition)
+		Expect(ready.ObservedGeneration).To(Equal(int64(3)))
+		Expect(ready.Reason).To(Equal(configv1alpha1.ProvisionedReason))
+
+		By("Changing ClusterProfile Spec fields not affecting what is deployed")
+		clusterProfile.Generation = 4
+		clusterProfile.Spec.DependsOn = []string{randomString()}
+		Expect(controllers.UpdateClusterProfileStatus(reconciler, context.TODO(), clusterProfileScope)).To(Succeed())
+		Expect(status.ClusterStatuses).To(ConsistOf(
+			configv1alpha1.ClusterProfileClusterStatus{ClusterRef: provisionedCluster, ObservedGeneration: 4}))
+	})
+
+	It("Reconcile sets conditions when ClusterProfile Spec is invalid", func() {
+		clusterProfile.Spec.DependsOn = []string{clusterProfile.Name}
+
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(clusterProfile).Build()
+
+		reconciler := getClusterProfileReconciler(c)
+		_, err := reconciler.Reconcile(context.TODO(), ctrl.Request{
+			NamespacedName: client.ObjectKey{Name: clusterProfile.Name},
+		})
+		Expect(err).To(BeNil())
+
+		currentClusterProfile := &configv1alpha1.ClusterProfile{}
+		Expect(c.Get(context.TODO(), client.ObjectKey{Name: clusterProfile.Name}, currentClusterProfile)).To(Succeed())
+		ready := meta.FindStatusCondition(currentClusterProfile.Status.Conditions, configv1alpha1.ReadyCondition)
+		Expect(ready).ToNot(BeNil())
+		Expect(ready.Status).To(Equal(metav1.ConditionFalse))
+		Expect(ready.Reason).To(Equal(configv1alpha1.InvalidSpecReason))
+		Expect(meta.IsStatusConditionTrue(currentClusterProfile.Status.Conditions,
+			configv1alpha1.DegradedCondition)).To(BeTrue())
+	})
+})
@@ -122,3 +122,33 @@ func (r *ClusterProfileReconciler) requeueClusterProfileForMachine(
 
 	return requests
 }
+
+func (r *ClusterProfileReconciler) requeueClusterProfileForClusterSummary(
+	o client.Object,
+) []reconcile.Request {
+
+	clusterSummary := o
+	logger := klogr.New().WithValues(
+		"objectMapper",
+		"requeueClusterProfileForClusterSummary",
+		"namespace",
+		clusterSummary.GetNamespace(),
+		"clusterSummary",
+		clusterSummary.GetName(),
+	)
+
+	clusterProfileName, ok := clusterSummary.GetLabels()[ClusterProfileLabelName]
+	if !ok {
+		logger.V(logs.LogVerbose).Info("ClusterSummary has no ClusterProfileLabelName")
+		return nil
+	}
+
+	logger.V(logs.LogDebug).Info("queuing ClusterProfile", "clusterProfile", clusterProfileName)
+	return []reconcile.Request{
+		{
+			NamespacedName: client.ObjectKey{
+				Name: clusterProfileName,
+			},
+		},
+	}
+}
@@ -26,6 +26,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2/klogr"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
@@ -35,6 +36,7 @@ import (
 	libsveltosset "github.com/projectsveltos/libsveltos/lib/set"
 	configv1alpha1 "github.com/projectsveltos/sveltos-manager/api/v1alpha1"
 	"github.com/projectsveltos/sveltos-manager/controllers"
+	"github.com/projectsveltos/sveltos-manager/pkg/scope"
 )
 
 var _ = Describe("ClusterProfileReconciler map functions", func() {
@@ -150,4 +152,82 @@ var _ = Describe("ClusterProfileReconciler map functions", func() {
 		requests = controllers.RequeueClusterProfileForCluster(reconciler, cluster)
 		Expect(requests).To(HaveLen(0))
 	})
+
+	It("requeueClusterProfileForCluster returns ClusterProfiles referencing the cluster in ClusterRefs", func() {
+		cluster := &clusterv1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      upstreamClusterNamePrefix + randomString(),
+				Namespace: namespace,
+			},
+		}
+		Expect(addTypeInformationToObject(scheme, cluster)).To(Succeed())
+
+		clusterProfile := &configv1alpha1.ClusterProfile{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: clusterProfileNamePrefix + randomString(),
+			},
+			Spec: configv1alpha1.ClusterProfileSpec{
+				ClusterSelector: libsveltosv1alpha1.Selector("env=production"),
+				ClusterRefs: []corev1.ObjectReference{
+					{Namespace: cluster.Namespace, Name: cluster.Name, Kind: cluster.Kind, APIVersion: cluster.APIVersion},
+				},
+			},
+		}
+
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(clusterProfile).Build()
+
+		reconciler := getClusterProfileReconciler(c)
+
+		clusterProfileScope, err := scope.NewClusterProfileScope(scope.ClusterProfileScopeParams{
+			Client:         c,
+			Logger:         klogr.New(),
+			ClusterProfile: clusterProfile,
+			ControllerName: "clusterprofile",
+		})
+		Expect(err).To(BeNil())
+
+		By("Cluster referenced in ClusterRefs does not exist yet. Its creation must requeue ClusterProfile")
+		controllers.UpdateClusterProfileMaps(reconciler, clusterProfileScope)
+		requests := controllers.RequeueClusterProfileForCluster(reconciler, cluster)
+		expected := reconcile.Request{NamespacedName: types.NamespacedName{Name: clusterProfile.Name}}
+		Expect(requests).To(ContainElement(expected))
+
+		By("Removing cluster from ClusterRefs")
+		clusterProfile.Spec.ClusterRefs = nil
+		controllers.UpdateClusterProfileMaps(reconciler, clusterProfileScope)
+		requests = controllers.RequeueClusterProfileForCluster(reconciler, cluster)
+		Expect(requests).To(HaveLen(0))
+
+		By("ClusterProfile selecting clusters with a CEL expression")
+		Expect(controllers.IsCELSelectorUsed(reconciler)).To(BeFalse())
+		clusterProfile.Spec.StructuredClusterSelector = &configv1alpha1.StructuredClusterSelector{
+			CELExpression: "status.ready == true",
+		}
+		controllers.UpdateClusterProfileMaps(reconciler, clusterProfileScope)
+		Expect(controllers.IsCELSelectorUsed(reconciler)).To(BeTrue())
+
+		clusterProfile.Spec.StructuredClusterSelector = nil
+		controllers.UpdateClusterProfileMaps(reconciler, clusterProfileScope)
+		Expect(controllers.IsCELSelectorUsed(reconciler)).To(BeFalse())
+	})
+
+	It("requeueClusterProfileForClusterSummary returns owning ClusterProfile", func() {
+		clusterProfileName := clusterProfileNamePrefix + randomString()
+		clusterName := randomString()
+		clusterSummary := &configv1alpha1.ClusterSummary{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      controllers.GetClusterSummaryName(clusterProfileName, clusterName, false),
+				Namespace: namespace,
+			},
+		}
+
+		reconciler := getClusterProfileReconciler(fake.NewClientBuilder().WithScheme(scheme).Build())
+
+		requests := controllers.RequeueClusterProfileForClusterSummary(reconciler, clusterSummary)
+		Expect(requests).To(HaveLen(0))
+
+		addLabelsToClusterSummary(clusterSummary, clusterProfileName, namespace, clusterName)
+		requests = controllers.RequeueClusterProfileForClusterSummary(reconciler, clusterSummary)
+		Expect(requests).To(ConsistOf(reconcile.Request{NamespacedName: types.NamespacedName{Name: clusterProfileName}}))
+	})
 })
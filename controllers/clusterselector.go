@@ -0,0 +1,146 @@
+/*
+Copyright 2022-23. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+
+	"github.com/go-logr/logr"
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/ext"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	logs "github.com/projectsveltos/libsveltos/lib/logsettings"
+	configv1alpha1 "github.com/projectsveltos/sveltos-manager/api/v1alpha1"
+)
+
+const (
+	celObjectVariable = "object"
+	celSpecVariable   = "spec"
+	celStatusVariable = "status"
+)
+
+// clusterMatcher evaluates whether a Sveltos/CAPI Cluster matches a ClusterProfile.
+// A cluster matches if it matches ClusterProfile.Spec.ClusterSelector and, when set,
+// ClusterProfile.Spec.StructuredClusterSelector.
+type clusterMatcher struct {
+	selector      labels.Selector
+	labelSelector labels.Selector
+	program       cel.Program
+}
+
+// getClusterMatcher parses all ClusterProfile selectors. An error is returned if any
+// of those is not valid.
+func getClusterMatcher(clusterProfile *configv1alpha1.ClusterProfile) (*clusterMatcher, error) {
+	selector, err := labels.Parse(string(clusterProfile.Spec.ClusterSelector))
+	if err != nil {
+		return nil, fmt.Errorf("invalid clusterSelector: %w", err)
+	}
+
+	matcher := &clusterMatcher{selector: selector}
+
+	structuredSelector := clusterProfile.Spec.StructuredClusterSelector
+	if structuredSelector == nil {
+		return matcher, nil
+	}
+
+	if structuredSelector.LabelSelector != nil {
+		matcher.labelSelector, err = metav1.LabelSelectorAsSelector(structuredSelector.LabelSelector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid structuredClusterSelector labelSelector: %w", err)
+		}
+	}
+
+	if structuredSelector.CELExpression != "" {
+		matcher.program, err = getClusterCELProgram(structuredSelector.CELExpression)
+		if err != nil {
+			return nil, fmt.Errorf("invalid structuredClusterSelector celExpression: %w", err)
+		}
+	}
+
+	return matcher, nil
+}
+
+// getClusterCELProgram compiles a CEL expression evaluated against a Sveltos/CAPI Cluster
+func getClusterCELProgram(expression string) (cel.Program, error) {
+	env, err := cel.NewEnv(
+		cel.Variable(celObjectVariable, cel.DynType),
+		cel.Variable(celSpecVariable, cel.DynType),
+		cel.Variable(celStatusVariable, cel.DynType),
+		ext.Strings(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	ast, issues := env.Compile(expression)
+	if issues != nil && issues.Err() != nil {
+		return nil, issues.Err()
+	}
+
+	if !ast.OutputType().IsAssignableType(cel.BoolType) {
+		return nil, fmt.Errorf("expression must evaluate to a bool, not %s", ast.OutputType())
+	}
+
+	return env.Program(ast)
+}
+
+// matches returns true if cluster matches all selectors
+func (m *clusterMatcher) matches(cluster client.Object, logger logr.Logger) bool {
+	if !m.selector.Matches(labels.Set(cluster.GetLabels())) {
+		return false
+	}
+
+	if m.labelSelector != nil && !m.labelSelector.Matches(labels.Set(cluster.GetLabels())) {
+		return false
+	}
+
+	if m.program == nil {
+		return true
+	}
+
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(cluster)
+	if err != nil {
+		logger.V(logs.LogInfo).Info(fmt.Sprintf("failed to convert cluster %s/%s: %v",
+			cluster.GetNamespace(), cluster.GetName(), err))
+		return false
+	}
+
+	getField := func(name string) map[string]interface{} {
+		if v, ok := content[name].(map[string]interface{}); ok {
+			return v
+		}
+		return map[string]interface{}{}
+	}
+
+	out, _, err := m.program.Eval(map[string]interface{}{
+		celObjectVariable: content,
+		celSpecVariable:   getField("spec"),
+		celStatusVariable: getField("status"),
+	})
+	if err != nil {
+		logger.V(logs.LogDebug).Info(fmt.Sprintf("failed to evaluate celExpression for cluster %s/%s: %v",
+			cluster.GetNamespace(), cluster.GetName(), err))
+		return false
+	}
+
+	match, ok := out.Value().(bool)
+	return ok && match
+}
@@ -0,0 +1,192 @@
+/*
+Copyright 2022-23. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2/klogr"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	libsveltosv1alpha1 "github.com/projectsveltos/libsveltos/api/v1alpha1"
+	configv1alpha1 "github.com/projectsveltos/sveltos-manager/api/v1alpha1"
+	"github.com/projectsveltos/sveltos-manager/controllers"
+	"github.com/projectsveltos/sveltos-manager/pkg/scope"
+)
+
+var _ = Describe("ClusterProfile: StructuredClusterSelector", func() {
+	var namespace string
+
+	BeforeEach(func() {
+		namespace = "clusterselector" + randomString()
+	})
+
+	getCluster := func(env, version string) *clusterv1.Cluster {
+		return &clusterv1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      upstreamClusterNamePrefix + randomString(),
+				Namespace: namespace,
+				Labels: map[string]string{
+					"env": env,
+				},
+			},
+			Spec: clusterv1.ClusterSpec{
+				Topology: &clusterv1.Topology{
+					Class:   randomString(),
+					Version: version,
+				},
+			},
+		}
+	}
+
+	It("getMatchingClusters matches clusters using matchExpressions and CEL expression", func() {
+		matchingCluster := getCluster("qa", "v1.25.3")
+		oldVersionCluster := getCluster("qa", "v1.24.7")
+		otherEnvCluster := getCluster("dev", "v1.26.0")
+		sveltosCluster := &libsveltosv1alpha1.SveltosCluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      randomString(),
+				Namespace: namespace,
+				Labels: map[string]string{
+					"env": "production",
+				},
+			},
+		}
+
+		clusterProfile := &configv1alpha1.ClusterProfile{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: clusterProfileNamePrefix + randomString(),
+			},
+			Spec: configv1alpha1.ClusterProfileSpec{
+				StructuredClusterSelector: &configv1alpha1.StructuredClusterSelector{
+					LabelSelector: &metav1.LabelSelector{
+						MatchExpressions: []metav1.LabelSelectorRequirement{
+							{Key: "env", Operator: metav1.LabelSelectorOpIn, Values: []string{"qa", "production"}},
+						},
+					},
+					// SveltosCluster has no topology. Evaluation fails and so it does not match
+					CELExpression: `int(spec.topology.version.split(".")[1]) >= 25`,
+				},
+			},
+		}
+
+		initObjects := []client.Object{
+			clusterProfile,
+			matchingCluster,
+			oldVersionCluster,
+			otherEnvCluster,
+			sveltosCluster,
+		}
+
+		Expect(addTypeInformationToObject(scheme, matchingCluster)).To(Succeed())
+
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(initObjects...).Build()
+
+		reconciler := getClusterProfileReconciler(c)
+
+		clusterProfileScope, err := scope.NewClusterProfileScope(scope.ClusterProfileScopeParams{
+			Client:         c,
+			Logger:         klogr.New(),
+			ClusterProfile: clusterProfile,
+			ControllerName: "clusterprofile",
+		})
+		Expect(err).To(BeNil())
+
+		matches, err := controllers.GetMatchingClusters(reconciler, context.TODO(), clusterProfileScope)
+		Expect(err).To(BeNil())
+		Expect(len(matches)).To(Equal(1))
+		Expect(matches).To(ContainElement(
+			corev1.ObjectReference{Namespace: matchingCluster.Namespace, Name: matchingCluster.Name,
+				Kind: matchingCluster.Kind, APIVersion: matchingCluster.APIVersion}))
+
+		By("Matching SveltosCluster using object kind")
+		clusterProfile.Spec.StructuredClusterSelector.CELExpression = `object.kind == "SveltosCluster"`
+		matches, err = controllers.GetMatchingClusters(reconciler, context.TODO(), clusterProfileScope)
+		Expect(err).To(BeNil())
+		Expect(len(matches)).To(Equal(1))
+		Expect(matches[0].Name).To(Equal(sveltosCluster.Name))
+	})
+
+	It("Reconcile reports invalid selectors in ClusterProfile Status", func() {
+		clusterProfile := &configv1alpha1.ClusterProfile{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: clusterProfileNamePrefix + randomString(),
+			},
+			Spec: configv1alpha1.ClusterProfileSpec{
+				StructuredClusterSelector: &configv1alpha1.StructuredClusterSelector{
+					CELExpression: `spec.topology.version + `,
+				},
+			},
+		}
+
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(clusterProfile).Build()
+
+		reconcileAndGetFailureMessage := func() *string {
+			reconciler := getClusterProfileReconciler(c)
+			_, err := reconciler.Reconcile(context.TODO(), ctrl.Request{
+				NamespacedName: client.ObjectKey{Name: clusterProfile.Name},
+			})
+			Expect(err).To(BeNil())
+
+			currentClusterProfile := &configv1alpha1.ClusterProfile{}
+			Expect(c.Get(context.TODO(), client.ObjectKey{Name: clusterProfile.Name}, currentClusterProfile)).To(Succeed())
+			return currentClusterProfile.Status.FailureMessage
+		}
+
+		By("CEL expression with syntax error")
+		failureMessage := reconcileAndGetFailureMessage()
+		Expect(failureMessage).ToNot(BeNil())
+		Expect(*failureMessage).To(ContainSubstring("celExpression"))
+
+		By("CEL expression not evaluating to a bool")
+		currentClusterProfile := &configv1alpha1.ClusterProfile{}
+		Expect(c.Get(context.TODO(), client.ObjectKey{Name: clusterProfile.Name}, currentClusterProfile)).To(Succeed())
+		currentClusterProfile.Spec.StructuredClusterSelector.CELExpression = `size(object.metadata.labels)`
+		Expect(c.Update(context.TODO(), currentClusterProfile)).To(Succeed())
+		failureMessage = reconcileAndGetFailureMessage()
+		Expect(failureMessage).ToNot(BeNil())
+		Expect(*failureMessage).To(ContainSubstring("celExpression"))
+
+		By("Label selector with invalid operator")
+		Expect(c.Get(context.TODO(), client.ObjectKey{Name: clusterProfile.Name}, currentClusterProfile)).To(Succeed())
+		currentClusterProfile.Spec.StructuredClusterSelector.CELExpression = ""
+		currentClusterProfile.Spec.StructuredClusterSelector.LabelSelector = &metav1.LabelSelector{
+			MatchExpressions: []metav1.LabelSelectorRequirement{
+				{Key: "env", Operator: metav1.LabelSelectorOperator(randomString())},
+			},
+		}
+		Expect(c.Update(context.TODO(), currentClusterProfile)).To(Succeed())
+		failureMessage = reconcileAndGetFailureMessage()
+		Expect(failureMessage).ToNot(BeNil())
+		Expect(*failureMessage).To(ContainSubstring("labelSelector"))
+
+		By("Fixing selectors clears failure message")
+		Expect(c.Get(context.TODO(), client.ObjectKey{Name: clusterProfile.Name}, currentClusterProfile)).To(Succeed())
+		currentClusterProfile.Spec.StructuredClusterSelector.LabelSelector.MatchExpressions[0].Operator =
+			metav1.LabelSelectorOpExists
+		Expect(c.Update(context.TODO(), currentClusterProfile)).To(Succeed())
+		Expect(reconcileAndGetFailureMessage()).To(BeNil())
+	})
+})
@@ -27,6 +27,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/rest"
@@ -119,8 +120,10 @@ type ClusterSummaryReconciler struct {
 //+kubebuilder:rbac:groups=config.projectsveltos.io,resources=clusterconfigurations/status,verbs=get;list;update
 //+kubebuilder:rbac:groups=config.projectsveltos.io,resources=clusterreports,verbs=get;list;watch
 //+kubebuilder:rbac:groups=config.projectsveltos.io,resources=clusterreports/status,verbs=get;list;update
+//+kubebuilder:rbac:groups=config.projectsveltos.io,resources=helmrepositories,verbs=get;list;watch
 //+kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
 //+kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
 //+kubebuilder:rbac:groups=controlplane.cluster.x-k8s.io,resources=kubeadmcontrolplanes,verbs=get;watch;list
 //+kubebuilder:rbac:groups="infrastructure.cluster.x-k8s.io",resources="*",verbs=get;watch;list
 
@@ -188,7 +191,9 @@ func (r *ClusterSummaryReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 	}
 
 	// Handle non-deleted clusterSummary
-	return r.reconcileNormal(ctx, clusterSummaryScope, logger)
+	result, err := r.reconcileNormal(ctx, clusterSummaryScope, logger)
+	updateClusterSummaryConditions(clusterSummaryScope)
+	return result, err
 }
 
 func (r *ClusterSummaryReconciler) reconcileDelete(
@@ -243,6 +248,10 @@ func (r *ClusterSummaryReconciler) reconcileDelete(
 
 	r.cleanMaps(clusterSummaryScope)
 
+	clusterSummary := clusterSummaryScope.ClusterSummary
+	removeRESTClientGetters(clusterSummary.Spec.ClusterNamespace, clusterSummary.Spec.ClusterName,
+		clusterSummary.Spec.ClusterType)
+
 	logger.V(logs.LogInfo).Info("Reconcile delete success")
 
 	return reconcile.Result{}, nil
@@ -265,6 +274,7 @@ func (r *ClusterSummaryReconciler) reconcileNormal(
 
 	if !r.shouldReconcile(clusterSummaryScope, logger) {
 		logger.V(logs.LogInfo).Info("ClusterSummary does not need a reconciliation")
+		clusterSummaryScope.SetObservedGeneration()
 		return reconcile.Result{}, nil
 	}
 
@@ -277,21 +287,76 @@ func (r *ClusterSummaryReconciler) reconcileNormal(
 	if err != nil {
 		return reconcile.Result{}, err
 	}
+	setClusterSummaryPausedCondition(clusterSummaryScope, paused)
 	if paused {
 		logger.V(logs.LogInfo).Info("cluster is paused. Do nothing.")
 		return reconcile.Result{}, nil
 	}
 
+	reachable, err := r.isClusterReachable(ctx, clusterSummaryScope, logger)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if !reachable {
+		logger.V(logs.LogInfo).Info("cluster is not reachable yet")
+		return reconcile.Result{Requeue: true, RequeueAfter: normalRequeueAfter}, nil
+	}
+
+	// DryRun does not change anything in the cluster. So no need to wait for dependencies.
+	if !clusterSummaryScope.IsDryRunSync() {
+		provisioned, err := areDependenciesProvisioned(ctx, r.Client, clusterSummaryScope.ClusterSummary, logger)
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+		setDependenciesProvisionedCondition(clusterSummaryScope, provisioned)
+		if !provisioned {
+			logger.V(logs.LogInfo).Info("waiting for dependencies to be provisioned")
+			return reconcile.Result{Requeue: true, RequeueAfter: normalRequeueAfter}, nil
+		}
+
+		inSyncWindow, nextSyncTime, err := isInSyncWindow(clusterSummaryScope.ClusterSummary.Spec.ClusterProfileSpec.SyncWindows,
+			time.Now())
+		if err != nil {
+			logger.V(logs.LogInfo).Error(err, "failed to evaluate sync windows")
+			return reconcile.Result{}, err
+		}
+		if !inSyncWindow {
+			logger.V(logs.LogInfo).Info(fmt.Sprintf("outside sync window. Changes are held till %s", nextSyncTime))
+			if err := r.holdFeatures(ctx, clusterSummaryScope, logger); err != nil {
+				return reconcile.Result{}, err
+			}
+			clusterSummaryScope.SetNextSyncTime(&metav1.Time{Time: nextSyncTime})
+			// Features were evaluated against current Spec: changed ones are WaitingForWindow
+			clusterSummaryScope.SetObservedGeneration()
+			return reconcile.Result{Requeue: true, RequeueAfter: getSyncWindowRequeueAfter(nextSyncTime)}, nil
+		}
+	} else {
+		setDependenciesProvisionedCondition(clusterSummaryScope, true)
+	}
+	clusterSummaryScope.SetNextSyncTime(nil)
+
 	if err := r.updateChartMap(ctx, clusterSummaryScope, logger); err != nil {
 		return reconcile.Result{Requeue: true, RequeueAfter: normalRequeueAfter}, nil
 	}
 
-	if err := r.deploy(ctx, clusterSummaryScope, logger); err != nil {
+	// When a chart version is a constraint, pick up any newer matching version
+	refreshResolvedChartVersions(ctx, r.Client, clusterSummaryScope.ClusterSummary, logger)
+
+	// ObservedGeneration is set only once every feature is evaluated against current Spec.
+	// Paused, unreachable, or waiting for dependencies, Status still reflects previous Spec.
+	err = r.deploy(ctx, clusterSummaryScope, logger)
+	clusterSummaryScope.SetObservedGeneration()
+	if err != nil {
 		logger.V(logs.LogInfo).Error(err, "failed to deploy")
 		return reconcile.Result{Requeue: true, RequeueAfter: normalRequeueAfter}, nil
 	}
 
 	logger.V(logs.LogInfo).Info("Reconciling ClusterSummary success")
+	// When a chart version is a constraint, periodically check whether a newer matching
+	// version was published
+	if hasChartVersionConstraints(clusterSummaryScope.ClusterSummary) {
+		return reconcile.Result{Requeue: true, RequeueAfter: getChartVersionConstraintRequeueAfter()}, nil
+	}
 	return reconcile.Result{}, nil
 }
 
@@ -338,6 +403,16 @@ func (r *ClusterSummaryReconciler) SetupWithManager(ctx context.Context, mgr ctr
 		handler.EnqueueRequestsFromMapFunc(r.requeueClusterSummaryForReference),
 		SecretPredicates(mgr.GetLogger().WithValues("predicate", "secretpredicate")),
 	)
+	if err != nil {
+		return nil, err
+	}
+
+	// When HelmRepository changes, according to HelmRepositoryPredicates,
+	// one or more ClusterSummaries need to be reconciled.
+	err = c.Watch(&source.Kind{Type: &configv1alpha1.HelmRepository{}},
+		handler.EnqueueRequestsFromMapFunc(r.requeueClusterSummaryForReference),
+		HelmRepositoryPredicates(mgr.GetLogger().WithValues("predicate", "helmrepositorypredicate")),
+	)
 
 	if r.ReportMode == CollectFromManagementCluster {
 		go collectAndProcessResourceSummaries(ctx, mgr.GetClient(), mgr.GetLogger())
@@ -545,6 +620,16 @@ func (r *ClusterSummaryReconciler) updateMaps(ctx context.Context, clusterSummar
 	logger.V(logs.LogDebug).Info("update policy map")
 	currentReferences := r.getCurrentReferences(clusterSummaryScope)
 
+	// HelmRepositories and credentials Secrets referenced by helm charts
+	helmReferences, err := getHelmReferences(ctx, r.Client, clusterSummaryScope.ClusterSummary)
+	if err != nil {
+		return err
+	}
+	for _, referencedResource := range helmReferences.Items() {
+		tmpResource := referencedResource
+		currentReferences.Insert(&tmpResource)
+	}
+
 	r.PolicyMux.Lock()
 	defer r.PolicyMux.Unlock()
 
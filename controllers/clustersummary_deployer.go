@@ -79,6 +79,9 @@ func (r *ClusterSummaryReconciler) deployFeature(ctx context.Context, clusterSum
 	if err != nil {
 		return err
 	}
+	// Whatever the outcome, feature status from now on refers to current Spec
+	defer clusterSummaryScope.SetFeatureDeployedGeneration(f.id)
+
 	hash := r.getHash(clusterSummaryScope, f.id)
 	isConfigSame := reflect.DeepEqual(hash, currentHash)
 	if !isConfigSame {
@@ -137,6 +140,8 @@ func (r *ClusterSummaryReconciler) deployFeature(ctx context.Context, clusterSum
 		return err
 	}
 
+	recordEvent(clusterSummary, corev1.EventTypeNormal, eventReasonDeployQueued,
+		"%s feature deploy queued", f.id)
 	return fmt.Errorf("request is queued")
 }
 
@@ -232,6 +237,8 @@ func (r *ClusterSummaryReconciler) undeployFeature(ctx context.Context, clusterS
 		return err
 	}
 
+	recordEvent(clusterSummary, corev1.EventTypeNormal, eventReasonWithdrawQueued,
+		"%s feature withdraw queued", f.id)
 	return fmt.Errorf("cleanup request is queued")
 }
 
@@ -344,6 +351,9 @@ func (r *ClusterSummaryReconciler) updateFeatureStatus(clusterSummaryScope *scop
 	}
 	logger = logger.WithValues("hash", fmt.Sprintf("%x", hash), "status", *status)
 	logger.V(logs.LogDebug).Info("updating clustersummary status")
+
+	r.recordFeatureStatusEvent(clusterSummaryScope, featureID, *status, statusError)
+
 	now := metav1.NewTime(time.Now())
 	switch *status {
 	case configv1alpha1.FeatureStatusProvisioned:
@@ -365,6 +375,43 @@ func (r *ClusterSummaryReconciler) updateFeatureStatus(clusterSummaryScope *scop
 	clusterSummaryScope.SetLastAppliedTime(featureID, &now)
 }
 
+// recordFeatureStatusEvent emits an Event when feature is provisioned, removed or failed.
+// Events are emitted only when status (or failure message) changes.
+func (r *ClusterSummaryReconciler) recordFeatureStatusEvent(clusterSummaryScope *scope.ClusterSummaryScope,
+	featureID configv1alpha1.FeatureID, status configv1alpha1.FeatureStatus, statusError error) {
+
+	var previous *configv1alpha1.FeatureSummary
+	for i := range clusterSummaryScope.ClusterSummary.Status.FeatureSummaries {
+		if clusterSummaryScope.ClusterSummary.Status.FeatureSummaries[i].FeatureID == featureID {
+			previous = &clusterSummaryScope.ClusterSummary.Status.FeatureSummaries[i]
+		}
+	}
+
+	isSameStatus := previous != nil && previous.Status == status
+
+	switch status {
+	case configv1alpha1.FeatureStatusProvisioned:
+		if !isSameStatus {
+			recordEvent(clusterSummaryScope.ClusterSummary, corev1.EventTypeNormal, eventReasonDeployCompleted,
+				"%s feature provisioned", featureID)
+		}
+	case configv1alpha1.FeatureStatusRemoved:
+		if !isSameStatus {
+			recordEvent(clusterSummaryScope.ClusterSummary, corev1.EventTypeNormal, eventReasonWithdrawCompleted,
+				"%s feature removed", featureID)
+		}
+	case configv1alpha1.FeatureStatusFailed:
+		if statusError == nil {
+			return
+		}
+		if isSameStatus && previous.FailureMessage != nil && *previous.FailureMessage == statusError.Error() {
+			return
+		}
+		recordEvent(clusterSummaryScope.ClusterSummary, corev1.EventTypeWarning, eventReasonFeatureFailed,
+			"%s feature failed: %v", featureID, statusError)
+	}
+}
+
 func (r *ClusterSummaryReconciler) convertResultStatus(result deployer.Result) *configv1alpha1.FeatureStatus {
 	switch result.ResultStatus {
 	case deployer.Deployed:
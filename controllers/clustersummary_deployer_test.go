@@ -382,6 +382,11 @@ var _ = Describe("ClustersummaryDeployer", func() {
 		key := deployer.GetKey(clusterSummary.Spec.ClusterNamespace, clusterSummary.Spec.ClusterName,
 			clusterSummary.Name, string(configv1alpha1.FeatureResources), libsveltosv1alpha1.ClusterTypeCapi, false)
 		Expect(dep.IsKeyInProgress(key)).To(BeTrue())
+
+		// Feature status now refers to current ClusterSummary Spec
+		featureSummaries := clusterSummaryScope.ClusterSummary.Status.FeatureSummaries
+		Expect(len(featureSummaries)).To(Equal(1))
+		Expect(featureSummaries[0].DeployedGeneration).To(Equal(clusterSummaryScope.ClusterSummary.Generation))
 	})
 
 	It("undeployFeature when feature is removed, does nothing", func() {
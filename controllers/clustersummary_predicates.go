@@ -26,6 +26,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 
 	logs "github.com/projectsveltos/libsveltos/lib/logsettings"
+	configv1alpha1 "github.com/projectsveltos/sveltos-manager/api/v1alpha1"
 )
 
 // ConfigMapPredicates predicates for ConfigMaps. ClusterSummaryReconciler watches ConfigMap events
@@ -108,6 +109,46 @@ func SecretPredicates(logger logr.Logger) predicate.Funcs {
 	}
 }
 
+// HelmRepositoryPredicates predicates for HelmRepositories. ClusterSummaryReconciler watches HelmRepository
+// events and react to those by reconciling itself based on following predicates
+func HelmRepositoryPredicates(logger logr.Logger) predicate.Funcs {
+	return predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			newHelmRepository := e.ObjectNew.(*configv1alpha1.HelmRepository)
+			oldHelmRepository := e.ObjectOld.(*configv1alpha1.HelmRepository)
+			log := logger.WithValues("predicate", "updateEvent",
+				"helmrepository", newHelmRepository.Name,
+			)
+
+			if oldHelmRepository == nil {
+				log.V(logs.LogVerbose).Info("Old HelmRepository is nil. Reconcile ClusterSummaries.")
+				return true
+			}
+
+			if !reflect.DeepEqual(oldHelmRepository.Spec, newHelmRepository.Spec) {
+				log.V(logs.LogVerbose).Info(
+					"HelmRepository Spec changed. Will attempt to reconcile associated ClusterSummaries.",
+				)
+				return true
+			}
+
+			// otherwise, return false
+			log.V(logs.LogVerbose).Info(
+				"HelmRepository did not match expected conditions.  Will not attempt to reconcile associated ClusterSummaries.")
+			return false
+		},
+		CreateFunc: func(e event.CreateEvent) bool {
+			return CreateFuncTrue(e, logger)
+		},
+		DeleteFunc: func(e event.DeleteEvent) bool {
+			return DeleteFuncTrue(e, logger)
+		},
+		GenericFunc: func(e event.GenericEvent) bool {
+			return GenericFuncFalse(e, logger)
+		},
+	}
+}
+
 var (
 	CreateFuncTrue = func(e event.CreateEvent, logger logr.Logger) bool {
 		log := logger.WithValues("predicate", "createEvent",
@@ -29,6 +29,7 @@ import (
 	"k8s.io/klog/v2/klogr"
 	"sigs.k8s.io/controller-runtime/pkg/event"
 
+	configv1alpha1 "github.com/projectsveltos/sveltos-manager/api/v1alpha1"
 	"github.com/projectsveltos/sveltos-manager/controllers"
 )
 
@@ -182,3 +183,61 @@ var _ = Describe("Clustersummary Predicates: SecretPredicates", func() {
 		Expect(result).To(BeFalse())
 	})
 })
+
+var _ = Describe("Clustersummary Predicates: HelmRepositoryPredicates", func() {
+	var logger logr.Logger
+	var helmRepository *configv1alpha1.HelmRepository
+
+	BeforeEach(func() {
+		logger = klogr.New()
+		helmRepository = &configv1alpha1.HelmRepository{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: randomString(),
+			},
+			Spec: configv1alpha1.HelmRepositorySpec{
+				URL: "https://charts.bitnami.com/bitnami",
+			},
+		}
+	})
+
+	It("Create returns true", func() {
+		helmRepositoryPredicate := controllers.HelmRepositoryPredicates(logger)
+
+		e := event.CreateEvent{
+			Object: helmRepository,
+		}
+
+		result := helmRepositoryPredicate.Create(e)
+		Expect(result).To(BeTrue())
+	})
+
+	It("Update returns true when Spec has changed", func() {
+		helmRepositoryPredicate := controllers.HelmRepositoryPredicates(logger)
+
+		oldHelmRepository := helmRepository.DeepCopy()
+		helmRepository.Spec.SecretRef = &corev1.ObjectReference{Namespace: randomString(), Name: randomString()}
+
+		e := event.UpdateEvent{
+			ObjectNew: helmRepository,
+			ObjectOld: oldHelmRepository,
+		}
+
+		result := helmRepositoryPredicate.Update(e)
+		Expect(result).To(BeTrue())
+	})
+
+	It("Update returns false when Spec has not changed", func() {
+		helmRepositoryPredicate := controllers.HelmRepositoryPredicates(logger)
+
+		oldHelmRepository := helmRepository.DeepCopy()
+		oldHelmRepository.Labels = map[string]string{"env": "testing"}
+
+		e := event.UpdateEvent{
+			ObjectNew: helmRepository,
+			ObjectOld: oldHelmRepository,
+		}
+
+		result := helmRepositoryPredicate.Update(e)
+		Expect(result).To(BeFalse())
+	})
+})
@@ -0,0 +1,197 @@
+/*
+Copyright 2022-23. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/projectsveltos/libsveltos/lib/clusterproxy"
+	logs "github.com/projectsveltos/libsveltos/lib/logsettings"
+	configv1alpha1 "github.com/projectsveltos/sveltos-manager/api/v1alpha1"
+	"github.com/projectsveltos/sveltos-manager/pkg/scope"
+)
+
+// setClusterSummaryPausedCondition sets Paused condition.
+func setClusterSummaryPausedCondition(clusterSummaryScope *scope.ClusterSummaryScope, paused bool) {
+	if paused {
+		clusterSummaryScope.SetCondition(configv1alpha1.PausedCondition, metav1.ConditionTrue,
+			configv1alpha1.PausedReason, "cluster or clusterSummary is paused")
+		return
+	}
+	clusterSummaryScope.SetCondition(configv1alpha1.PausedCondition, metav1.ConditionFalse,
+		configv1alpha1.NotPausedReason, "")
+}
+
+// setDependenciesProvisionedCondition sets DependenciesProvisioned condition.
+func setDependenciesProvisionedCondition(clusterSummaryScope *scope.ClusterSummaryScope, provisioned bool) {
+	if provisioned {
+		clusterSummaryScope.SetCondition(configv1alpha1.DependenciesProvisionedCondition, metav1.ConditionTrue,
+			configv1alpha1.DependenciesProvisionedReason, "")
+		return
+	}
+	clusterSummaryScope.SetCondition(configv1alpha1.DependenciesProvisionedCondition, metav1.ConditionFalse,
+		configv1alpha1.WaitingForDependenciesReason,
+		fmt.Sprintf("waiting for ClusterProfiles %s to be provisioned",
+			strings.Join(clusterSummaryScope.ClusterSummary.Spec.ClusterProfileSpec.DependsOn, ", ")))
+}
+
+// isClusterReachable returns true if Sveltos/CAPI Cluster exists and is ready to be configured.
+// ClusterReachable condition is set accordingly.
+func (r *ClusterSummaryReconciler) isClusterReachable(ctx context.Context,
+	clusterSummaryScope *scope.ClusterSummaryScope, logger logr.Logger) (bool, error) {
+
+	cs := clusterSummaryScope.ClusterSummary
+	clusterRef := getClusterRef(cs.Spec.ClusterNamespace, cs.Spec.ClusterName, cs.Spec.ClusterType)
+
+	_, err := getCluster(ctx, r.Client, cs.Spec.ClusterNamespace, cs.Spec.ClusterName, cs.Spec.ClusterType)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			clusterSummaryScope.SetCondition(configv1alpha1.ClusterReachableCondition, metav1.ConditionFalse,
+				configv1alpha1.ClusterNotFoundReason,
+				fmt.Sprintf("%s %s/%s not found", clusterRef.Kind, clusterRef.Namespace, clusterRef.Name))
+			return false, nil
+		}
+		return false, err
+	}
+
+	ready, err := clusterproxy.IsClusterReadyToBeConfigured(ctx, r.Client, clusterRef, logger)
+	if err != nil {
+		logger.V(logs.LogDebug).Info(fmt.Sprintf("failed to verify whether cluster is ready: %v", err))
+		return false, err
+	}
+
+	if !ready {
+		clusterSummaryScope.SetCondition(configv1alpha1.ClusterReachableCondition, metav1.ConditionFalse,
+			configv1alpha1.ClusterNotReadyReason,
+			fmt.Sprintf("%s %s/%s is not ready to be configured yet", clusterRef.Kind, clusterRef.Namespace, clusterRef.Name))
+		return false, nil
+	}
+
+	clusterSummaryScope.SetCondition(configv1alpha1.ClusterReachableCondition, metav1.ConditionTrue,
+		configv1alpha1.ClusterReadyReason, "")
+	return true, nil
+}
+
+// updateClusterSummaryConditions sets HelmConflict, ResourcesApplied, HelmApplied and Ready
+// conditions based on ClusterSummary Status.
+// Ready is true only if ClusterSummary is not paused, cluster is reachable, dependencies are
+// provisioned, there is no helm chart conflict and all features are applied.
+func updateClusterSummaryConditions(clusterSummaryScope *scope.ClusterSummaryScope) {
+	clusterSummary := clusterSummaryScope.ClusterSummary
+
+	conflicts := make([]string, 0)
+	for i := range clusterSummary.Status.HelmReleaseSummaries {
+		summary := &clusterSummary.Status.HelmReleaseSummaries[i]
+		if summary.Status == configv1alpha1.HelChartStatusConflict {
+			conflicts = append(conflicts, fmt.Sprintf("%s/%s: %s",
+				summary.ReleaseNamespace, summary.ReleaseName, summary.ConflictMessage))
+		}
+	}
+	if len(conflicts) != 0 {
+		clusterSummaryScope.SetCondition(configv1alpha1.HelmConflictCondition, metav1.ConditionTrue,
+			configv1alpha1.HelmChartConflictReason, strings.Join(conflicts, "; "))
+	} else {
+		clusterSummaryScope.SetCondition(configv1alpha1.HelmConflictCondition, metav1.ConditionFalse,
+			configv1alpha1.NoConflictReason, "")
+	}
+
+	setFeatureAppliedCondition(clusterSummaryScope, configv1alpha1.FeatureResources,
+		configv1alpha1.ResourcesAppliedCondition, len(clusterSummary.Spec.ClusterProfileSpec.PolicyRefs) != 0)
+	setFeatureAppliedCondition(clusterSummaryScope, configv1alpha1.FeatureHelm,
+		configv1alpha1.HelmAppliedCondition, len(clusterSummary.Spec.ClusterProfileSpec.HelmCharts) != 0)
+
+	conditions := clusterSummary.Status.Conditions
+	var notReady *metav1.Condition
+	switch {
+	case meta.IsStatusConditionTrue(conditions, configv1alpha1.PausedCondition):
+		notReady = meta.FindStatusCondition(conditions, configv1alpha1.PausedCondition)
+	case meta.IsStatusConditionFalse(conditions, configv1alpha1.ClusterReachableCondition):
+		notReady = meta.FindStatusCondition(conditions, configv1alpha1.ClusterReachableCondition)
+	case meta.IsStatusConditionFalse(conditions, configv1alpha1.DependenciesProvisionedCondition):
+		notReady = meta.FindStatusCondition(conditions, configv1alpha1.DependenciesProvisionedCondition)
+	case meta.IsStatusConditionTrue(conditions, configv1alpha1.HelmConflictCondition):
+		notReady = meta.FindStatusCondition(conditions, configv1alpha1.HelmConflictCondition)
+	case meta.IsStatusConditionFalse(conditions, configv1alpha1.ResourcesAppliedCondition):
+		notReady = meta.FindStatusCondition(conditions, configv1alpha1.ResourcesAppliedCondition)
+	case meta.IsStatusConditionFalse(conditions, configv1alpha1.HelmAppliedCondition):
+		notReady = meta.FindStatusCondition(conditions, configv1alpha1.HelmAppliedCondition)
+	}
+
+	if notReady != nil {
+		clusterSummaryScope.SetCondition(configv1alpha1.ReadyCondition, metav1.ConditionFalse,
+			notReady.Reason, notReady.Message)
+		return
+	}
+	clusterSummaryScope.SetCondition(configv1alpha1.ReadyCondition, metav1.ConditionTrue,
+		configv1alpha1.ProvisionedReason, "all features are provisioned")
+}
+
+// setFeatureAppliedCondition sets conditionType based on featureID status.
+// configured indicates whether ClusterSummary has anything to deploy for featureID.
+func setFeatureAppliedCondition(clusterSummaryScope *scope.ClusterSummaryScope, featureID configv1alpha1.FeatureID,
+	conditionType string, configured bool) {
+
+	var fs *configv1alpha1.FeatureSummary
+	for i := range clusterSummaryScope.ClusterSummary.Status.FeatureSummaries {
+		if clusterSummaryScope.ClusterSummary.Status.FeatureSummaries[i].FeatureID == featureID {
+			fs = &clusterSummaryScope.ClusterSummary.Status.FeatureSummaries[i]
+		}
+	}
+
+	if !configured && (fs == nil || fs.Status == configv1alpha1.FeatureStatusRemoved) {
+		clusterSummaryScope.SetCondition(conditionType, metav1.ConditionTrue,
+			configv1alpha1.NothingToApplyReason, "")
+		return
+	}
+
+	if fs == nil {
+		clusterSummaryScope.SetCondition(conditionType, metav1.ConditionFalse,
+			configv1alpha1.ProvisioningReason, "feature not deployed yet")
+		return
+	}
+
+	switch fs.Status {
+	case configv1alpha1.FeatureStatusProvisioned:
+		clusterSummaryScope.SetCondition(conditionType, metav1.ConditionTrue,
+			configv1alpha1.ProvisionedReason, "")
+	case configv1alpha1.FeatureStatusFailed:
+		message := ""
+		if fs.FailureMessage != nil {
+			message = *fs.FailureMessage
+		}
+		clusterSummaryScope.SetCondition(conditionType, metav1.ConditionFalse,
+			configv1alpha1.ProvisioningFailedReason, message)
+	case configv1alpha1.FeatureStatusWaitingForWindow:
+		message := "changes are held till next sync window opens"
+		if nextSyncTime := clusterSummaryScope.ClusterSummary.Status.NextSyncTime; nextSyncTime != nil {
+			message = fmt.Sprintf("changes are held till %s", nextSyncTime.UTC().Format(time.RFC3339))
+		}
+		clusterSummaryScope.SetCondition(conditionType, metav1.ConditionFalse,
+			configv1alpha1.WaitingForSyncWindowReason, message)
+	default:
+		clusterSummaryScope.SetCondition(conditionType, metav1.ConditionFalse,
+			configv1alpha1.ProvisioningReason, fmt.Sprintf("feature is %s", fs.Status))
+	}
+}
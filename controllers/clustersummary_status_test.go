@@ -0,0 +1,210 @@
+/*
+Copyright 2022-23. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2/klogr"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	libsveltosv1alpha1 "github.com/projectsveltos/libsveltos/api/v1alpha1"
+	fakedeployer "github.com/projectsveltos/libsveltos/lib/deployer/fake"
+	configv1alpha1 "github.com/projectsveltos/sveltos-manager/api/v1alpha1"
+	"github.com/projectsveltos/sveltos-manager/controllers"
+	"github.com/projectsveltos/sveltos-manager/pkg/scope"
+)
+
+var _ = Describe("ClusterSummary: conditions", func() {
+	var clusterProfile *configv1alpha1.ClusterProfile
+	var clusterSummary *configv1alpha1.ClusterSummary
+	var cluster *clusterv1.Cluster
+	var namespace string
+
+	BeforeEach(func() {
+		namespace = "conditions" + randomString()
+
+		cluster = &clusterv1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      randomString(),
+				Namespace: namespace,
+			},
+		}
+
+		clusterProfile = &configv1alpha1.ClusterProfile{
+			TypeMeta: metav1.TypeMeta{
+				Kind:       configv1alpha1.ClusterProfileKind,
+				APIVersion: configv1alpha1.GroupVersion.String(),
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name: clusterProfileNamePrefix + randomString(),
+				UID:  "1",
+			},
+			Spec: configv1alpha1.ClusterProfileSpec{
+				ClusterSelector: selector,
+				SyncMode:        configv1alpha1.SyncModeContinuous,
+			},
+		}
+
+		clusterSummary = &configv1alpha1.ClusterSummary{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:       controllers.GetClusterSummaryName(clusterProfile.Name, cluster.Name, false),
+				Namespace:  namespace,
+				Generation: 2,
+				OwnerReferences: []metav1.OwnerReference{
+					{
+						Kind:       clusterProfile.Kind,
+						Name:       clusterProfile.Name,
+						APIVersion: clusterProfile.APIVersion,
+						UID:        clusterProfile.UID,
+					},
+				},
+			},
+			Spec: configv1alpha1.ClusterSummarySpec{
+				ClusterNamespace:   cluster.Namespace,
+				ClusterName:        cluster.Name,
+				ClusterType:        libsveltosv1alpha1.ClusterTypeCapi,
+				ClusterProfileSpec: clusterProfile.Spec,
+			},
+		}
+	})
+
+	It("updateClusterSummaryConditions sets conditions based on ClusterSummary status", func() {
+		failureMessage := randomString()
+		clusterSummary.Spec.ClusterProfileSpec.PolicyRefs = []libsveltosv1alpha1.PolicyRef{
+			{
+				Kind:      string(libsveltosv1alpha1.ConfigMapReferencedResourceKind),
+				Namespace: namespace,
+				Name:      randomString(),
+			},
+		}
+		clusterSummary.Spec.ClusterProfileSpec.HelmCharts = []configv1alpha1.HelmChart{
+			{
+				RepositoryURL: randomString(), RepositoryName: randomString(), ChartName: randomString(),
+				ChartVersion: randomString(), ReleaseName: randomString(), ReleaseNamespace: randomString(),
+			},
+		}
+		clusterSummary.Status = configv1alpha1.ClusterSummaryStatus{
+			FeatureSummaries: []configv1alpha1.FeatureSummary{
+				{FeatureID: configv1alpha1.FeatureResources, Status: configv1alpha1.FeatureStatusProvisioned},
+				{FeatureID: configv1alpha1.FeatureHelm, Status: configv1alpha1.FeatureStatusFailed,
+					FailureMessage: &failureMessage},
+			},
+			HelmReleaseSummaries: []configv1alpha1.HelmChartSummary{
+				{ReleaseName: randomString(), ReleaseNamespace: randomString(),
+					Status: configv1alpha1.HelChartStatusConflict, ConflictMessage: randomString()},
+			},
+		}
+
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(clusterSummary).Build()
+
+		clusterSummaryScope, err := scope.NewClusterSummaryScope(scope.ClusterSummaryScopeParams{
+			Client:         c,
+			Logger:         klogr.New(),
+			ClusterSummary: clusterSummary,
+			ControllerName: "clustersummary",
+		})
+		Expect(err).To(BeNil())
+
+		controllers.UpdateClusterSummaryConditions(clusterSummaryScope)
+		conditions := clusterSummary.Status.Conditions
+		Expect(meta.IsStatusConditionTrue(conditions, configv1alpha1.HelmConflictCondition)).To(BeTrue())
+		Expect(meta.IsStatusConditionTrue(conditions, configv1alpha1.ResourcesAppliedCondition)).To(BeTrue())
+		helmApplied := meta.FindStatusCondition(conditions, configv1alpha1.HelmAppliedCondition)
+		Expect(helmApplied).ToNot(BeNil())
+		Expect(helmApplied.Status).To(Equal(metav1.ConditionFalse))
+		Expect(helmApplied.Reason).To(Equal(configv1alpha1.ProvisioningFailedReason))
+		Expect(helmApplied.Message).To(Equal(failureMessage))
+		ready := meta.FindStatusCondition(conditions, configv1alpha1.ReadyCondition)
+		Expect(ready).ToNot(BeNil())
+		Expect(ready.Status).To(Equal(metav1.ConditionFalse))
+		Expect(ready.Reason).To(Equal(configv1alpha1.HelmChartConflictReason))
+
+		By("Resolving conflict and provisioning helm charts")
+		clusterSummary.Status.HelmReleaseSummaries[0].Status = configv1alpha1.HelChartStatusManaging
+		clusterSummary.Status.FeatureSummaries[1].Status = configv1alpha1.FeatureStatusProvisioned
+		controllers.UpdateClusterSummaryConditions(clusterSummaryScope)
+		conditions = clusterSummary.Status.Conditions
+		Expect(meta.IsStatusConditionFalse(conditions, configv1alpha1.HelmConflictCondition)).To(BeTrue())
+		Expect(meta.IsStatusConditionTrue(conditions, configv1alpha1.HelmAppliedCondition)).To(BeTrue())
+		Expect(meta.IsStatusConditionTrue(conditions, configv1alpha1.ReadyCondition)).To(BeTrue())
+
+		By("Removing helm charts")
+		clusterSummary.Spec.ClusterProfileSpec.HelmCharts = nil
+		clusterSummary.Status.FeatureSummaries[1].Status = configv1alpha1.FeatureStatusRemoved
+		controllers.UpdateClusterSummaryConditions(clusterSummaryScope)
+		helmApplied = meta.FindStatusCondition(clusterSummary.Status.Conditions, configv1alpha1.HelmAppliedCondition)
+		Expect(helmApplied.Status).To(Equal(metav1.ConditionTrue))
+		Expect(helmApplied.Reason).To(Equal(configv1alpha1.NothingToApplyReason))
+	})
+
+	It("Reconcile sets Paused and ClusterReachable conditions and observedGeneration", func() {
+		cluster.Spec.Paused = true
+		controllerutil.AddFinalizer(clusterSummary, configv1alpha1.ClusterSummaryFinalizer)
+
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(clusterProfile, clusterSummary, cluster).Build()
+
+		dep := fakedeployer.GetClient(context.TODO(), klogr.New(), c)
+		reconciler := getClusterSummaryReconciler(c, dep)
+
+		reconcileAndGetConditions := func() *configv1alpha1.ClusterSummary {
+			_, err := reconciler.Reconcile(context.TODO(), ctrl.Request{
+				NamespacedName: client.ObjectKeyFromObject(clusterSummary),
+			})
+			Expect(err).To(BeNil())
+
+			currentClusterSummary := &configv1alpha1.ClusterSummary{}
+			Expect(c.Get(context.TODO(), client.ObjectKeyFromObject(clusterSummary), currentClusterSummary)).To(Succeed())
+			return currentClusterSummary
+		}
+
+		currentClusterSummary := reconcileAndGetConditions()
+		// Nothing is deployed while paused, so current generation is not observed yet
+		Expect(currentClusterSummary.Status.ObservedGeneration).To(BeZero())
+		conditions := currentClusterSummary.Status.Conditions
+		Expect(meta.IsStatusConditionTrue(conditions, configv1alpha1.PausedCondition)).To(BeTrue())
+		ready := meta.FindStatusCondition(conditions, configv1alpha1.ReadyCondition)
+		Expect(ready).ToNot(BeNil())
+		Expect(ready.Status).To(Equal(metav1.ConditionFalse))
+		Expect(ready.Reason).To(Equal(configv1alpha1.PausedReason))
+
+		By("Unpausing cluster. Cluster has no running control plane machine so it is not reachable yet")
+		Expect(c.Get(context.TODO(), client.ObjectKeyFromObject(cluster), cluster)).To(Succeed())
+		cluster.Spec.Paused = false
+		Expect(c.Update(context.TODO(), cluster)).To(Succeed())
+
+		currentClusterSummary = reconcileAndGetConditions()
+		conditions = currentClusterSummary.Status.Conditions
+		Expect(meta.IsStatusConditionFalse(conditions, configv1alpha1.PausedCondition)).To(BeTrue())
+		reachable := meta.FindStatusCondition(conditions, configv1alpha1.ClusterReachableCondition)
+		Expect(reachable).ToNot(BeNil())
+		Expect(reachable.Status).To(Equal(metav1.ConditionFalse))
+		Expect(reachable.Reason).To(Equal(configv1alpha1.ClusterNotReadyReason))
+		ready = meta.FindStatusCondition(conditions, configv1alpha1.ReadyCondition)
+		Expect(ready.Reason).To(Equal(configv1alpha1.ClusterNotReadyReason))
+		Expect(currentClusterSummary.Status.ObservedGeneration).To(BeZero())
+	})
+})
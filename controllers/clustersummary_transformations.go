@@ -27,6 +27,7 @@ import (
 
 	libsveltosv1alpha1 "github.com/projectsveltos/libsveltos/api/v1alpha1"
 	logs "github.com/projectsveltos/libsveltos/lib/logsettings"
+	configv1alpha1 "github.com/projectsveltos/sveltos-manager/api/v1alpha1"
 )
 
 func (r *ClusterSummaryReconciler) requeueClusterSummaryForReference(
@@ -62,6 +63,12 @@ func (r *ClusterSummaryReconciler) requeueClusterSummaryForReference(
 			Namespace:  o.GetNamespace(),
 			Name:       o.GetName(),
 		}
+	case *configv1alpha1.HelmRepository:
+		key = corev1.ObjectReference{
+			APIVersion: configv1alpha1.GroupVersion.String(),
+			Kind:       configv1alpha1.HelmRepositoryKind,
+			Name:       o.GetName(),
+		}
 	default:
 		key = corev1.ObjectReference{
 			APIVersion: o.GetObjectKind().GroupVersionKind().GroupVersion().String(),
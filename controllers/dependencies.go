@@ -0,0 +1,121 @@
+/*
+Copyright 2022-23. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	logs "github.com/projectsveltos/libsveltos/lib/logsettings"
+	configv1alpha1 "github.com/projectsveltos/sveltos-manager/api/v1alpha1"
+)
+
+// A ClusterProfile can depend on other ClusterProfiles (ClusterProfile.Spec.DependsOn).
+// In a given cluster, a ClusterSummary does not deploy any feature till the ClusterSummaries
+// of all ClusterProfiles it depends on are provisioned in that same cluster.
+// Dependency cycles would cause all involved ClusterSummaries to wait forever. So ClusterProfileReconciler
+// detects those and refuses to propagate to ClusterSummaries a ClusterProfile Spec introducing a cycle.
+
+// getDependencyCycle returns, if any, a dependency cycle reachable from clusterProfile.
+// Cycle is returned as the ordered list of ClusterProfile names, with first and last element being the same.
+// Returns nil if no cycle is found.
+func getDependencyCycle(ctx context.Context, c client.Client,
+	clusterProfile *configv1alpha1.ClusterProfile) ([]string, error) {
+
+	if len(clusterProfile.Spec.DependsOn) == 0 {
+		return nil, nil
+	}
+
+	clusterProfiles := &configv1alpha1.ClusterProfileList{}
+	if err := c.List(ctx, clusterProfiles); err != nil {
+		return nil, err
+	}
+
+	// key: ClusterProfile name; value: names of the ClusterProfiles it depends on
+	dependencies := make(map[string][]string)
+	for i := range clusterProfiles.Items {
+		dependencies[clusterProfiles.Items[i].Name] = clusterProfiles.Items[i].Spec.DependsOn
+	}
+	// Always consider the version of ClusterProfile currently being reconciled
+	dependencies[clusterProfile.Name] = clusterProfile.Spec.DependsOn
+
+	visited := make(map[string]bool)
+	inPath := make(map[string]bool)
+	path := make([]string, 0)
+
+	var visit func(name string) []string
+	visit = func(name string) []string {
+		if inPath[name] {
+			for i := range path {
+				if path[i] == name {
+					cycle := make([]string, 0, len(path)-i+1)
+					cycle = append(cycle, path[i:]...)
+					return append(cycle, name)
+				}
+			}
+		}
+		if visited[name] {
+			return nil
+		}
+
+		inPath[name] = true
+		path = append(path, name)
+		for i := range dependencies[name] {
+			if cycle := visit(dependencies[name][i]); cycle != nil {
+				return cycle
+			}
+		}
+		path = path[:len(path)-1]
+		inPath[name] = false
+		visited[name] = true
+		return nil
+	}
+
+	return visit(clusterProfile.Name), nil
+}
+
+// areDependenciesProvisioned returns true if, in the cluster clusterSummary is for, all features of the
+// ClusterProfiles clusterSummary depends on are provisioned.
+func areDependenciesProvisioned(ctx context.Context, c client.Client,
+	clusterSummary *configv1alpha1.ClusterSummary, logger logr.Logger) (bool, error) {
+
+	for i := range clusterSummary.Spec.ClusterProfileSpec.DependsOn {
+		clusterProfileName := clusterSummary.Spec.ClusterProfileSpec.DependsOn[i]
+		dependency, err := getClusterSummary(ctx, c, clusterProfileName,
+			clusterSummary.Spec.ClusterNamespace, clusterSummary.Spec.ClusterName)
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				logger.V(logs.LogDebug).Info(fmt.Sprintf("ClusterProfile %s is not deployed in cluster yet",
+					clusterProfileName))
+				return false, nil
+			}
+			return false, err
+		}
+
+		if !dependency.DeletionTimestamp.IsZero() || !isClusterSummaryProvisioned(dependency) {
+			logger.V(logs.LogDebug).Info(fmt.Sprintf("ClusterProfile %s is not provisioned in cluster yet",
+				clusterProfileName))
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
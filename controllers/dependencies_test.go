@@ -0,0 +1,270 @@
+/*
+Copyright 2022-23. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2/klogr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	libsveltosv1alpha1 "github.com/projectsveltos/libsveltos/api/v1alpha1"
+	fakedeployer "github.com/projectsveltos/libsveltos/lib/deployer/fake"
+	configv1alpha1 "github.com/projectsveltos/sveltos-manager/api/v1alpha1"
+	"github.com/projectsveltos/sveltos-manager/controllers"
+)
+
+var _ = Describe("ClusterProfile dependencies", func() {
+	var namespace string
+
+	BeforeEach(func() {
+		namespace = "dependencies" + randomString()
+	})
+
+	getClusterProfile := func(name string, dependsOn ...string) *configv1alpha1.ClusterProfile {
+		return &configv1alpha1.ClusterProfile{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: name,
+			},
+			Spec: configv1alpha1.ClusterProfileSpec{
+				ClusterSelector: selector,
+				DependsOn:       dependsOn,
+			},
+		}
+	}
+
+	It("getDependencyCycle returns nil when there is no cycle", func() {
+		cni := getClusterProfile("cni-" + randomString())
+		certManager := getClusterProfile("cert-manager-"+randomString(), cni.Name)
+		apps := getClusterProfile("apps-"+randomString(), cni.Name, certManager.Name)
+
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(cni, certManager, apps).Build()
+
+		cycle, err := controllers.GetDependencyCycle(context.TODO(), c, apps)
+		Expect(err).To(BeNil())
+		Expect(cycle).To(BeNil())
+
+		By("Depending on a non existing ClusterProfile is not a cycle")
+		apps.Spec.DependsOn = append(apps.Spec.DependsOn, randomString())
+		cycle, err = controllers.GetDependencyCycle(context.TODO(), c, apps)
+		Expect(err).To(BeNil())
+		Expect(cycle).To(BeNil())
+	})
+
+	It("getDependencyCycle returns the dependency cycle", func() {
+		cni := getClusterProfile("cni-" + randomString())
+		certManager := getClusterProfile("cert-manager-"+randomString(), cni.Name)
+		apps := getClusterProfile("apps-"+randomString(), certManager.Name)
+
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(cni, certManager, apps).Build()
+
+		// Current version of cni (not yet persisted) introduces a cycle
+		cni.Spec.DependsOn = []string{apps.Name}
+		cycle, err := controllers.GetDependencyCycle(context.TODO(), c, cni)
+		Expect(err).To(BeNil())
+		Expect(cycle).To(Equal([]string{cni.Name, apps.Name, certManager.Name, cni.Name}))
+
+		By("A ClusterProfile depending on itself is a cycle")
+		cni.Spec.DependsOn = []string{cni.Name}
+		cycle, err = controllers.GetDependencyCycle(context.TODO(), c, cni)
+		Expect(err).To(BeNil())
+		Expect(cycle).To(Equal([]string{cni.Name, cni.Name}))
+	})
+
+	It("areDependenciesProvisioned returns true only when ClusterSummaries of dependencies are provisioned", func() {
+		clusterName := randomString()
+		cni := getClusterProfile("cni-" + randomString())
+		apps := getClusterProfile("apps-"+randomString(), cni.Name)
+
+		clusterSummary := &configv1alpha1.ClusterSummary{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      controllers.GetClusterSummaryName(apps.Name, clusterName, false),
+				Namespace: namespace,
+			},
+			Spec: configv1alpha1.ClusterSummarySpec{
+				ClusterNamespace:   namespace,
+				ClusterName:        clusterName,
+				ClusterType:        libsveltosv1alpha1.ClusterTypeCapi,
+				ClusterProfileSpec: apps.Spec,
+			},
+		}
+		addLabelsToClusterSummary(clusterSummary, apps.Name, namespace, clusterName)
+
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(cni, apps, clusterSummary).Build()
+
+		By("ClusterSummary for dependency does not exist yet")
+		provisioned, err := controllers.AreDependenciesProvisioned(context.TODO(), c, clusterSummary, klogr.New())
+		Expect(err).To(BeNil())
+		Expect(provisioned).To(BeFalse())
+
+		cniClusterSummary := &configv1alpha1.ClusterSummary{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      controllers.GetClusterSummaryName(cni.Name, clusterName, false),
+				Namespace: namespace,
+			},
+			Spec: configv1alpha1.ClusterSummarySpec{
+				ClusterNamespace: namespace,
+				ClusterName:      clusterName,
+				ClusterType:      libsveltosv1alpha1.ClusterTypeCapi,
+				ClusterProfileSpec: configv1alpha1.ClusterProfileSpec{
+					HelmCharts: []configv1alpha1.HelmChart{
+						{ReleaseName: randomString(), ReleaseNamespace: randomString()},
+					},
+				},
+			},
+			Status: configv1alpha1.ClusterSummaryStatus{
+				FeatureSummaries: []configv1alpha1.FeatureSummary{
+					{FeatureID: configv1alpha1.FeatureHelm, Status: configv1alpha1.FeatureStatusProvisioning},
+				},
+			},
+		}
+		addLabelsToClusterSummary(cniClusterSummary, cni.Name, namespace, clusterName)
+		Expect(c.Create(context.TODO(), cniClusterSummary)).To(Succeed())
+
+		By("ClusterSummary for dependency is still provisioning")
+		provisioned, err = controllers.AreDependenciesProvisioned(context.TODO(), c, clusterSummary, klogr.New())
+		Expect(err).To(BeNil())
+		Expect(provisioned).To(BeFalse())
+
+		By("ClusterSummary for dependency is provisioned")
+		cniClusterSummary.Status.FeatureSummaries[0].Status = configv1alpha1.FeatureStatusProvisioned
+		Expect(c.Status().Update(context.TODO(), cniClusterSummary)).To(Succeed())
+
+		provisioned, err = controllers.AreDependenciesProvisioned(context.TODO(), c, clusterSummary, klogr.New())
+		Expect(err).To(BeNil())
+		Expect(provisioned).To(BeTrue())
+	})
+
+	It("Reconcile sets DependenciesProvisioned condition while waiting for dependencies", func() {
+		cni := getClusterProfile("cni-" + randomString())
+		apps := getClusterProfile("apps-"+randomString(), cni.Name)
+		apps.UID = types.UID(randomString())
+		apps.Spec.SyncMode = configv1alpha1.SyncModeContinuous
+
+		sveltosCluster := &libsveltosv1alpha1.SveltosCluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      randomString(),
+				Namespace: namespace,
+			},
+			Status: libsveltosv1alpha1.SveltosClusterStatus{
+				Ready: true,
+			},
+		}
+
+		clusterSummary := &configv1alpha1.ClusterSummary{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:       controllers.GetClusterSummaryName(apps.Name, sveltosCluster.Name, true),
+				Namespace:  namespace,
+				Generation: 1,
+				Finalizers: []string{configv1alpha1.ClusterSummaryFinalizer},
+				OwnerReferences: []metav1.OwnerReference{
+					{
+						Kind:       configv1alpha1.ClusterProfileKind,
+						Name:       apps.Name,
+						APIVersion: configv1alpha1.GroupVersion.String(),
+						UID:        apps.UID,
+					},
+				},
+			},
+			Spec: configv1alpha1.ClusterSummarySpec{
+				ClusterNamespace:   namespace,
+				ClusterName:        sveltosCluster.Name,
+				ClusterType:        libsveltosv1alpha1.ClusterTypeSveltos,
+				ClusterProfileSpec: apps.Spec,
+			},
+		}
+		addLabelsToClusterSummary(clusterSummary, apps.Name, namespace, sveltosCluster.Name)
+
+		initObjects := []client.Object{cni, apps, sveltosCluster, clusterSummary}
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(initObjects...).Build()
+
+		dep := fakedeployer.GetClient(context.TODO(), klogr.New(), c)
+		reconciler := getClusterSummaryReconciler(c, dep)
+
+		_, err := reconciler.Reconcile(context.TODO(), ctrl.Request{
+			NamespacedName: client.ObjectKeyFromObject(clusterSummary),
+		})
+		Expect(err).To(BeNil())
+
+		currentClusterSummary := &configv1alpha1.ClusterSummary{}
+		Expect(c.Get(context.TODO(), client.ObjectKeyFromObject(clusterSummary), currentClusterSummary)).To(Succeed())
+		// Nothing is deployed while waiting for dependencies, so current generation is not observed yet
+		Expect(currentClusterSummary.Status.ObservedGeneration).To(BeZero())
+		conditions := currentClusterSummary.Status.Conditions
+		dependencies := meta.FindStatusCondition(conditions, configv1alpha1.DependenciesProvisionedCondition)
+		Expect(dependencies).ToNot(BeNil())
+		Expect(dependencies.Status).To(Equal(metav1.ConditionFalse))
+		Expect(dependencies.Reason).To(Equal(configv1alpha1.WaitingForDependenciesReason))
+		Expect(dependencies.Message).To(ContainSubstring(cni.Name))
+		ready := meta.FindStatusCondition(conditions, configv1alpha1.ReadyCondition)
+		Expect(ready).ToNot(BeNil())
+		Expect(ready.Status).To(Equal(metav1.ConditionFalse))
+		Expect(ready.Reason).To(Equal(configv1alpha1.WaitingForDependenciesReason))
+
+		By("Provisioning dependency")
+		cniClusterSummary := &configv1alpha1.ClusterSummary{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      controllers.GetClusterSummaryName(cni.Name, sveltosCluster.Name, true),
+				Namespace: namespace,
+			},
+			Spec: configv1alpha1.ClusterSummarySpec{
+				ClusterNamespace: namespace,
+				ClusterName:      sveltosCluster.Name,
+				ClusterType:      libsveltosv1alpha1.ClusterTypeSveltos,
+			},
+		}
+		addLabelsToClusterSummary(cniClusterSummary, cni.Name, namespace, sveltosCluster.Name)
+		Expect(c.Create(context.TODO(), cniClusterSummary)).To(Succeed())
+
+		_, err = reconciler.Reconcile(context.TODO(), ctrl.Request{
+			NamespacedName: client.ObjectKeyFromObject(clusterSummary),
+		})
+		Expect(err).To(BeNil())
+
+		Expect(c.Get(context.TODO(), client.ObjectKeyFromObject(clusterSummary), currentClusterSummary)).To(Succeed())
+		Expect(meta.IsStatusConditionTrue(currentClusterSummary.Status.Conditions,
+			configv1alpha1.DependenciesProvisionedCondition)).To(BeTrue())
+	})
+
+	It("Reconciling a ClusterProfile introducing a dependency cycle reports it in status", func() {
+		first := getClusterProfile("first-" + randomString())
+		second := getClusterProfile("second-"+randomString(), first.Name)
+		first.Spec.DependsOn = []string{second.Name}
+
+		initObjects := []client.Object{first, second}
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(initObjects...).Build()
+
+		reconciler := getClusterProfileReconciler(c)
+		_, err := reconciler.Reconcile(context.TODO(), ctrl.Request{
+			NamespacedName: client.ObjectKey{Name: first.Name},
+		})
+		Expect(err).To(BeNil())
+
+		currentClusterProfile := &configv1alpha1.ClusterProfile{}
+		Expect(c.Get(context.TODO(), client.ObjectKey{Name: first.Name}, currentClusterProfile)).To(Succeed())
+		Expect(currentClusterProfile.Status.FailureMessage).ToNot(BeNil())
+		Expect(*currentClusterProfile.Status.FailureMessage).To(ContainSubstring("dependency cycle detected"))
+	})
+})
@@ -0,0 +1,83 @@
+/*
+Copyright 2022-23. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	configv1alpha1 "github.com/projectsveltos/sveltos-manager/api/v1alpha1"
+)
+
+const (
+	eventReasonDeployQueued      = "DeployQueued"
+	eventReasonDeployCompleted   = "DeployCompleted"
+	eventReasonWithdrawQueued    = "WithdrawQueued"
+	eventReasonWithdrawCompleted = "WithdrawCompleted"
+	eventReasonFeatureFailed     = "FeatureFailed"
+	eventReasonHelmInstall       = "HelmInstall"
+	eventReasonHelmUpgrade       = "HelmUpgrade"
+	eventReasonHelmUninstall     = "HelmUninstall"
+	eventReasonHelmChartConflict = "HelmChartConflict"
+	eventReasonHelmRemediation   = "HelmRemediation"
+	eventReasonHelmAdoption      = "HelmAdoption"
+	eventReasonResourceConflict  = "ResourceConflict"
+	eventReasonDriftDetected     = "DriftDetected"
+)
+
+var (
+	eventRecorder record.EventRecorder
+)
+
+// SetEventRecorder sets the recorder used to emit Events on ClusterSummaries and
+// on their owning ClusterProfiles.
+func SetEventRecorder(recorder record.EventRecorder) {
+	eventRecorder = recorder
+}
+
+// recordEvent emits an Event on ClusterSummary and on the ClusterProfile owning it.
+// DryRun does not change anything in the cluster so no Event is emitted in DryRun mode.
+func recordEvent(clusterSummary *configv1alpha1.ClusterSummary, eventType, reason, messageFmt string,
+	args ...interface{}) {
+
+	if eventRecorder == nil {
+		return
+	}
+
+	if clusterSummary.Spec.ClusterProfileSpec.SyncMode == configv1alpha1.SyncModeDryRun {
+		return
+	}
+
+	message := fmt.Sprintf(messageFmt, args...)
+	eventRecorder.Event(clusterSummary, eventType, reason, message)
+
+	// ClusterProfile is built from the OwnerReference. No need to fetch it.
+	for i := range clusterSummary.OwnerReferences {
+		ref := &clusterSummary.OwnerReferences[i]
+		if ref.Kind != configv1alpha1.ClusterProfileKind {
+			continue
+		}
+		clusterProfile := &configv1alpha1.ClusterProfile{
+			TypeMeta:   metav1.TypeMeta{Kind: ref.Kind, APIVersion: ref.APIVersion},
+			ObjectMeta: metav1.ObjectMeta{Name: ref.Name, UID: ref.UID},
+		}
+		eventRecorder.Event(clusterProfile, eventType, reason,
+			fmt.Sprintf("cluster %s/%s: %s", clusterSummary.Spec.ClusterNamespace, clusterSummary.Spec.ClusterName, message))
+	}
+}
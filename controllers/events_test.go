@@ -0,0 +1,176 @@
+/*
+Copyright 2022-23. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers_test
+
+import (
+	"context"
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2/klogr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	libsveltosv1alpha1 "github.com/projectsveltos/libsveltos/api/v1alpha1"
+	configv1alpha1 "github.com/projectsveltos/sveltos-manager/api/v1alpha1"
+	"github.com/projectsveltos/sveltos-manager/controllers"
+	"github.com/projectsveltos/sveltos-manager/controllers/chartmanager"
+)
+
+var _ = Describe("Events", func() {
+	var clusterProfile *configv1alpha1.ClusterProfile
+	var clusterSummary *configv1alpha1.ClusterSummary
+	var recorder *record.FakeRecorder
+
+	BeforeEach(func() {
+		recorder = record.NewFakeRecorder(10)
+		controllers.SetEventRecorder(recorder)
+
+		clusterProfile = &configv1alpha1.ClusterProfile{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: clusterProfileNamePrefix + randomString(),
+			},
+			Spec: configv1alpha1.ClusterProfileSpec{
+				ClusterSelector: selector,
+			},
+		}
+
+		clusterSummary = &configv1alpha1.ClusterSummary{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      randomString(),
+				Namespace: "events" + randomString(),
+				OwnerReferences: []metav1.OwnerReference{
+					{
+						Kind:       configv1alpha1.ClusterProfileKind,
+						Name:       clusterProfile.Name,
+						APIVersion: configv1alpha1.GroupVersion.String(),
+					},
+				},
+			},
+			Spec: configv1alpha1.ClusterSummarySpec{
+				ClusterNamespace: randomString(),
+				ClusterName:      randomString(),
+				ClusterType:      libsveltosv1alpha1.ClusterTypeCapi,
+			},
+		}
+	})
+
+	AfterEach(func() {
+		controllers.SetEventRecorder(nil)
+	})
+
+	It("updateFeatureStatus emits events on ClusterSummary and ClusterProfile when status changes", func() {
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(clusterProfile, clusterSummary).Build()
+
+		reconciler := getClusterSummaryReconciler(c, nil)
+		clusterSummaryScope := getClusterSummaryScope(c, klogr.New(), clusterProfile, clusterSummary)
+
+		status := configv1alpha1.FeatureStatusFailed
+		statusErr := fmt.Errorf("failed to deploy")
+		controllers.UpdateFeatureStatus(reconciler, clusterSummaryScope, configv1alpha1.FeatureResources, &status,
+			nil, statusErr, klogr.New())
+		// One event for ClusterSummary and one for ClusterProfile
+		Expect(recorder.Events).To(HaveLen(2))
+		Expect(<-recorder.Events).To(ContainSubstring("Warning FeatureFailed"))
+		Expect(<-recorder.Events).To(ContainSubstring(clusterSummary.Spec.ClusterName))
+
+		By("Same failure does not emit any new event")
+		controllers.UpdateFeatureStatus(reconciler, clusterSummaryScope, configv1alpha1.FeatureResources, &status,
+			nil, statusErr, klogr.New())
+		Expect(recorder.Events).To(BeEmpty())
+
+		status = configv1alpha1.FeatureStatusProvisioned
+		controllers.UpdateFeatureStatus(reconciler, clusterSummaryScope, configv1alpha1.FeatureResources, &status,
+			nil, nil, klogr.New())
+		Expect(recorder.Events).To(HaveLen(2))
+		Expect(<-recorder.Events).To(ContainSubstring("Normal DeployCompleted"))
+		<-recorder.Events
+
+		controllers.UpdateFeatureStatus(reconciler, clusterSummaryScope, configv1alpha1.FeatureResources, &status,
+			nil, nil, klogr.New())
+		Expect(recorder.Events).To(BeEmpty())
+
+		By("No event is emitted in DryRun mode")
+		clusterSummary.Spec.ClusterProfileSpec.SyncMode = configv1alpha1.SyncModeDryRun
+		status = configv1alpha1.FeatureStatusFailed
+		controllers.UpdateFeatureStatus(reconciler, clusterSummaryScope, configv1alpha1.FeatureResources, &status,
+			nil, statusErr, klogr.New())
+		Expect(recorder.Events).To(BeEmpty())
+	})
+
+	It("updateStatusForReferencedHelmReleases emits HelmChartConflict event only when conflict first appears", func() {
+		helmChart := configv1alpha1.HelmChart{
+			RepositoryURL: randomString(), RepositoryName: randomString(), ChartName: randomString(),
+			ChartVersion: randomString(), ReleaseName: randomString(), ReleaseNamespace: randomString(),
+		}
+		clusterSummary.Spec.ClusterProfileSpec.HelmCharts = []configv1alpha1.HelmChart{helmChart}
+
+		// Another ClusterSummary is already managing the helm release
+		otherClusterSummary := clusterSummary.DeepCopy()
+		otherClusterSummary.Name = randomString()
+
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(clusterSummary).Build()
+
+		manager, err := chartmanager.GetChartManagerInstance(context.TODO(), c)
+		Expect(err).To(BeNil())
+		manager.RegisterClusterSummaryForCharts(otherClusterSummary)
+		defer manager.RemoveAllRegistrations(otherClusterSummary)
+
+		conflict, err := controllers.UpdateStatusForReferencedHelmReleases(context.TODO(), c, clusterSummary)
+		Expect(err).To(BeNil())
+		Expect(conflict).To(BeTrue())
+		Expect(recorder.Events).To(HaveLen(2))
+		Expect(<-recorder.Events).To(ContainSubstring("Warning HelmChartConflict"))
+		<-recorder.Events
+
+		By("Same conflict does not emit any new event")
+		conflict, err = controllers.UpdateStatusForReferencedHelmReleases(context.TODO(), c, clusterSummary)
+		Expect(err).To(BeNil())
+		Expect(conflict).To(BeTrue())
+		Expect(recorder.Events).To(BeEmpty())
+	})
+
+	It("processResourceSummary emits DriftDetected event", func() {
+		clusterSummary.Status.FeatureSummaries = []configv1alpha1.FeatureSummary{
+			{FeatureID: configv1alpha1.FeatureResources, Status: configv1alpha1.FeatureStatusProvisioned,
+				Hash: []byte(randomString())},
+		}
+
+		resourceSummary := getResourceSummary(nil, nil)
+		resourceSummary.Labels = map[string]string{
+			libsveltosv1alpha1.ClusterSummaryLabelName:      clusterSummary.Name,
+			libsveltosv1alpha1.ClusterSummaryLabelNamespace: clusterSummary.Namespace,
+		}
+		resourceSummary.Status.ResourcesChanged = true
+
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(clusterSummary).Build()
+		remoteClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(resourceSummary).Build()
+
+		Expect(controllers.ProcessResourceSummary(context.TODO(), c, remoteClient, resourceSummary,
+			klogr.New())).To(Succeed())
+		Expect(recorder.Events).To(HaveLen(2))
+		Expect(<-recorder.Events).To(ContainSubstring("Normal DriftDetected"))
+
+		currentClusterSummary := &configv1alpha1.ClusterSummary{}
+		Expect(c.Get(context.TODO(), client.ObjectKeyFromObject(clusterSummary), currentClusterSummary)).To(Succeed())
+		Expect(currentClusterSummary.Status.FeatureSummaries[0].Status).To(Equal(configv1alpha1.FeatureStatusProvisioning))
+	})
+})
@@ -27,9 +27,25 @@ var (
 	CleanClusterReports          = (*ClusterProfileReconciler).cleanClusterReports
 	UpdateClusterReports         = (*ClusterProfileReconciler).updateClusterReports
 	UpdateClusterSummarySyncMode = (*ClusterProfileReconciler).updateClusterSummarySyncMode
+	RolloutClusterSummaries      = (*ClusterProfileReconciler).rolloutClusterSummaries
+	UpdateClusterProfileMaps     = (*ClusterProfileReconciler).updateMaps
+	UpdateClusterProfileStatus   = (*ClusterProfileReconciler).updateClusterProfileStatus
+	IsCELSelectorUsed            = (*ClusterProfileReconciler).isCELSelectorUsed
 
-	RequeueClusterProfileForCluster = (*ClusterProfileReconciler).requeueClusterProfileForCluster
-	RequeueClusterProfileForMachine = (*ClusterProfileReconciler).requeueClusterProfileForMachine
+	RequeueClusterProfileForCluster        = (*ClusterProfileReconciler).requeueClusterProfileForCluster
+	RequeueClusterProfileForMachine        = (*ClusterProfileReconciler).requeueClusterProfileForMachine
+	RequeueClusterProfileForClusterSummary = (*ClusterProfileReconciler).requeueClusterProfileForClusterSummary
+
+	IsRolloutCompleted          = isRolloutCompleted
+	GetRolloutMaxUpdate         = getRolloutMaxUpdate
+	GetRolloutFailureBudget     = getRolloutFailureBudget
+	IsClusterSummaryProvisioned = isClusterSummaryProvisioned
+
+	GetDependencyCycle         = getDependencyCycle
+	AreDependenciesProvisioned = areDependenciesProvisioned
+
+	GetSyncWindowState = getSyncWindowState
+	IsInSyncWindow     = isInSyncWindow
 )
 
 var (
@@ -45,6 +61,10 @@ var (
 	UpdateChartMap                 = (*ClusterSummaryReconciler).updateChartMap
 	ShouldRedeploy                 = (*ClusterSummaryReconciler).shouldRedeploy
 	CanRemoveFinalizer             = (*ClusterSummaryReconciler).canRemoveFinalizer
+	HoldFeatures                   = (*ClusterSummaryReconciler).holdFeatures
+	IsClusterReachable             = (*ClusterSummaryReconciler).isClusterReachable
+
+	UpdateClusterSummaryConditions = updateClusterSummaryConditions
 
 	ConvertResultStatus               = (*ClusterSummaryReconciler).convertResultStatus
 	RequeueClusterSummaryForReference = (*ClusterSummaryReconciler).requeueClusterSummaryForReference
@@ -88,14 +108,84 @@ var (
 	CreateReportForUnmanagedHelmRelease      = createReportForUnmanagedHelmRelease
 	UpdateClusterReportWithHelmReports       = updateClusterReportWithHelmReports
 	HandleCharts                             = handleCharts
+	ApplyInstallOptions                      = applyInstallOptions
+	ApplyUpgradeOptions                      = applyUpgradeOptions
+	ApplyUninstallOptions                    = applyUninstallOptions
+	GetStorageDriver                         = getStorageDriver
+	IsWaitRequested                          = isWaitRequested
+	NeedsRemediation                         = needsRemediation
+	GetRollbackRevision                      = getRollbackRevision
+	UpdateRemediationStatus                  = updateRemediationStatus
+	ResetRemediationAttempts                 = resetRemediationAttempts
+
+	IsOCIChart                    = isOCIChart
+	GetChartName                  = getChartName
+	GetOCIChartRef                = getOCIChartRef
+	GetRegistryHost               = getRegistryHost
+	GetRegistryClient             = getRegistryClient
+	CreateRegistryCredentialsFile = createRegistryCredentialsFile
+	GetRegistryCredentials        = getRegistryCredentials
+
+	ResolveHelmChart   = resolveHelmChart
+	GetRepositoryEntry = getRepositoryEntry
+	GetHelmReferences  = getHelmReferences
 
 	InstantiateTemplateValues = instantiateTemplateValues
+
+	NewRESTClientGetter     = newRESTClientGetter
+	GetRESTClientGetter     = getRESTClientGetter
+	RemoveRESTClientGetters = removeRESTClientGetters
+	WithNamespace           = (*restClientGetter).withNamespace
+	GetSettings             = getSettings
+
+	NewChartCache     = newChartCache
+	GetChartCacheKey  = getChartCacheKey
+	GetRepositoryLock = (*chartCache).getRepositoryLock
+	GetChartLock      = (*chartCache).getChartLock
+	IsIndexUpToDate   = (*chartCache).isIndexUpToDate
+	IsEntryAdded      = (*chartCache).isEntryAdded
+	IndexDownloaded   = (*chartCache).indexDownloaded
+	GetChartFromCache = (*chartCache).getChart
+	AddChartToCache   = (*chartCache).addChart
+	LocateChart       = (*chartCache).locateChart
+
+	ResolveChartDependencies = resolveChartDependencies
+
+	MergeValues     = mergeValues
+	FetchValuesFrom = fetchValuesFrom
+
+	ValidateValuesAgainstSchema = validateValuesAgainstSchema
+
+	GetPostRenderer          = getPostRenderer
+	HavePostRenderersChanged = havePostRenderersChanged
+	UpdatePostRenderersHash  = updatePostRenderersHash
+
+	IsChartVersionConstraint     = isChartVersionConstraint
+	GetLatestMatchingVersion     = getLatestMatchingVersion
+	ResolveChartVersion          = resolveChartVersion
+	RefreshResolvedChartVersions = refreshResolvedChartVersions
+
+	GetReleaseResourceChanges = getReleaseResourceChanges
+	GetUnifiedDiff            = getUnifiedDiff
+
+	MarkChartAsManaged   = markChartAsManaged
+	IsReleaseManagedBy   = isReleaseManagedBy
+	GetAdoptionRefusal   = getAdoptionRefusal
+	ResetAdoptionPending = resetAdoptionPending
+
+	GetKeyring         = getKeyring
+	ApplyVerifyOptions = applyVerifyOptions
 )
 
 type (
 	ReleaseInfo = releaseInfo
 )
 
+const (
+	MaxReleaseDiffLength = maxReleaseDiffLength
+	DiffOmittedMessage   = diffOmittedMessage
+)
+
 var (
 	GetClusterReportName        = getClusterReportName
 	GetClusterConfigurationName = getClusterConfigurationName
@@ -118,4 +208,5 @@ var (
 
 var (
 	CollectResourceSummariesFromCluster = collectResourceSummariesFromCluster
+	ProcessResourceSummary              = processResourceSummary
 )
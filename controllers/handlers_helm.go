@@ -23,12 +23,12 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strconv"
 	"strings"
-	"sync"
 	"time"
 
-	"github.com/Masterminds/semver"
+	"github.com/Masterminds/semver/v3"
 	"github.com/gdexlab/go-render/render"
 	"github.com/go-logr/logr"
 	"github.com/gofrs/flock"
@@ -38,12 +38,12 @@ import (
 	"helm.sh/helm/v3/pkg/chart/loader"
 	"helm.sh/helm/v3/pkg/chartutil"
 	"helm.sh/helm/v3/pkg/cli"
-	"helm.sh/helm/v3/pkg/downloader"
 	"helm.sh/helm/v3/pkg/getter"
-	"helm.sh/helm/v3/pkg/kube"
+	"helm.sh/helm/v3/pkg/registry"
 	"helm.sh/helm/v3/pkg/release"
 	"helm.sh/helm/v3/pkg/repo"
 	"helm.sh/helm/v3/pkg/storage/driver"
+	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
@@ -51,7 +51,6 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	libsveltosv1alpha1 "github.com/projectsveltos/libsveltos/api/v1alpha1"
-	"github.com/projectsveltos/libsveltos/lib/clusterproxy"
 	"github.com/projectsveltos/libsveltos/lib/deployer"
 	logs "github.com/projectsveltos/libsveltos/lib/logsettings"
 	"github.com/projectsveltos/libsveltos/lib/utils"
@@ -61,17 +60,26 @@ import (
 )
 
 var (
-	settings          = cli.New()
 	defaultUploadPath = "/tmp/charts"
 	chartExtension    = "tgz"
-	repoLock          sync.Mutex
-	repoAdded         map[string]bool
 )
 
 const (
-	writeFilePermission = 0644
-	lockTimeout         = 30
-	notInstalledMessage = "Not installed yet and action is uninstall"
+	writeFilePermission  = 0644
+	lockTimeout          = 30
+	notInstalledMessage  = "Not installed yet and action is uninstall"
+	valuesChangedMessage = "Helm values changed"
+	// postRenderersChangedMessage is reported when only post renderers changed
+	postRenderersChangedMessage = "Helm post renderers changed"
+	// staleReleaseNotManagedMessage is reported when an helm release not referenced anymore
+	// is left untouched, as it is not marked as managed by the ClusterSummary
+	staleReleaseNotManagedMessage = "Not referenced anymore. Not uninstalled as not managed by this ClusterProfile"
+	// defaultHelmTimeout is the time to wait for any individual Kubernetes operation
+	// when not specified in HelmChart options. Same default used by Helm CLI
+	defaultHelmTimeout = 5 * time.Minute
+	// defaultRemediationRetries is the maximum number of remediation attempts
+	// when not specified in HelmChart remediation
+	defaultRemediationRetries = 3
 )
 
 type releaseInfo struct {
@@ -83,6 +91,13 @@ type releaseInfo struct {
 	Chart            string      `json:"chart"`
 	ChartVersion     string      `json:"chart_version"`
 	AppVersion       string      `json:"app_version"`
+	// Values are the values the release was installed/upgraded with
+	Values map[string]interface{} `json:"values,omitempty"`
+	// Manifest is the manifest of the release (hooks excluded)
+	Manifest string `json:"manifest,omitempty"`
+	// ManagedBy is the ClusterSummary (namespace/name) the release is marked as managed by.
+	// Empty if release was not installed by Sveltos.
+	ManagedBy string `json:"managedBy,omitempty"`
 }
 
 func deployHelmCharts(ctx context.Context, c client.Client,
@@ -109,20 +124,13 @@ func deployHelmCharts(ctx context.Context, c client.Client,
 	logger = logger.WithValues("clusterSummary", clusterSummary.Name)
 	logger = logger.WithValues("admin", getClusterSummaryAdmin(clusterSummary))
 
-	kubeconfigContent, err := getSecretData(ctx, c, clusterNamespace, clusterName, getClusterSummaryAdmin(clusterSummary),
-		clusterSummary.Spec.ClusterType, logger)
+	clientGetter, err := getRESTClientGetter(ctx, c, clusterNamespace, clusterName,
+		getClusterSummaryAdmin(clusterSummary), clusterSummary.Spec.ClusterType, logger)
 	if err != nil {
 		return err
 	}
 
-	var kubeconfig string
-	kubeconfig, err = clusterproxy.CreateKubeconfig(logger, kubeconfigContent)
-	if err != nil {
-		return err
-	}
-	defer os.Remove(kubeconfig)
-
-	err = handleCharts(ctx, clusterSummary, c, remoteClient, kubeconfig, logger)
+	err = handleCharts(ctx, clusterSummary, c, remoteClient, clientGetter, logger)
 	if err != nil {
 		return err
 	}
@@ -130,7 +138,7 @@ func deployHelmCharts(ctx context.Context, c client.Client,
 	if clusterSummary.Spec.ClusterProfileSpec.SyncMode == configv1alpha1.SyncModeContinuousWithDriftDetection {
 		// Deploy resourceSummary
 		err = deployResourceSummaryWithHelmResources(ctx, c, clusterNamespace, clusterName,
-			clusterType, clusterSummary, kubeconfig, logger)
+			clusterType, clusterSummary, clientGetter, logger)
 		if err != nil {
 			return err
 		}
@@ -158,21 +166,14 @@ func undeployHelmCharts(ctx context.Context, c client.Client,
 	logger = logger.WithValues("clusterSummary", clusterSummary.Name)
 	logger = logger.WithValues("admin", getClusterSummaryAdmin(clusterSummary))
 
-	kubeconfigContent, err := getSecretData(ctx, c, clusterNamespace, clusterName, getClusterSummaryAdmin(clusterSummary),
-		clusterSummary.Spec.ClusterType, logger)
-	if err != nil {
-		return err
-	}
-
-	var kubeconfig string
-	kubeconfig, err = clusterproxy.CreateKubeconfig(logger, kubeconfigContent)
+	clientGetter, err := getRESTClientGetter(ctx, c, clusterNamespace, clusterName,
+		getClusterSummaryAdmin(clusterSummary), clusterSummary.Spec.ClusterType, logger)
 	if err != nil {
 		return err
 	}
-	defer os.Remove(kubeconfig)
 
 	var releaseReports []configv1alpha1.ReleaseReport
-	releaseReports, err = uninstallHelmCharts(ctx, c, clusterSummary, kubeconfig, logger)
+	releaseReports, err = uninstallHelmCharts(ctx, c, clusterSummary, clientGetter, logger)
 	if err != nil {
 		return err
 	}
@@ -181,7 +182,7 @@ func undeployHelmCharts(ctx context.Context, c client.Client,
 	// not referenced anymore. Only if this operation succeeds, removes all stale
 	// helm release registration for this clusterSummary.
 	var undeployedReports []configv1alpha1.ReleaseReport
-	undeployedReports, err = undeployStaleReleases(ctx, c, clusterSummary, kubeconfig, logger)
+	undeployedReports, err = undeployStaleReleases(ctx, c, clusterSummary, clientGetter, logger)
 	if err != nil {
 		return err
 	}
@@ -218,7 +219,7 @@ func undeployHelmCharts(ctx context.Context, c client.Client,
 }
 
 func uninstallHelmCharts(ctx context.Context, c client.Client, clusterSummary *configv1alpha1.ClusterSummary,
-	kubeconfig string, logger logr.Logger) ([]configv1alpha1.ReleaseReport, error) {
+	clientGetter *restClientGetter, logger logr.Logger) ([]configv1alpha1.ReleaseReport, error) {
 
 	chartManager, err := chartmanager.GetChartManagerInstance(ctx, c)
 	if err != nil {
@@ -234,6 +235,8 @@ func uninstallHelmCharts(ctx context.Context, c client.Client, clusterSummary *c
 				currentChart.RepositoryURL,
 				currentChart.RepositoryName))
 
+			action := configv1alpha1.UninstallHelmAction
+			var message string
 			// If another ClusterSummary is queued to manage this chart in this cluster, do not uninstall.
 			// Let the other ClusterSummary take it over.
 			if chartManager.GetNumberOfRegisteredClusterSummaries(clusterSummary.Spec.ClusterNamespace,
@@ -247,18 +250,20 @@ func uninstallHelmCharts(ctx context.Context, c client.Client, clusterSummary *c
 
 					logger.V(logs.LogInfo).Info("ClusterProfile StopMatchingBehavior set to LeavePolicies")
 				} else {
-					err = doUninstallRelease(clusterSummary, currentChart, kubeconfig, logger)
+					// An helm release this ClusterSummary cannot adopt is never uninstalled
+					message, err = uninstallAdoptableRelease(clusterSummary, currentChart, clientGetter, logger)
 					if err != nil {
-						if !errors.Is(err, driver.ErrReleaseNotFound) {
-							return nil, err
-						}
+						return nil, err
+					}
+					if message != "" {
+						action = configv1alpha1.NoHelmAction
 					}
 				}
 			}
 
 			releaseReports = append(releaseReports, configv1alpha1.ReleaseReport{
 				ReleaseNamespace: currentChart.ReleaseNamespace, ReleaseName: currentChart.ReleaseName,
-				Action: string(configv1alpha1.UninstallHelmAction),
+				Action: string(action), Message: message,
 			})
 		} else {
 			releaseReports = append(releaseReports, configv1alpha1.ReleaseReport{
@@ -285,6 +290,38 @@ func helmHash(ctx context.Context, c client.Client, clusterSummaryScope *scope.C
 		currentChart := &clusterSummary.Spec.ClusterProfileSpec.HelmCharts[i]
 
 		config += render.AsCode(*currentChart)
+
+		// Consider repository settings and credentials (so that, for instance, rotated
+		// credentials or a HelmRepository pointing to a different URL are used)
+		repositoryConfig, err := getRepositoryConfig(ctx, c, currentChart)
+		if err != nil {
+			return nil, err
+		}
+		config += repositoryConfig
+
+		// Consider content of ConfigMaps/Secrets containing values
+		valuesFromConfig, err := getValuesFromConfig(ctx, c, clusterSummary.Spec.ClusterNamespace, currentChart)
+		if err != nil {
+			return nil, err
+		}
+		config += valuesFromConfig
+
+		// Consider keyring used to verify chart provenance (so that, for instance, with a
+		// rotated key chart provenance is verified again)
+		keyringConfig, err := getKeyringConfig(ctx, c, currentChart)
+		if err != nil {
+			return nil, err
+		}
+		config += keyringConfig
+
+		// When ChartVersion is a constraint, consider the version it was last resolved to (see
+		// refreshResolvedChartVersions), so that a newly published matching version is deployed
+		if isChartVersionConstraint(currentChart.ChartVersion) {
+			summary := getHelmChartSummary(clusterSummary, currentChart.ReleaseNamespace, currentChart.ReleaseName)
+			if summary != nil {
+				config += summary.ResolvedChartVersion
+			}
+		}
 	}
 
 	h.Write([]byte(config))
@@ -296,7 +333,7 @@ func getHelmRefs(clusterSummary *configv1alpha1.ClusterSummary) []libsveltosv1al
 }
 
 func handleCharts(ctx context.Context, clusterSummary *configv1alpha1.ClusterSummary,
-	c, remoteClient client.Client, kubeconfig string, logger logr.Logger) error {
+	c, remoteClient client.Client, clientGetter *restClientGetter, logger logr.Logger) error {
 
 	chartManager, err := chartmanager.GetChartManagerInstance(ctx, c)
 	if err != nil {
@@ -316,6 +353,9 @@ func handleCharts(ctx context.Context, clusterSummary *configv1alpha1.ClusterSum
 
 	releaseReports := make([]configv1alpha1.ReleaseReport, 0)
 	chartDeployed := make([]configv1alpha1.Chart, 0)
+	// validationErr is set if values of any helm chart do not match chart schema or
+	// if an existing helm release could not be adopted
+	var validationErr error
 	for i := range clusterSummary.Spec.ClusterProfileSpec.HelmCharts {
 		currentChart := &clusterSummary.Spec.ClusterProfileSpec.HelmCharts[i]
 		if !chartManager.CanManageChart(clusterSummary, currentChart) {
@@ -330,11 +370,40 @@ func handleCharts(ctx context.Context, clusterSummary *configv1alpha1.ClusterSum
 			continue
 		}
 
+		// When referencing a HelmRepository, repository URL and credentials come from it
+		currentChart, err = resolveHelmChart(ctx, c, currentChart)
+		if err != nil {
+			return err
+		}
+
+		// When ChartVersion is a constraint, the latest matching version is deployed
+		var resolvedVersion string
+		if isChartVersionConstraint(currentChart.ChartVersion) {
+			currentChart, err = resolveChartVersion(ctx, currentChart, logger)
+			if err != nil {
+				return err
+			}
+			resolvedVersion = currentChart.ChartVersion
+		}
+		err = updateResolvedChartVersion(ctx, c, clusterSummary, currentChart, resolvedVersion)
+		if err != nil {
+			return err
+		}
+
 		var report *configv1alpha1.ReleaseReport
 		var currentRelease *releaseInfo
-		currentRelease, report, err = handleChart(ctx, clusterSummary, currentChart, remoteClient, kubeconfig, logger)
+		currentRelease, report, err = handleChart(ctx, c, clusterSummary, currentChart, remoteClient, clientGetter, logger)
 		if err != nil {
-			return err
+			var schemaErr *valuesSchemaError
+			var adoptionErr *adoptionRefusedError
+			if report == nil || (!errors.As(err, &schemaErr) && !errors.As(err, &adoptionErr)) {
+				return err
+			}
+			// Values not matching chart schema and helm releases which cannot be adopted are reported.
+			// Remaining helm charts are still deployed.
+			releaseReports = append(releaseReports, *report)
+			validationErr = err
+			continue
 		}
 		releaseReports = append(releaseReports, *report)
 
@@ -365,7 +434,7 @@ func handleCharts(ctx context.Context, clusterSummary *configv1alpha1.ClusterSum
 	// not referenced anymore. Only if this operation succeeds, removes all stale
 	// helm release registration for this clusterSummary.
 	var undeployedReports []configv1alpha1.ReleaseReport
-	undeployedReports, err = undeployStaleReleases(ctx, c, clusterSummary, kubeconfig, logger)
+	undeployedReports, err = undeployStaleReleases(ctx, c, clusterSummary, clientGetter, logger)
 	if err != nil {
 		return err
 	}
@@ -393,16 +462,17 @@ func handleCharts(ctx context.Context, clusterSummary *configv1alpha1.ClusterSum
 	if clusterSummary.Spec.ClusterProfileSpec.SyncMode == configv1alpha1.SyncModeDryRun {
 		return &configv1alpha1.DryRunReconciliationError{}
 	}
-	return nil
+	return validationErr
 }
 
 func handleInstall(ctx context.Context, clusterSummary *configv1alpha1.ClusterSummary, currentChart *configv1alpha1.HelmChart,
-	remoteClient client.Client, kubeconfig string, logger logr.Logger) (*configv1alpha1.ReleaseReport, error) {
+	currentRelease *releaseInfo, values chartutil.Values, postRenderer *kustomizePostRenderer, remoteClient client.Client,
+	clientGetter *restClientGetter, logger logr.Logger) (*configv1alpha1.ReleaseReport, error) {
 
 	var report *configv1alpha1.ReleaseReport
 	logger.V(logs.LogDebug).Info("install helm release")
-	err := doInstallRelease(ctx, clusterSummary, remoteClient, currentChart,
-		kubeconfig, logger)
+	err := doInstallRelease(ctx, clusterSummary, remoteClient, currentChart, values, postRenderer,
+		clientGetter, logger)
 	if err != nil {
 		return nil, err
 	}
@@ -410,21 +480,25 @@ func handleInstall(ctx context.Context, clusterSummary *configv1alpha1.ClusterSu
 		ReleaseNamespace: currentChart.ReleaseNamespace, ReleaseName: currentChart.ReleaseName,
 		ChartVersion: currentChart.ChartVersion, Action: string(configv1alpha1.InstallHelmAction),
 	}
+	// In DryRun mode, report which resources would be deployed
+	if clusterSummary.Spec.ClusterProfileSpec.SyncMode == configv1alpha1.SyncModeDryRun {
+		report.ResourceChanges, report.Message = getDryRunResourceChanges(ctx, currentChart, currentRelease,
+			values, postRenderer, clientGetter, logger)
+	}
 	return report, nil
 }
 
 func handleUpgrade(ctx context.Context, clusterSummary *configv1alpha1.ClusterSummary, currentChart *configv1alpha1.HelmChart,
-	currentRelease *releaseInfo, remoteClient client.Client, kubeconfig string,
-	logger logr.Logger) (*configv1alpha1.ReleaseReport, error) {
+	currentRelease *releaseInfo, values chartutil.Values, postRenderer *kustomizePostRenderer, adopt bool,
+	remoteClient client.Client, clientGetter *restClientGetter, logger logr.Logger) (*configv1alpha1.ReleaseReport, error) {
 
 	var report *configv1alpha1.ReleaseReport
 	logger.V(logs.LogDebug).Info("upgrade helm release")
-	err := doUpgradeRelease(ctx, clusterSummary, remoteClient, currentChart,
-		kubeconfig, logger)
+	err := doUpgradeRelease(ctx, clusterSummary, remoteClient, currentChart, values, postRenderer,
+		clientGetter, logger)
 	if err != nil {
 		return nil, err
 	}
-	var message string
 	current, err := semver.NewVersion(currentRelease.ChartVersion)
 	if err != nil {
 		logger.V(logs.LogInfo).Info(fmt.Sprintf("failed to get semantic version. Err: %v", err))
@@ -435,26 +509,46 @@ func handleUpgrade(ctx context.Context, clusterSummary *configv1alpha1.ClusterSu
 		logger.V(logs.LogInfo).Info(fmt.Sprintf("failed to get semantic version. Err: %v", err))
 		return nil, err
 	}
+	messages := make([]string, 0)
+	if adopt {
+		messages = append(messages, releaseAdoptedMessage)
+	}
 	if current.Compare(expected) != 0 {
-		message = fmt.Sprintf("Current version: %q. Would move to version: %q",
-			currentRelease.ChartVersion, currentChart.ChartVersion)
-	} else {
-		message = fmt.Sprintf("No op, already at version: %s", currentRelease.ChartVersion)
+		messages = append(messages, fmt.Sprintf("Current version: %q. Would move to version: %q",
+			currentRelease.ChartVersion, currentChart.ChartVersion))
+	}
+	if haveValuesChanged(currentRelease, values) {
+		messages = append(messages, valuesChangedMessage)
+	}
+	if havePostRenderersChanged(clusterSummary, currentRelease, currentChart, postRenderer) {
+		messages = append(messages, postRenderersChangedMessage)
+	}
+	if len(messages) == 0 {
+		messages = append(messages, fmt.Sprintf("No op, already at version: %s", currentRelease.ChartVersion))
 	}
 	report = &configv1alpha1.ReleaseReport{
 		ReleaseNamespace: currentChart.ReleaseNamespace, ReleaseName: currentChart.ReleaseName,
 		ChartVersion: currentChart.ChartVersion, Action: string(configv1alpha1.UpgradeHelmAction),
-		Message: message,
 	}
+	// In DryRun mode, report which resources would change
+	if clusterSummary.Spec.ClusterProfileSpec.SyncMode == configv1alpha1.SyncModeDryRun {
+		var failureMessage string
+		report.ResourceChanges, failureMessage = getDryRunResourceChanges(ctx, currentChart, currentRelease,
+			values, postRenderer, clientGetter, logger)
+		if failureMessage != "" {
+			messages = append(messages, failureMessage)
+		}
+	}
+	report.Message = strings.Join(messages, ". ")
 	return report, nil
 }
 
 func handleUninstall(clusterSummary *configv1alpha1.ClusterSummary, currentChart *configv1alpha1.HelmChart,
-	kubeconfig string, logger logr.Logger) (*configv1alpha1.ReleaseReport, error) {
+	clientGetter *restClientGetter, logger logr.Logger) (*configv1alpha1.ReleaseReport, error) {
 
 	var report *configv1alpha1.ReleaseReport
 	logger.V(logs.LogDebug).Info("uniinstall helm release")
-	err := doUninstallRelease(clusterSummary, currentChart, kubeconfig, logger)
+	err := doUninstallRelease(clusterSummary, currentChart, clientGetter, logger)
 	if err != nil {
 		return nil, err
 	}
@@ -465,11 +559,12 @@ func handleUninstall(clusterSummary *configv1alpha1.ClusterSummary, currentChart
 	return report, nil
 }
 
-func handleChart(ctx context.Context, clusterSummary *configv1alpha1.ClusterSummary, currentChart *configv1alpha1.HelmChart,
-	remoteClient client.Client, kubeconfig string, logger logr.Logger) (*releaseInfo, *configv1alpha1.ReleaseReport, error) {
+func handleChart(ctx context.Context, c client.Client, clusterSummary *configv1alpha1.ClusterSummary,
+	currentChart *configv1alpha1.HelmChart, remoteClient client.Client, clientGetter *restClientGetter, logger logr.Logger,
+) (*releaseInfo, *configv1alpha1.ReleaseReport, error) {
 
 	currentRelease, err := getReleaseInfo(currentChart.ReleaseName,
-		currentChart.ReleaseNamespace, kubeconfig, logger)
+		currentChart.ReleaseNamespace, clientGetter, currentChart.Options, logger)
 	if err != nil && !errors.Is(err, driver.ErrReleaseNotFound) {
 		return nil, nil, err
 	}
@@ -477,22 +572,110 @@ func handleChart(ctx context.Context, clusterSummary *configv1alpha1.ClusterSumm
 
 	logger = logger.WithValues("releaseNamespace", currentChart.ReleaseNamespace, "releaseName", currentChart.ReleaseName)
 
+	// An helm release existing in the cluster but not installed by this ClusterSummary (for instance
+	// installed manually) is taken over only if AdoptionPolicy allows it. Otherwise it is left untouched.
+	var adopt bool
+	if currentRelease != nil && !isReleaseManagedBy(clusterSummary, currentRelease) {
+		if refusal := getAdoptionRefusal(currentRelease, currentChart); refusal != "" {
+			logger.V(logs.LogInfo).Info(refusal)
+			recordEvent(clusterSummary, corev1.EventTypeWarning, eventReasonHelmAdoption, "%s", refusal)
+			report = &configv1alpha1.ReleaseReport{
+				ReleaseNamespace: currentChart.ReleaseNamespace, ReleaseName: currentChart.ReleaseName,
+				ChartVersion: currentChart.ChartVersion, Action: string(configv1alpha1.NoHelmAction),
+				ChartRef: getOCIChartRef(currentChart), Message: refusal,
+			}
+			return nil, report, &adoptionRefusedError{message: refusal}
+		}
+		adopt = true
+	}
+	err = resetAdoptionPending(ctx, c, clusterSummary, currentChart)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// An helm release left in a failed or pending state is recovered, if so requested,
+	// before deciding which action to take
+	var remediation string
+	currentRelease, remediation, err = remediateRelease(ctx, c, clusterSummary, currentChart, currentRelease,
+		clientGetter, logger)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Values are instantiated before deciding which action to take, as a values only change
+	// requires an upgrade
+	var values chartutil.Values
+	var postRenderer *kustomizePostRenderer
+	if currentChart.HelmChartAction != configv1alpha1.HelmChartActionUninstall {
+		values, err = getInstantiatedValues(ctx, clusterSummary, currentChart, logger)
+		if err != nil {
+			return nil, nil, err
+		}
+		postRenderer, err = getPostRenderer(ctx, clusterSummary, currentChart, logger)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// A post renderers only change requires an upgrade as well. An adopted helm release is
+	// upgraded so that it is marked as managed by this ClusterSummary.
+	upgrade := shouldUpgrade(currentRelease, currentChart, values, clusterSummary) ||
+		havePostRenderersChanged(clusterSummary, currentRelease, currentChart, postRenderer) ||
+		(adopt && currentChart.HelmChartAction != configv1alpha1.HelmChartActionUninstall)
+
+	// Before installing/upgrading, values are validated against chart schema. On failure,
+	// the report explaining why release was not deployed is returned along with the error.
+	// Failing to get the chart is not a validation failure: same error is hit (and reported)
+	// installing/upgrading.
+	if shouldInstall(currentRelease, currentChart) || upgrade {
+		err = validateValues(ctx, currentChart, values, logger)
+		var schemaErr *valuesSchemaError
+		if errors.As(err, &schemaErr) {
+			report = &configv1alpha1.ReleaseReport{
+				ReleaseNamespace: currentChart.ReleaseNamespace, ReleaseName: currentChart.ReleaseName,
+				ChartVersion: currentChart.ChartVersion, Action: string(configv1alpha1.NoHelmAction),
+				ChartRef: getOCIChartRef(currentChart), Message: schemaErr.Error(), Remediation: remediation,
+			}
+			return nil, report, err
+		} else if err != nil {
+			logger.V(logs.LogInfo).Info(fmt.Sprintf("failed to validate values against chart schema: %v", err))
+		}
+	}
+
 	if shouldInstall(currentRelease, currentChart) {
-		report, err = handleInstall(ctx, clusterSummary, currentChart, remoteClient, kubeconfig, logger)
+		report, err = handleInstall(ctx, clusterSummary, currentChart, currentRelease, values, postRenderer,
+			remoteClient, clientGetter, logger)
+		if err != nil {
+			return nil, nil, err
+		}
+		err = updatePostRenderersHash(ctx, c, clusterSummary, currentChart, postRenderer)
 		if err != nil {
 			return nil, nil, err
 		}
-	} else if shouldUpgrade(currentRelease, currentChart, clusterSummary) {
-		report, err = handleUpgrade(ctx, clusterSummary, currentChart, currentRelease, remoteClient, kubeconfig, logger)
+		recordEvent(clusterSummary, corev1.EventTypeNormal, eventReasonHelmInstall,
+			"helm release %s/%s (version %s) installed",
+			currentChart.ReleaseNamespace, currentChart.ReleaseName, currentChart.ChartVersion)
+	} else if upgrade {
+		report, err = handleUpgrade(ctx, clusterSummary, currentChart, currentRelease, values, postRenderer,
+			adopt, remoteClient, clientGetter, logger)
 		if err != nil {
 			return nil, nil, err
 		}
+		err = updatePostRenderersHash(ctx, c, clusterSummary, currentChart, postRenderer)
+		if err != nil {
+			return nil, nil, err
+		}
+		recordEvent(clusterSummary, corev1.EventTypeNormal, eventReasonHelmUpgrade,
+			"helm release %s/%s upgraded (version %s)",
+			currentChart.ReleaseNamespace, currentChart.ReleaseName, currentChart.ChartVersion)
 	} else if shouldUninstall(currentRelease, currentChart) {
-		report, err = handleUninstall(clusterSummary, currentChart, kubeconfig, logger)
+		report, err = handleUninstall(clusterSummary, currentChart, clientGetter, logger)
 		if err != nil {
 			return nil, nil, err
 		}
 		report.ChartVersion = currentRelease.ChartVersion
+		recordEvent(clusterSummary, corev1.EventTypeNormal, eventReasonHelmUninstall,
+			"helm release %s/%s uninstalled", currentChart.ReleaseNamespace, currentChart.ReleaseName)
 	} else if currentRelease == nil {
 		logger.V(logs.LogDebug).Info("no action for helm release")
 		report = &configv1alpha1.ReleaseReport{
@@ -508,39 +691,64 @@ func handleChart(ctx context.Context, clusterSummary *configv1alpha1.ClusterSumm
 		}
 		report.Message = "Already managing this helm release and specified version already installed"
 	}
+	report.Remediation = remediation
+	if adopt {
+		report.Adopted = true
+		recordEvent(clusterSummary, corev1.EventTypeNormal, eventReasonHelmAdoption,
+			"helm release %s/%s adopted", currentChart.ReleaseNamespace, currentChart.ReleaseName)
+	}
+	if report.Action != string(configv1alpha1.UninstallHelmAction) {
+		report.ChartRef = getOCIChartRef(currentChart)
+	}
 
 	currentRelease, err = getReleaseInfo(currentChart.ReleaseName,
-		currentChart.ReleaseNamespace, kubeconfig, logger)
+		currentChart.ReleaseNamespace, clientGetter, currentChart.Options, logger)
 	if err != nil && !errors.Is(err, driver.ErrReleaseNotFound) {
 		return nil, nil, err
 	}
 
-	return currentRelease, report, nil
-}
+	// When asked to wait, helm release is reported as deployed only once all resources
+	// are ready. Until then, do not consider the feature provisioned.
+	if clusterSummary.Spec.ClusterProfileSpec.SyncMode != configv1alpha1.SyncModeDryRun &&
+		isWaitRequested(currentChart, report) && !isReleaseDeployed(currentRelease) {
 
-// RepoAdd adds repo with given name and url
-func repoAdd(settings *cli.EnvSettings, name, url string, logger logr.Logger) error {
-	logger = logger.WithValues("repo", url)
+		return nil, nil, fmt.Errorf("helm release %s/%s is not ready yet",
+			currentChart.ReleaseNamespace, currentChart.ReleaseName)
+	}
+
+	if isReleaseDeployed(currentRelease) {
+		// Helm release is successfully deployed. Reset remediation attempts.
+		err = resetRemediationAttempts(ctx, c, clusterSummary, currentChart)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
 
-	settings.Debug = true
-	repoInfo := fmt.Sprintf("%s:%s", url, name)
+	return currentRelease, report, nil
+}
 
-	repoLock.Lock()
-	defer repoLock.Unlock()
+// repoAdd adds repo described by entry and downloads its index.
+// Caller must hold the repository lock (see chartCache).
+func repoAdd(settings *cli.EnvSettings, entry *repo.Entry, logger logr.Logger) error {
+	logger = logger.WithValues("repo", entry.URL)
+	logger.V(logs.LogDebug).Info("adding repo")
 
-	if repoAdded == nil {
-		repoAdded = make(map[string]bool)
+	r, err := repo.NewChartRepository(entry, getter.All(settings))
+	if err != nil {
+		return err
 	}
 
-	if _, ok := repoAdded[repoInfo]; ok {
-		logger.V(logs.LogDebug).Info("repo already added")
-		return nil
+	_, err = r.DownloadIndexFile()
+	if err != nil {
+		return err
 	}
 
-	logger.V(logs.LogDebug).Info("adding repo")
+	// Repository config file is shared by all repositories
+	repositoryFileMux.Lock()
+	defer repositoryFileMux.Unlock()
 
 	// Ensure the file directory exists as it is required for file locking
-	err := os.MkdirAll(filepath.Dir(settings.RepositoryConfig), os.ModePerm)
+	err = os.MkdirAll(filepath.Dir(settings.RepositoryConfig), os.ModePerm)
 	if err != nil && !os.IsExist(err) {
 		return err
 	}
@@ -554,10 +762,9 @@ func repoAdd(settings *cli.EnvSettings, name, url string, logger logr.Logger) er
 		return err
 	}
 	if locked {
-		err = safeCloser(fileLock, logger)
-		if err != nil {
-			return err
-		}
+		defer func() {
+			_ = safeCloser(fileLock, logger)
+		}()
 	}
 
 	b, err := os.ReadFile(settings.RepositoryConfig)
@@ -571,18 +778,7 @@ func repoAdd(settings *cli.EnvSettings, name, url string, logger logr.Logger) er
 		return err
 	}
 
-	cfg := &repo.Entry{Name: name, URL: url}
-	r, err := repo.NewChartRepository(cfg, getter.All(settings))
-	if err != nil {
-		return err
-	}
-
-	_, err = r.DownloadIndexFile()
-	if err != nil {
-		return err
-	}
-
-	f.Update(cfg)
+	f.Update(entry)
 
 	err = f.WriteFile(settings.RepositoryConfig, writeFilePermission)
 	if err != nil {
@@ -590,19 +786,17 @@ func repoAdd(settings *cli.EnvSettings, name, url string, logger logr.Logger) er
 	}
 
 	logger.V(logs.LogDebug).Info("adding repo done")
-	repoAdded[repoInfo] = true
 
 	return nil
 }
 
-// repoUpdate updates repo
-func repoUpdate(settings *cli.EnvSettings, name, url string, logger logr.Logger) error {
-	logger = logger.WithValues("repo", url)
+// repoUpdate downloads repo index.
+// Caller must hold the repository lock (see chartCache).
+func repoUpdate(settings *cli.EnvSettings, entry *repo.Entry, logger logr.Logger) error {
+	logger = logger.WithValues("repo", entry.URL)
 	logger.V(logs.LogDebug).Info("updating repo")
 
-	settings.Debug = true
-	cfg := &repo.Entry{Name: name, URL: url}
-	r, err := repo.NewChartRepository(cfg, getter.All(settings))
+	r, err := repo.NewChartRepository(entry, getter.All(settings))
 	if err != nil {
 		return err
 	}
@@ -615,11 +809,40 @@ func repoUpdate(settings *cli.EnvSettings, name, url string, logger logr.Logger)
 	return nil
 }
 
+// prepareRepository makes sure the repository helm chart is stored in is added and its
+// index is up to date
+func prepareRepository(ctx context.Context, settings *cli.EnvSettings, requestedChart *configv1alpha1.HelmChart,
+	logger logr.Logger) error {
+
+	// Charts stored in OCI registries are pulled directly, there is no repository index
+	if isOCIChart(requestedChart) {
+		return nil
+	}
+
+	entry, err := getRepositoryEntry(ctx, getManagementClusterClient(), settings, requestedChart)
+	if err != nil {
+		return err
+	}
+
+	return helmChartCache.ensureRepository(settings, entry, logger)
+}
+
+// getUploadedChartName returns, for charts uploaded as archives (*.tgz), the path of the archive.
+// Chart name, unchanged, otherwise.
+func getUploadedChartName(chartName string) string {
+	splitChart := strings.Split(chartName, ".")
+	if splitChart[len(splitChart)-1] == chartExtension && !strings.Contains(chartName, ":") {
+		return defaultUploadPath + "/" + chartName
+	}
+	return chartName
+}
+
 // installRelease installs helm release in the CAPI cluster.
 // No action in DryRun mode.
 func installRelease(clusterSummary *configv1alpha1.ClusterSummary,
-	settings *cli.EnvSettings, releaseName, releaseNamespace, chartName, chartVersion, kubeconfig string,
-	values map[string]interface{}, logger logr.Logger) error {
+	settings *cli.EnvSettings, releaseName, releaseNamespace, repositoryURL, chartName, chartVersion string,
+	clientGetter *restClientGetter, values map[string]interface{}, postRenderer *kustomizePostRenderer, keyring string,
+	options *configv1alpha1.HelmOptions, registryClient *registry.Client, logger logr.Logger) error {
 
 	// No-op in DryRun mode
 	if clusterSummary.Spec.ClusterProfileSpec.SyncMode == configv1alpha1.SyncModeDryRun {
@@ -630,29 +853,32 @@ func installRelease(clusterSummary *configv1alpha1.ClusterSummary,
 		chartName, "chartVersion", chartVersion)
 	logger.V(logs.LogDebug).Info("installing release")
 
-	settings.Debug = true
 	if chartName == "" {
 		return fmt.Errorf("chart name can not be empty")
 	}
 
 	// install with local uploaded charts, *.tgz
-	splitChart := strings.Split(chartName, ".")
-	if splitChart[len(splitChart)-1] == chartExtension && !strings.Contains(chartName, ":") {
-		chartName = defaultUploadPath + "/" + chartName
-	}
+	chartName = getUploadedChartName(chartName)
 
-	actionConfig, err := actionConfigInit(releaseNamespace, kubeconfig, logger)
+	actionConfig, err := actionConfigInit(releaseNamespace, clientGetter, getStorageDriver(options), logger)
 	if err != nil {
 		logger.V(logs.LogDebug).Info("actionConfigInit failed")
 		return err
 	}
+	// Registry client is needed to pull charts from OCI registries
+	actionConfig.RegistryClient = registryClient
 
 	installObject := action.NewInstall(actionConfig)
 	installObject.ReleaseName = releaseName
 	installObject.Namespace = releaseNamespace
 	installObject.Version = chartVersion
+	applyInstallOptions(installObject, options)
+	applyVerifyOptions(&installObject.ChartPathOptions, keyring)
+	if postRenderer != nil {
+		installObject.PostRenderer = postRenderer
+	}
 
-	cp, err := installObject.ChartPathOptions.LocateChart(chartName, settings)
+	cp, err := helmChartCache.locateChart(&installObject.ChartPathOptions, repositoryURL, chartName, settings, logger)
 	if err != nil {
 		logger.V(logs.LogDebug).Info("LocateChart failed")
 		return err
@@ -669,30 +895,12 @@ func installRelease(clusterSummary *configv1alpha1.ClusterSummary,
 		return fmt.Errorf("chart is not installable")
 	}
 
-	if req := chartRequested.Metadata.Dependencies; req != nil {
-		// If CheckDependencies returns an error, we have unfulfilled dependencies.
-		// As of Helm 2.4.0, this is treated as a stopping condition:
-		// https://github.com/helm/helm/issues/2209
-		err = action.CheckDependencies(chartRequested, req)
-		if err != nil {
-			if installObject.DependencyUpdate {
-				man := &downloader.Manager{
-					ChartPath:        cp,
-					Keyring:          installObject.ChartPathOptions.Keyring,
-					SkipUpdate:       false,
-					Getters:          getter.All(settings),
-					RepositoryConfig: settings.RepositoryConfig,
-					RepositoryCache:  settings.RepositoryCache,
-				}
-				err = man.Update()
-				if err != nil {
-					return err
-				}
-			} else {
-				return nil
-			}
-		}
+	chartRequested, err = resolveChartDependencies(chartRequested, cp, installObject.ChartPathOptions.Keyring,
+		settings, registryClient, logger)
+	if err != nil {
+		return err
 	}
+	markChartAsManaged(chartRequested, clusterSummary)
 
 	_, err = installObject.Run(chartRequested, values)
 	if err != nil {
@@ -706,7 +914,8 @@ func installRelease(clusterSummary *configv1alpha1.ClusterSummary,
 // uninstallRelease removes helm release from a CAPI Cluster.
 // No action in DryRun mode.
 func uninstallRelease(clusterSummary *configv1alpha1.ClusterSummary,
-	releaseName, releaseNamespace, kubeconfig string, logger logr.Logger) error {
+	releaseName, releaseNamespace string, clientGetter *restClientGetter, options *configv1alpha1.HelmOptions,
+	logger logr.Logger) error {
 
 	// No-op in DryRun mode
 	if clusterSummary.Spec.ClusterProfileSpec.SyncMode == configv1alpha1.SyncModeDryRun {
@@ -716,13 +925,13 @@ func uninstallRelease(clusterSummary *configv1alpha1.ClusterSummary,
 	logger = logger.WithValues("release", releaseName, "releaseNamespace", releaseNamespace)
 	logger.V(logs.LogDebug).Info("uninstalling release")
 
-	settings.Debug = true
-	actionConfig, err := actionConfigInit(releaseNamespace, kubeconfig, logger)
+	actionConfig, err := actionConfigInit(releaseNamespace, clientGetter, getStorageDriver(options), logger)
 	if err != nil {
 		return err
 	}
 
 	uninstallObject := action.NewUninstall(actionConfig)
+	applyUninstallOptions(uninstallObject, options)
 	_, err = uninstallObject.Run(releaseName)
 	if err != nil {
 		return err
@@ -735,8 +944,9 @@ func uninstallRelease(clusterSummary *configv1alpha1.ClusterSummary,
 // upgradeRelease upgrades helm release in CAPI cluster.
 // No action in DryRun mode.
 func upgradeRelease(clusterSummary *configv1alpha1.ClusterSummary, settings *cli.EnvSettings,
-	releaseName, releaseNamespace, chartName, chartVersion, kubeconfig string,
-	values map[string]interface{}, logger logr.Logger) error {
+	releaseName, releaseNamespace, repositoryURL, chartName, chartVersion string, clientGetter *restClientGetter,
+	values map[string]interface{}, postRenderer *kustomizePostRenderer, keyring string, options *configv1alpha1.HelmOptions,
+	maxHistory int, registryClient *registry.Client, logger logr.Logger) error {
 
 	// No-op in DryRun mode
 	if clusterSummary.Spec.ClusterProfileSpec.SyncMode == configv1alpha1.SyncModeDryRun {
@@ -747,28 +957,34 @@ func upgradeRelease(clusterSummary *configv1alpha1.ClusterSummary, settings *cli
 		chartName, "chartVersion", chartVersion)
 	logger.V(logs.LogDebug).Info("upgrading release")
 
-	settings.Debug = true
 	if chartName == "" {
 		return fmt.Errorf("chart name can not be empty")
 	}
 
-	// upgrade with local uploaded charts *.tgz
-	splitChart := strings.Split(chartName, ".")
-	if splitChart[len(splitChart)-1] == chartExtension {
-		chartName = defaultUploadPath + "/" + chartName
-	}
+	// upgrade with local uploaded charts, *.tgz
+	chartName = getUploadedChartName(chartName)
 
-	actionConfig, err := actionConfigInit(releaseNamespace, kubeconfig, logger)
+	actionConfig, err := actionConfigInit(releaseNamespace, clientGetter, getStorageDriver(options), logger)
 	if err != nil {
 		return err
 	}
+	// Registry client is needed to pull charts from OCI registries
+	actionConfig.RegistryClient = registryClient
 
 	upgradeObject := action.NewUpgrade(actionConfig)
 	upgradeObject.Install = true
+	// Values are always fully specified. Never reuse values of the current release.
+	upgradeObject.ResetValues = true
 	upgradeObject.Namespace = releaseNamespace
 	upgradeObject.Version = chartVersion
+	applyUpgradeOptions(upgradeObject, options)
+	upgradeObject.MaxHistory = maxHistory
+	applyVerifyOptions(&upgradeObject.ChartPathOptions, keyring)
+	if postRenderer != nil {
+		upgradeObject.PostRenderer = postRenderer
+	}
 
-	cp, err := upgradeObject.ChartPathOptions.LocateChart(chartName, settings)
+	cp, err := helmChartCache.locateChart(&upgradeObject.ChartPathOptions, repositoryURL, chartName, settings, logger)
 	if err != nil {
 		return err
 	}
@@ -777,19 +993,19 @@ func upgradeRelease(clusterSummary *configv1alpha1.ClusterSummary, settings *cli
 	if err != nil {
 		return err
 	}
-	if req := chartRequested.Metadata.Dependencies; req != nil {
-		err = action.CheckDependencies(chartRequested, req)
-		if err != nil {
-			return err
-		}
+	chartRequested, err = resolveChartDependencies(chartRequested, cp, upgradeObject.ChartPathOptions.Keyring,
+		settings, registryClient, logger)
+	if err != nil {
+		return err
 	}
+	markChartAsManaged(chartRequested, clusterSummary)
 
 	hisClient := action.NewHistory(actionConfig)
 	hisClient.Max = 1
 	_, err = hisClient.Run(releaseName)
 	if errors.Is(err, driver.ErrReleaseNotFound) {
-		err = installRelease(clusterSummary, settings, releaseName, releaseNamespace, chartName, chartVersion,
-			kubeconfig, values, logger)
+		err = installRelease(clusterSummary, settings, releaseName, releaseNamespace, repositoryURL, chartName,
+			chartVersion, clientGetter, values, postRenderer, keyring, options, registryClient, logger)
 		if err != nil {
 			return err
 		}
@@ -798,7 +1014,7 @@ func upgradeRelease(clusterSummary *configv1alpha1.ClusterSummary, settings *cli
 		return err
 	}
 
-	_, err = upgradeObject.Run(releaseName, chartRequested, nil)
+	_, err = upgradeObject.Run(releaseName, chartRequested, values)
 	if err != nil {
 		return err
 	}
@@ -815,12 +1031,12 @@ func safeCloser(fileLock *flock.Flock, logger logr.Logger) error {
 	return nil
 }
 
-func actionConfigInit(namespace, kubeconfig string, logger logr.Logger) (*action.Configuration, error) {
-	actionConfig := new(action.Configuration)
+func actionConfigInit(namespace string, clientGetter *restClientGetter, storageDriver string,
+	logger logr.Logger) (*action.Configuration, error) {
 
-	clientConfig := kube.GetConfig(kubeconfig, settings.KubeContext, namespace)
+	actionConfig := new(action.Configuration)
 
-	err := actionConfig.Init(clientConfig, namespace, "secret", func(format string, v ...interface{}) {
+	err := actionConfig.Init(clientGetter.withNamespace(namespace), namespace, storageDriver, func(format string, v ...interface{}) {
 		logger.V(logs.LogDebug).Info(fmt.Sprintf(format, v))
 	})
 	if err != nil {
@@ -840,8 +1056,101 @@ func isChartInstallable(ch *chart.Chart) bool {
 	return false
 }
 
-func getReleaseInfo(releaseName, releaseNamespace, kubeconfig string, logger logr.Logger) (*releaseInfo, error) {
-	actionConfig, err := actionConfigInit(releaseNamespace, kubeconfig, logger)
+// getStorageDriver returns the Helm storage driver to use for an helm chart
+func getStorageDriver(options *configv1alpha1.HelmOptions) string {
+	if options == nil || options.StorageDriver == "" {
+		return string(configv1alpha1.HelmStorageDriverSecret)
+	}
+
+	return string(options.StorageDriver)
+}
+
+// getTimeout returns the time to wait for any individual Kubernetes operation
+func getTimeout(timeout *metav1.Duration) time.Duration {
+	if timeout == nil {
+		return defaultHelmTimeout
+	}
+
+	return timeout.Duration
+}
+
+// applyInstallOptions applies HelmChart install options to the Helm install action
+func applyInstallOptions(installObject *action.Install, options *configv1alpha1.HelmOptions) {
+	if options == nil || options.Install == nil {
+		installObject.Timeout = defaultHelmTimeout
+		return
+	}
+
+	installOptions := options.Install
+	installObject.Wait = installOptions.Wait
+	installObject.WaitForJobs = installOptions.WaitForJobs
+	installObject.Timeout = getTimeout(installOptions.Timeout)
+	installObject.Atomic = installOptions.Atomic
+	installObject.SkipCRDs = installOptions.SkipCRDs
+	installObject.DisableHooks = installOptions.DisableHooks
+	installObject.Replace = installOptions.Replace
+	installObject.DisableOpenAPIValidation = installOptions.DisableOpenAPIValidation
+}
+
+// applyUpgradeOptions applies HelmChart upgrade options to the Helm upgrade action
+func applyUpgradeOptions(upgradeObject *action.Upgrade, options *configv1alpha1.HelmOptions) {
+	if options == nil || options.Upgrade == nil {
+		upgradeObject.Timeout = defaultHelmTimeout
+		return
+	}
+
+	upgradeOptions := options.Upgrade
+	upgradeObject.Wait = upgradeOptions.Wait
+	upgradeObject.WaitForJobs = upgradeOptions.WaitForJobs
+	upgradeObject.Timeout = getTimeout(upgradeOptions.Timeout)
+	upgradeObject.Atomic = upgradeOptions.Atomic
+	upgradeObject.SkipCRDs = upgradeOptions.SkipCRDs
+	upgradeObject.DisableHooks = upgradeOptions.DisableHooks
+	upgradeObject.Force = upgradeOptions.Force
+	upgradeObject.CleanupOnFail = upgradeOptions.CleanupOnFail
+	upgradeObject.DisableOpenAPIValidation = upgradeOptions.DisableOpenAPIValidation
+}
+
+// applyUninstallOptions applies HelmChart uninstall options to the Helm uninstall action
+func applyUninstallOptions(uninstallObject *action.Uninstall, options *configv1alpha1.HelmOptions) {
+	if options == nil || options.Uninstall == nil {
+		uninstallObject.Timeout = defaultHelmTimeout
+		return
+	}
+
+	uninstallOptions := options.Uninstall
+	uninstallObject.Wait = uninstallOptions.Wait
+	uninstallObject.Timeout = getTimeout(uninstallOptions.Timeout)
+	uninstallObject.DisableHooks = uninstallOptions.DisableHooks
+	uninstallObject.KeepHistory = uninstallOptions.KeepHistory
+}
+
+// isWaitRequested returns true if, for the action taken on the helm chart,
+// helm was asked to wait for all resources to be ready (Atomic implies wait)
+func isWaitRequested(requestedChart *configv1alpha1.HelmChart, report *configv1alpha1.ReleaseReport) bool {
+	options := requestedChart.Options
+	if options == nil || report == nil {
+		return false
+	}
+
+	switch configv1alpha1.HelmAction(report.Action) {
+	case configv1alpha1.InstallHelmAction:
+		return options.Install != nil && (options.Install.Wait || options.Install.Atomic)
+	case configv1alpha1.UpgradeHelmAction:
+		return options.Upgrade != nil && (options.Upgrade.Wait || options.Upgrade.Atomic)
+	}
+
+	return false
+}
+
+func isReleaseDeployed(currentRelease *releaseInfo) bool {
+	return currentRelease != nil && currentRelease.Status == release.StatusDeployed.String()
+}
+
+func getReleaseInfo(releaseName, releaseNamespace string, clientGetter *restClientGetter, options *configv1alpha1.HelmOptions,
+	logger logr.Logger) (*releaseInfo, error) {
+
+	actionConfig, err := actionConfigInit(releaseNamespace, clientGetter, getStorageDriver(options), logger)
 
 	if err != nil {
 		return nil, err
@@ -861,6 +1170,9 @@ func getReleaseInfo(releaseName, releaseNamespace, kubeconfig string, logger log
 		Chart:            results.Chart.Metadata.Name,
 		ChartVersion:     results.Chart.Metadata.Version,
 		AppVersion:       results.Chart.AppVersion(),
+		Values:           results.Config,
+		Manifest:         results.Manifest,
+		ManagedBy:        results.Chart.Metadata.Annotations[ReleaseClusterSummaryAnnotation],
 	}
 
 	var t metav1.Time
@@ -896,15 +1208,16 @@ func shouldInstall(currentRelease *releaseInfo, requestedChart *configv1alpha1.H
 }
 
 // shouldUpgrade returns true if action is not uninstall and current installed chart is different
-// than what currently requested by customer
+// than what currently requested by customer (either chart version or instantiated values)
 func shouldUpgrade(currentRelease *releaseInfo, requestedChart *configv1alpha1.HelmChart,
-	clusterSummary *configv1alpha1.ClusterSummary) bool {
+	requestedValues chartutil.Values, clusterSummary *configv1alpha1.ClusterSummary) bool {
 
 	if clusterSummary.Spec.ClusterProfileSpec.SyncMode != configv1alpha1.SyncModeContinuousWithDriftDetection {
 		// With drift detection mode, there is reconciliation due to configuration drift even
 		// when version is same. So skip this check in SyncModeContinuousWithDriftDetection
 		if currentRelease != nil &&
-			currentRelease.ChartVersion == requestedChart.ChartVersion {
+			currentRelease.ChartVersion == requestedChart.ChartVersion &&
+			!haveValuesChanged(currentRelease, requestedValues) {
 
 			return false
 		}
@@ -913,6 +1226,20 @@ func shouldUpgrade(currentRelease *releaseInfo, requestedChart *configv1alpha1.H
 	return requestedChart.HelmChartAction != configv1alpha1.HelmChartActionUninstall
 }
 
+// haveValuesChanged returns true if values current release was deployed with are different
+// than requested (instantiated) values
+func haveValuesChanged(currentRelease *releaseInfo, requestedValues chartutil.Values) bool {
+	if currentRelease == nil {
+		return false
+	}
+
+	if len(currentRelease.Values) == 0 && len(requestedValues) == 0 {
+		return false
+	}
+
+	return !reflect.DeepEqual(currentRelease.Values, map[string]interface{}(requestedValues))
+}
+
 // shouldUninstall returns true if action is uninstall there is a release installed currently
 func shouldUninstall(currentRelease *releaseInfo, requestedChart *configv1alpha1.HelmChart) bool {
 	if requestedChart.HelmChartAction != configv1alpha1.HelmChartActionUninstall {
@@ -929,8 +1256,8 @@ func shouldUninstall(currentRelease *releaseInfo, requestedChart *configv1alpha1
 // doInstallRelease installs helm release in the CAPI Cluster.
 // No action in DryRun mode.
 func doInstallRelease(ctx context.Context, clusterSummary *configv1alpha1.ClusterSummary,
-	remoteClient client.Client, requestedChart *configv1alpha1.HelmChart,
-	kubeconfig string, logger logr.Logger) error {
+	remoteClient client.Client, requestedChart *configv1alpha1.HelmChart, values chartutil.Values,
+	postRenderer *kustomizePostRenderer, clientGetter *restClientGetter, logger logr.Logger) error {
 
 	// No-op in DryRun mode
 	if clusterSummary.Spec.ClusterProfileSpec.SyncMode == configv1alpha1.SyncModeDryRun {
@@ -949,28 +1276,29 @@ func doInstallRelease(ctx context.Context, clusterSummary *configv1alpha1.Cluste
 		return err
 	}
 
-	err = repoAdd(settings, requestedChart.RepositoryName,
-		requestedChart.RepositoryURL, logger)
+	settings := getSettings()
+
+	err = prepareRepository(ctx, settings, requestedChart, logger)
 	if err != nil {
 		return err
 	}
 
-	err = repoUpdate(settings, requestedChart.RepositoryName,
-		requestedChart.RepositoryURL, logger)
+	registryClient, cleanup, err := getRegistryClient(ctx, getManagementClusterClient(), requestedChart, logger)
 	if err != nil {
 		return err
 	}
+	defer cleanup()
 
-	var values chartutil.Values
-	values, err = getInstantiatedValues(ctx, clusterSummary, requestedChart, logger)
+	keyring, cleanupKeyring, err := getKeyring(ctx, getManagementClusterClient(), requestedChart, logger)
 	if err != nil {
 		return err
 	}
+	defer cleanupKeyring()
 
 	err = installRelease(clusterSummary, settings, requestedChart.ReleaseName,
-		requestedChart.ReleaseNamespace, requestedChart.ChartName,
-		requestedChart.ChartVersion, kubeconfig,
-		values, logger)
+		requestedChart.ReleaseNamespace, requestedChart.RepositoryURL, getChartName(requestedChart),
+		requestedChart.ChartVersion, clientGetter,
+		values, postRenderer, keyring, requestedChart.Options, registryClient, logger)
 	if err != nil {
 		return err
 	}
@@ -981,7 +1309,7 @@ func doInstallRelease(ctx context.Context, clusterSummary *configv1alpha1.Cluste
 // doUninstallRelease uninstalls helm release from the CAPI Cluster.
 // No action in DryRun mode.
 func doUninstallRelease(clusterSummary *configv1alpha1.ClusterSummary, requestedChart *configv1alpha1.HelmChart,
-	kubeconfig string, logger logr.Logger) error {
+	clientGetter *restClientGetter, logger logr.Logger) error {
 
 	// No-op in DryRun mode
 	if clusterSummary.Spec.ClusterProfileSpec.SyncMode == configv1alpha1.SyncModeDryRun {
@@ -994,14 +1322,14 @@ func doUninstallRelease(clusterSummary *configv1alpha1.ClusterSummary, requested
 		requestedChart.RepositoryName))
 
 	return uninstallRelease(clusterSummary, requestedChart.ReleaseName, requestedChart.ReleaseNamespace,
-		kubeconfig, logger)
+		clientGetter, requestedChart.Options, logger)
 }
 
 // doUpgradeRelease upgrades helm release in the CAPI Cluster.
 // No action in DryRun mode.
 func doUpgradeRelease(ctx context.Context, clusterSummary *configv1alpha1.ClusterSummary,
-	remoteClient client.Client, requestedChart *configv1alpha1.HelmChart,
-	kubeconfig string, logger logr.Logger) error {
+	remoteClient client.Client, requestedChart *configv1alpha1.HelmChart, values chartutil.Values,
+	postRenderer *kustomizePostRenderer, clientGetter *restClientGetter, logger logr.Logger) error {
 
 	// No-op in DryRun mode
 	if clusterSummary.Spec.ClusterProfileSpec.SyncMode == configv1alpha1.SyncModeDryRun {
@@ -1020,28 +1348,29 @@ func doUpgradeRelease(ctx context.Context, clusterSummary *configv1alpha1.Cluste
 		return err
 	}
 
-	err = repoAdd(settings, requestedChart.RepositoryName,
-		requestedChart.RepositoryURL, logger)
+	settings := getSettings()
+
+	err = prepareRepository(ctx, settings, requestedChart, logger)
 	if err != nil {
 		return err
 	}
 
-	err = repoUpdate(settings, requestedChart.RepositoryName,
-		requestedChart.RepositoryURL, logger)
+	registryClient, cleanup, err := getRegistryClient(ctx, getManagementClusterClient(), requestedChart, logger)
 	if err != nil {
 		return err
 	}
+	defer cleanup()
 
-	var values chartutil.Values
-	values, err = getInstantiatedValues(ctx, clusterSummary, requestedChart, logger)
+	keyring, cleanupKeyring, err := getKeyring(ctx, getManagementClusterClient(), requestedChart, logger)
 	if err != nil {
 		return err
 	}
+	defer cleanupKeyring()
 
 	err = upgradeRelease(clusterSummary, settings, requestedChart.ReleaseName,
-		requestedChart.ReleaseNamespace, requestedChart.ChartName,
-		requestedChart.ChartVersion, kubeconfig,
-		values, logger)
+		requestedChart.ReleaseNamespace, requestedChart.RepositoryURL, getChartName(requestedChart),
+		requestedChart.ChartVersion, clientGetter, values, postRenderer, keyring, requestedChart.Options,
+		getMaxHistory(requestedChart.Remediation), registryClient, logger)
 	if err != nil {
 		return err
 	}
@@ -1071,7 +1400,7 @@ func updateChartsInClusterConfiguration(ctx context.Context, c client.Client, cl
 
 // undeployStaleReleases uninstalls all helm charts previously managed and not referenced anyomre
 func undeployStaleReleases(ctx context.Context, c client.Client, clusterSummary *configv1alpha1.ClusterSummary,
-	kubeconfig string, logger logr.Logger) ([]configv1alpha1.ReleaseReport, error) {
+	clientGetter *restClientGetter, logger logr.Logger) ([]configv1alpha1.ReleaseReport, error) {
 
 	chartManager, err := chartmanager.GetChartManagerInstance(ctx, c)
 	if err != nil {
@@ -1094,10 +1423,18 @@ func undeployStaleReleases(ctx context.Context, c client.Client, clusterSummary
 		if _, ok := currentlyReferencedReleases[releaseKey]; !ok {
 			logger.V(logs.LogInfo).Info(fmt.Sprintf("helm release %s (namespace %s) used to be managed but not referenced anymore",
 				managedHelmReleases[i].Name, managedHelmReleases[i].Namespace))
-			if err := uninstallRelease(clusterSummary, managedHelmReleases[i].Name, managedHelmReleases[i].Namespace,
-				kubeconfig, logger); err != nil {
+			uninstalled, err := uninstallStaleRelease(clusterSummary, managedHelmReleases[i].Name,
+				managedHelmReleases[i].Namespace, clientGetter, logger)
+			if err != nil {
 				return nil, err
 			}
+			if !uninstalled {
+				reports = append(reports, configv1alpha1.ReleaseReport{
+					ReleaseNamespace: managedHelmReleases[i].Namespace, ReleaseName: managedHelmReleases[i].Name,
+					Action: string(configv1alpha1.NoHelmAction), Message: staleReleaseNotManagedMessage,
+				})
+				continue
+			}
 			reports = append(reports, configv1alpha1.ReleaseReport{
 				ReleaseNamespace: managedHelmReleases[i].Namespace, ReleaseName: managedHelmReleases[i].Name,
 				Action: string(configv1alpha1.UninstallHelmAction),
@@ -1108,6 +1445,223 @@ func undeployStaleReleases(ctx context.Context, c client.Client, clusterSummary
 	return reports, nil
 }
 
+// needsRemediation returns true if helm release is in a failed or pending state.
+// Helm actions are synchronous, so an helm release found in a pending state is left
+// over by an helm action which never completed (for instance pod was restarted).
+func needsRemediation(currentRelease *releaseInfo) bool {
+	if currentRelease == nil {
+		return false
+	}
+
+	switch currentRelease.Status {
+	case release.StatusFailed.String(), release.StatusPendingInstall.String(),
+		release.StatusPendingUpgrade.String(), release.StatusPendingRollback.String():
+		return true
+	}
+
+	return false
+}
+
+// getRollbackRevision returns the most recent revision, before the current one, which was
+// successfully deployed. Returns 0 if there is no such revision (for instance helm install failed).
+func getRollbackRevision(history []*release.Release) int {
+	latest := 0
+	for i := range history {
+		if history[i].Version > latest {
+			latest = history[i].Version
+		}
+	}
+
+	revision := 0
+	for i := range history {
+		if history[i].Version == latest || history[i].Info == nil {
+			continue
+		}
+		if history[i].Info.Status != release.StatusDeployed && history[i].Info.Status != release.StatusSuperseded {
+			continue
+		}
+		if history[i].Version > revision {
+			revision = history[i].Version
+		}
+	}
+
+	return revision
+}
+
+// getRemediationRetries returns the maximum number of remediation attempts
+func getRemediationRetries(remediation *configv1alpha1.HelmRemediation) int32 {
+	if remediation == nil || remediation.Retries == 0 {
+		return defaultRemediationRetries
+	}
+
+	return remediation.Retries
+}
+
+// getMaxHistory returns the maximum number of revisions saved per helm release.
+// 0 means no limit.
+func getMaxHistory(remediation *configv1alpha1.HelmRemediation) int {
+	if remediation == nil {
+		return 0
+	}
+
+	return int(remediation.MaxHistory)
+}
+
+// remediateRelease recovers an helm release found in a failed or pending state, as requested
+// by HelmChart remediation:
+// - if there is a previously deployed revision (upgrade failed), helm release is rolled back to it;
+// - otherwise (install failed), helm release is uninstalled so it can be installed again.
+// Remediation attempts are recorded in ClusterSummary Status. Once retries are exhausted,
+// no remediation is attempted.
+// Returns the helm release after remediation and a message describing remediation applied (empty
+// if no remediation was applied).
+// No action in DryRun mode.
+func remediateRelease(ctx context.Context, c client.Client, clusterSummary *configv1alpha1.ClusterSummary,
+	requestedChart *configv1alpha1.HelmChart, currentRelease *releaseInfo, clientGetter *restClientGetter,
+	logger logr.Logger) (*releaseInfo, string, error) {
+
+	// No-op in DryRun mode
+	if clusterSummary.Spec.ClusterProfileSpec.SyncMode == configv1alpha1.SyncModeDryRun {
+		return currentRelease, "", nil
+	}
+
+	remediation := requestedChart.Remediation
+	if remediation == nil || !needsRemediation(currentRelease) {
+		return currentRelease, "", nil
+	}
+
+	var attempts int32
+	if summary := getHelmChartSummary(clusterSummary, requestedChart.ReleaseNamespace,
+		requestedChart.ReleaseName); summary != nil {
+
+		attempts = summary.RemediationAttempts
+	}
+	if attempts >= getRemediationRetries(remediation) {
+		logger.V(logs.LogInfo).Info(fmt.Sprintf("helm release in status %s. Remediation retries exhausted",
+			currentRelease.Status))
+		return currentRelease, "", nil
+	}
+
+	actionConfig, err := actionConfigInit(requestedChart.ReleaseNamespace, clientGetter,
+		getStorageDriver(requestedChart.Options), logger)
+	if err != nil {
+		return nil, "", err
+	}
+
+	history, err := action.NewHistory(actionConfig).Run(requestedChart.ReleaseName)
+	if err != nil {
+		return nil, "", err
+	}
+
+	revision := getRollbackRevision(history)
+	var message string
+	var remediate func() error
+	switch {
+	case revision != 0 && remediation.RollbackOnUpgradeFailure:
+		message = fmt.Sprintf("Release in status %s rolled back to revision %d", currentRelease.Status, revision)
+		remediate = func() error {
+			return rollbackRelease(actionConfig, requestedChart, revision, logger)
+		}
+	case revision == 0 && remediation.UninstallOnInstallFailure:
+		message = fmt.Sprintf("Release in status %s uninstalled", currentRelease.Status)
+		remediate = func() error {
+			// History is not kept, otherwise release could not be installed again
+			options := &configv1alpha1.HelmOptions{StorageDriver: configv1alpha1.HelmStorageDriver(
+				getStorageDriver(requestedChart.Options))}
+			return uninstallRelease(clusterSummary, requestedChart.ReleaseName, requestedChart.ReleaseNamespace,
+				clientGetter, options, logger)
+		}
+	default:
+		return currentRelease, "", nil
+	}
+
+	// Attempt is recorded before remediating, so a remediation which keeps failing
+	// is not attempted more than retries times
+	err = updateRemediationStatus(ctx, c, clusterSummary, requestedChart, attempts+1, message)
+	if err != nil {
+		return nil, "", err
+	}
+
+	logger.V(logs.LogInfo).Info(fmt.Sprintf("remediating helm release in status %s", currentRelease.Status))
+	err = remediate()
+	if err != nil {
+		return nil, "", err
+	}
+	recordEvent(clusterSummary, corev1.EventTypeWarning, eventReasonHelmRemediation, "helm release %s/%s: %s",
+		requestedChart.ReleaseNamespace, requestedChart.ReleaseName, message)
+
+	currentRelease, err = getReleaseInfo(requestedChart.ReleaseName,
+		requestedChart.ReleaseNamespace, clientGetter, requestedChart.Options, logger)
+	if err != nil && !errors.Is(err, driver.ErrReleaseNotFound) {
+		return nil, "", err
+	}
+
+	return currentRelease, message, nil
+}
+
+// rollbackRelease rolls helm release back to revision
+func rollbackRelease(actionConfig *action.Configuration, requestedChart *configv1alpha1.HelmChart,
+	revision int, logger logr.Logger) error {
+
+	logger.V(logs.LogDebug).Info(fmt.Sprintf("rolling back release to revision %d", revision))
+
+	rollbackObject := action.NewRollback(actionConfig)
+	rollbackObject.Version = revision
+	rollbackObject.MaxHistory = getMaxHistory(requestedChart.Remediation)
+	// Wait and timeout are the ones requested for upgrade
+	upgradeObject := action.NewUpgrade(actionConfig)
+	applyUpgradeOptions(upgradeObject, requestedChart.Options)
+	rollbackObject.Wait = upgradeObject.Wait
+	rollbackObject.WaitForJobs = upgradeObject.WaitForJobs
+	rollbackObject.Timeout = upgradeObject.Timeout
+	rollbackObject.DisableHooks = upgradeObject.DisableHooks
+	rollbackObject.CleanupOnFail = upgradeObject.CleanupOnFail
+
+	err := rollbackObject.Run(requestedChart.ReleaseName)
+	if err != nil {
+		return err
+	}
+
+	logger.V(logs.LogDebug).Info("rolling back release done")
+	return nil
+}
+
+// uninstallStaleRelease uninstalls an helm release not referenced anymore.
+// Helm chart options, and so storage driver, used to install it are not known anymore.
+// So look for the release using all supported storage drivers.
+// Helm chart AdoptionPolicy is not known anymore either. So an helm release not managed by
+// ClusterSummary (see isReleaseManagedBy) is left untouched. Returns false in such a case.
+func uninstallStaleRelease(clusterSummary *configv1alpha1.ClusterSummary,
+	releaseName, releaseNamespace string, clientGetter *restClientGetter, logger logr.Logger) (bool, error) {
+
+	storageDrivers := []configv1alpha1.HelmStorageDriver{
+		configv1alpha1.HelmStorageDriverSecret,
+		configv1alpha1.HelmStorageDriverConfigMap,
+	}
+
+	var err error
+	for i := range storageDrivers {
+		options := &configv1alpha1.HelmOptions{StorageDriver: storageDrivers[i]}
+		var currentRelease *releaseInfo
+		currentRelease, err = getReleaseInfo(releaseName, releaseNamespace, clientGetter, options, logger)
+		if errors.Is(err, driver.ErrReleaseNotFound) {
+			continue
+		} else if err != nil {
+			return false, err
+		}
+
+		if !isReleaseManagedBy(clusterSummary, currentRelease) {
+			logger.V(logs.LogInfo).Info(fmt.Sprintf("helm release %s/%s is not managed by ClusterSummary. Not uninstalling it.",
+				releaseNamespace, releaseName))
+			return false, nil
+		}
+
+		return true, uninstallRelease(clusterSummary, releaseName, releaseNamespace, clientGetter, options, logger)
+	}
+
+	return false, err
+}
+
 // updateStatusForReferencedHelmReleases considers helm releases ClusterSummary currently
 // references. For each of those helm releases, adds an entry in ClusterSummary.Status reporting
 // whether such helm release is managed by this ClusterSummary or not.
@@ -1142,8 +1696,10 @@ func updateStatusForReferencedHelmReleases(ctx context.Context, c client.Client,
 	currentlyReferenced := make(map[string]bool)
 
 	conflict := false
+	var conflictMessages []string
 
 	err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		conflictMessages = make([]string, 0)
 		currentClusterSummary := &configv1alpha1.ClusterSummary{}
 		err = c.Get(ctx,
 			types.NamespacedName{Namespace: clusterSummary.Namespace, Name: clusterSummary.Name}, currentClusterSummary)
@@ -1160,6 +1716,23 @@ func updateStatusForReferencedHelmReleases(ctx context.Context, c client.Client,
 					ReleaseNamespace: currentChart.ReleaseNamespace,
 					Status:           configv1alpha1.HelChartStatusManaging,
 				}
+				// Remediation attempts are tracked till helm release is successfully deployed
+				previous := getHelmChartSummary(currentClusterSummary, currentChart.ReleaseNamespace, currentChart.ReleaseName)
+				if previous != nil && previous.Status == configv1alpha1.HelChartStatusManaging {
+					helmReleaseSummaries[i].RemediationAttempts = previous.RemediationAttempts
+					helmReleaseSummaries[i].LastRemediation = previous.LastRemediation
+				}
+				if previous != nil {
+					helmReleaseSummaries[i].PostRenderersHash = previous.PostRenderersHash
+					helmReleaseSummaries[i].ResolvedChartVersion = previous.ResolvedChartVersion
+				}
+				// Till helm release is installed, or allowed to be adopted, it is not considered
+				// managed by this ClusterSummary (see isReleaseManagedBy)
+				if previous != nil && previous.Status == configv1alpha1.HelChartStatusManaging {
+					helmReleaseSummaries[i].AdoptionPending = previous.AdoptionPending
+				} else {
+					helmReleaseSummaries[i].AdoptionPending = true
+				}
 				currentlyReferenced[helmInfo(currentChart.ReleaseNamespace, currentChart.ReleaseName)] = true
 			} else {
 				var managerName string
@@ -1174,6 +1747,15 @@ func updateStatusForReferencedHelmReleases(ctx context.Context, c client.Client,
 					Status:           configv1alpha1.HelChartStatusConflict,
 					ConflictMessage:  fmt.Sprintf("ClusterSummary %s managing it", managerName),
 				}
+				// Conflict is reported by an event only when it first appears
+				previous := getHelmChartSummary(currentClusterSummary, currentChart.ReleaseNamespace, currentChart.ReleaseName)
+				if previous == nil || previous.Status != configv1alpha1.HelChartStatusConflict ||
+					previous.ConflictMessage != helmReleaseSummaries[i].ConflictMessage {
+
+					conflictMessages = append(conflictMessages,
+						fmt.Sprintf("helm release %s/%s is managed by ClusterSummary %s",
+							currentChart.ReleaseNamespace, currentChart.ReleaseName, managerName))
+				}
 				conflict = true
 			}
 		}
@@ -1195,9 +1777,120 @@ func updateStatusForReferencedHelmReleases(ctx context.Context, c client.Client,
 
 		return c.Status().Update(ctx, currentClusterSummary)
 	})
+	if err == nil {
+		for i := range conflictMessages {
+			recordEvent(clusterSummary, corev1.EventTypeWarning, eventReasonHelmChartConflict, "%s", conflictMessages[i])
+		}
+	}
 	return conflict, err
 }
 
+// getHelmChartSummary returns the entry in ClusterSummary Status for an helm release.
+// Returns nil if not found.
+func getHelmChartSummary(clusterSummary *configv1alpha1.ClusterSummary,
+	releaseNamespace, releaseName string) *configv1alpha1.HelmChartSummary {
+
+	for i := range clusterSummary.Status.HelmReleaseSummaries {
+		summary := &clusterSummary.Status.HelmReleaseSummaries[i]
+		if summary.ReleaseNamespace == releaseNamespace && summary.ReleaseName == releaseName {
+			return summary
+		}
+	}
+
+	return nil
+}
+
+// updateRemediationStatus records, in ClusterSummary Status, remediation attempts and last remediation
+// applied to an helm release.
+func updateRemediationStatus(ctx context.Context, c client.Client, clusterSummary *configv1alpha1.ClusterSummary,
+	requestedChart *configv1alpha1.HelmChart, attempts int32, lastRemediation string) error {
+
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		currentClusterSummary := &configv1alpha1.ClusterSummary{}
+		err := c.Get(ctx,
+			types.NamespacedName{Namespace: clusterSummary.Namespace, Name: clusterSummary.Name}, currentClusterSummary)
+		if err != nil {
+			return err
+		}
+
+		summary := getHelmChartSummary(currentClusterSummary, requestedChart.ReleaseNamespace, requestedChart.ReleaseName)
+		if summary == nil {
+			return nil
+		}
+		summary.RemediationAttempts = attempts
+		summary.LastRemediation = lastRemediation
+
+		return c.Status().Update(ctx, currentClusterSummary)
+	})
+	if err != nil {
+		return err
+	}
+
+	// Keep in memory copy in sync
+	if summary := getHelmChartSummary(clusterSummary, requestedChart.ReleaseNamespace,
+		requestedChart.ReleaseName); summary != nil {
+
+		summary.RemediationAttempts = attempts
+		summary.LastRemediation = lastRemediation
+	}
+
+	return nil
+}
+
+// updateHelmChartSummary applies mutate to the entry, in ClusterSummary Status, for an helm release.
+// No action if there is no such entry.
+func updateHelmChartSummary(ctx context.Context, c client.Client, clusterSummary *configv1alpha1.ClusterSummary,
+	requestedChart *configv1alpha1.HelmChart, mutate func(summary *configv1alpha1.HelmChartSummary)) error {
+
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		currentClusterSummary := &configv1alpha1.ClusterSummary{}
+		err := c.Get(ctx,
+			types.NamespacedName{Namespace: clusterSummary.Namespace, Name: clusterSummary.Name}, currentClusterSummary)
+		if err != nil {
+			return err
+		}
+
+		summary := getHelmChartSummary(currentClusterSummary, requestedChart.ReleaseNamespace, requestedChart.ReleaseName)
+		if summary == nil {
+			return nil
+		}
+		mutate(summary)
+
+		return c.Status().Update(ctx, currentClusterSummary)
+	})
+	if err != nil {
+		return err
+	}
+
+	// Keep in memory copy in sync
+	if summary := getHelmChartSummary(clusterSummary, requestedChart.ReleaseNamespace,
+		requestedChart.ReleaseName); summary != nil {
+
+		mutate(summary)
+	}
+
+	return nil
+}
+
+// resetRemediationAttempts resets remediation attempts for an helm release.
+// No action if no remediation was attempted.
+// No action in DryRun mode.
+func resetRemediationAttempts(ctx context.Context, c client.Client, clusterSummary *configv1alpha1.ClusterSummary,
+	requestedChart *configv1alpha1.HelmChart) error {
+
+	// No-op in DryRun mode
+	if clusterSummary.Spec.ClusterProfileSpec.SyncMode == configv1alpha1.SyncModeDryRun {
+		return nil
+	}
+
+	summary := getHelmChartSummary(clusterSummary, requestedChart.ReleaseNamespace, requestedChart.ReleaseName)
+	if summary == nil || summary.RemediationAttempts == 0 {
+		return nil
+	}
+
+	return updateRemediationStatus(ctx, c, clusterSummary, requestedChart, 0, summary.LastRemediation)
+}
+
 // updateStatusForNonReferencedHelmReleases walks ClusterSummary.Status entries.
 // Removes any entry pointing to a helm release currently not referenced by ClusterSummary.
 // No action in DryRun mode.
@@ -1329,9 +2022,19 @@ func updateClusterReportWithHelmReports(ctx context.Context, c client.Client,
 	return err
 }
 
+// getInstantiatedValues returns the values for helm chart. Following values are merged
+// in order, a value set later overriding the one set earlier:
+// - values ValuesFrom point to (merged in order);
+// - instantiated inline Values;
+// - ValuesObject.
 func getInstantiatedValues(ctx context.Context, clusterSummary *configv1alpha1.ClusterSummary,
 	requestedChart *configv1alpha1.HelmChart, logger logr.Logger) (chartutil.Values, error) {
 
+	values, err := getValuesFrom(ctx, clusterSummary, requestedChart, logger)
+	if err != nil {
+		return nil, err
+	}
+
 	instantiatedValues, err := instantiateTemplateValues(ctx, getManagementClusterConfig(), getManagementClusterClient(),
 		clusterSummary.Spec.ClusterType, clusterSummary.Spec.ClusterNamespace, clusterSummary.Spec.ClusterName,
 		requestedChart.ChartName, requestedChart.Values, requestedChart.SecretRef, logger)
@@ -1339,12 +2042,27 @@ func getInstantiatedValues(ctx context.Context, clusterSummary *configv1alpha1.C
 		return nil, err
 	}
 
-	return chartutil.ReadValues([]byte(instantiatedValues))
+	inlineValues, err := chartutil.ReadValues([]byte(instantiatedValues))
+	if err != nil {
+		return nil, err
+	}
+	values = mergeValues(values, inlineValues)
+
+	if requestedChart.ValuesObject != nil {
+		var objectValues chartutil.Values
+		objectValues, err = chartutil.ReadValues(requestedChart.ValuesObject.Raw)
+		if err != nil {
+			return nil, err
+		}
+		values = mergeValues(values, objectValues)
+	}
+
+	return values, nil
 }
 
 func deployResourceSummaryWithHelmResources(ctx context.Context, c client.Client,
 	clusterNamespace, clusterName string, clusterType libsveltosv1alpha1.ClusterType,
-	clusterSummary *configv1alpha1.ClusterSummary, kubeconfig string, logger logr.Logger) error {
+	clusterSummary *configv1alpha1.ClusterSummary, clientGetter *restClientGetter, logger logr.Logger) error {
 
 	chartManager, err := chartmanager.GetChartManagerInstance(ctx, c)
 	if err != nil {
@@ -1358,7 +2076,8 @@ func deployResourceSummaryWithHelmResources(ctx context.Context, c client.Client
 		l := logger.WithValues("chart", currentChart.ChartName, "releaseNamespace", currentChart.ReleaseNamespace)
 		l.V(logs.LogDebug).Info("collecting resources for helm chart")
 		if chartManager.CanManageChart(clusterSummary, currentChart) {
-			actionConfig, err := actionConfigInit(currentChart.ReleaseNamespace, kubeconfig, logger)
+			actionConfig, err := actionConfigInit(currentChart.ReleaseNamespace, clientGetter,
+				getStorageDriver(currentChart.Options), logger)
 
 			if err != nil {
 				return err
@@ -21,11 +21,14 @@ import (
 	"crypto/sha256"
 	"errors"
 	"reflect"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
 	"github.com/gdexlab/go-render/render"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chartutil"
 	"helm.sh/helm/v3/pkg/release"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -35,7 +38,6 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
 	libsveltosv1alpha1 "github.com/projectsveltos/libsveltos/api/v1alpha1"
-	"github.com/projectsveltos/libsveltos/lib/clusterproxy"
 	configv1alpha1 "github.com/projectsveltos/sveltos-manager/api/v1alpha1"
 	"github.com/projectsveltos/sveltos-manager/controllers"
 	"github.com/projectsveltos/sveltos-manager/controllers/chartmanager"
@@ -148,7 +150,37 @@ var _ = Describe("HandlersHelm", func() {
 			ChartVersion:    "v2.5.3",
 			HelmChartAction: configv1alpha1.HelmChartActionInstall,
 		}
-		Expect(controllers.ShouldUpgrade(currentRelease, requestChart, clusterSummary)).To(BeTrue())
+		Expect(controllers.ShouldUpgrade(currentRelease, requestChart, nil, clusterSummary)).To(BeTrue())
+	})
+
+	It("shouldUpgrade returns true when instantiated values are different than release values", func() {
+		currentRelease := &controllers.ReleaseInfo{
+			Status:       release.StatusDeployed.String(),
+			ChartVersion: "v2.5.3",
+			Values: map[string]interface{}{
+				"replicaCount": float64(1),
+			},
+		}
+		requestChart := &configv1alpha1.HelmChart{
+			ChartVersion:    "v2.5.3",
+			HelmChartAction: configv1alpha1.HelmChartActionInstall,
+		}
+
+		values, err := chartutil.ReadValues([]byte("replicaCount: 1"))
+		Expect(err).To(BeNil())
+		Expect(controllers.ShouldUpgrade(currentRelease, requestChart, values, clusterSummary)).To(BeFalse())
+
+		values, err = chartutil.ReadValues([]byte("replicaCount: 3"))
+		Expect(err).To(BeNil())
+		Expect(controllers.ShouldUpgrade(currentRelease, requestChart, values, clusterSummary)).To(BeTrue())
+
+		By("Removing all values")
+		values, err = chartutil.ReadValues([]byte(""))
+		Expect(err).To(BeNil())
+		Expect(controllers.ShouldUpgrade(currentRelease, requestChart, values, clusterSummary)).To(BeTrue())
+
+		currentRelease.Values = nil
+		Expect(controllers.ShouldUpgrade(currentRelease, requestChart, values, clusterSummary)).To(BeFalse())
 	})
 
 	It("UpdateStatusForReferencedHelmReleases updates ClusterSummary.Status.HelmReleaseSummaries", func() {
@@ -203,12 +235,15 @@ var _ = Describe("HandlersHelm", func() {
 		Expect(currentClusterSummary.Status.HelmReleaseSummaries[0].Status).To(Equal(configv1alpha1.HelChartStatusManaging))
 		Expect(currentClusterSummary.Status.HelmReleaseSummaries[0].ReleaseName).To(Equal(calicoChart.ReleaseName))
 		Expect(currentClusterSummary.Status.HelmReleaseSummaries[0].ReleaseNamespace).To(Equal(calicoChart.ReleaseNamespace))
+		// Newly referenced helm release is not considered managed till installed or adopted
+		Expect(currentClusterSummary.Status.HelmReleaseSummaries[0].AdoptionPending).To(BeTrue())
 
 		// UpdateStatusForReferencedHelmReleases adds status for referenced releases and does not remove any
 		// existing entry for non existing releases.
 		Expect(currentClusterSummary.Status.HelmReleaseSummaries[1].Status).To(Equal(kyvernoSummary.Status))
 		Expect(currentClusterSummary.Status.HelmReleaseSummaries[1].ReleaseName).To(Equal(kyvernoSummary.ReleaseName))
 		Expect(currentClusterSummary.Status.HelmReleaseSummaries[1].ReleaseNamespace).To(Equal(kyvernoSummary.ReleaseNamespace))
+		Expect(currentClusterSummary.Status.HelmReleaseSummaries[1].AdoptionPending).To(BeFalse())
 	})
 
 	It("updateStatusForReferencedHelmReleases is no-op in DryRun mode", func() {
@@ -503,13 +538,13 @@ var _ = Describe("HandlersHelm", func() {
 
 		Expect(waitForObject(context.TODO(), testEnv.Client, clusterReport)).To(Succeed())
 
-		kubeconfig, err := clusterproxy.CreateKubeconfig(klogr.New(), testEnv.Kubeconfig)
+		clientGetter, err := controllers.NewRESTClientGetter(testEnv.Config)
 		Expect(err).To(BeNil())
 
 		// ClusterSummary in DryRun mode. Nothing registered with chartManager with respect to the two referenced
 		// helm chart. So expect action for Install will be install, and the action for Uninstall will be no action as
 		// such release has never been installed.
-		err = controllers.HandleCharts(context.TODO(), clusterSummary, testEnv.Client, nil, kubeconfig, klogr.New())
+		err = controllers.HandleCharts(context.TODO(), clusterSummary, testEnv.Client, nil, clientGetter, klogr.New())
 		Expect(err).ToNot(BeNil())
 
 		var druRunError *configv1alpha1.DryRunReconciliationError
@@ -551,6 +586,167 @@ var _ = Describe("HandlersHelm", func() {
 		}
 		Expect(found).To(BeTrue())
 	})
+
+	It("needsRemediation returns true for helm releases in failed or pending state", func() {
+		Expect(controllers.NeedsRemediation(nil)).To(BeFalse())
+		Expect(controllers.NeedsRemediation(&controllers.ReleaseInfo{
+			Status: release.StatusDeployed.String()})).To(BeFalse())
+		Expect(controllers.NeedsRemediation(&controllers.ReleaseInfo{
+			Status: release.StatusFailed.String()})).To(BeTrue())
+		Expect(controllers.NeedsRemediation(&controllers.ReleaseInfo{
+			Status: release.StatusPendingInstall.String()})).To(BeTrue())
+		Expect(controllers.NeedsRemediation(&controllers.ReleaseInfo{
+			Status: release.StatusPendingUpgrade.String()})).To(BeTrue())
+	})
+
+	It("getRollbackRevision returns last successfully deployed revision before current one", func() {
+		getRelease := func(version int, status release.Status) *release.Release {
+			return &release.Release{Version: version, Info: &release.Info{Status: status}}
+		}
+
+		// Install failed
+		history := []*release.Release{getRelease(1, release.StatusFailed)}
+		Expect(controllers.GetRollbackRevision(history)).To(Equal(0))
+
+		// Upgrade failed
+		history = []*release.Release{
+			getRelease(3, release.StatusFailed),
+			getRelease(1, release.StatusSuperseded),
+			getRelease(2, release.StatusDeployed),
+		}
+		Expect(controllers.GetRollbackRevision(history)).To(Equal(2))
+
+		// Upgrade failed after a previous failed upgrade
+		history = []*release.Release{
+			getRelease(1, release.StatusDeployed),
+			getRelease(2, release.StatusFailed),
+			getRelease(3, release.StatusPendingUpgrade),
+		}
+		Expect(controllers.GetRollbackRevision(history)).To(Equal(1))
+	})
+
+	It("remediation attempts are recorded in ClusterSummary Status and reset once release is deployed", func() {
+		helmChart := &configv1alpha1.HelmChart{
+			RepositoryURL: randomString(), RepositoryName: randomString(),
+			ChartName: randomString(), ChartVersion: "1.0.0",
+			ReleaseName: randomString(), ReleaseNamespace: randomString(),
+			Remediation: &configv1alpha1.HelmRemediation{RollbackOnUpgradeFailure: true},
+		}
+		clusterSummary.Spec.ClusterProfileSpec.HelmCharts = []configv1alpha1.HelmChart{*helmChart}
+		clusterSummary.Status.HelmReleaseSummaries = []configv1alpha1.HelmChartSummary{
+			{
+				ReleaseName: helmChart.ReleaseName, ReleaseNamespace: helmChart.ReleaseNamespace,
+				Status: configv1alpha1.HelChartStatusManaging,
+			},
+		}
+
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(clusterSummary).Build()
+
+		message := randomString()
+		Expect(controllers.UpdateRemediationStatus(context.TODO(), c, clusterSummary, helmChart,
+			1, message)).To(Succeed())
+
+		verifyRemediation := func(attempts int32) {
+			currentClusterSummary := &configv1alpha1.ClusterSummary{}
+			Expect(c.Get(context.TODO(), types.NamespacedName{Namespace: clusterSummary.Namespace, Name: clusterSummary.Name},
+				currentClusterSummary)).To(Succeed())
+			Expect(len(currentClusterSummary.Status.HelmReleaseSummaries)).To(Equal(1))
+			Expect(currentClusterSummary.Status.HelmReleaseSummaries[0].RemediationAttempts).To(Equal(attempts))
+			Expect(currentClusterSummary.Status.HelmReleaseSummaries[0].LastRemediation).To(Equal(message))
+			Expect(clusterSummary.Status.HelmReleaseSummaries[0].RemediationAttempts).To(Equal(attempts))
+		}
+		verifyRemediation(1)
+
+		manager, err := chartmanager.GetChartManagerInstance(context.TODO(), c)
+		Expect(err).To(BeNil())
+		manager.RegisterClusterSummaryForCharts(clusterSummary)
+
+		// Remediation attempts are preserved when ClusterSummary Status is rebuilt
+		_, err = controllers.UpdateStatusForReferencedHelmReleases(context.TODO(), c, clusterSummary)
+		Expect(err).To(BeNil())
+		verifyRemediation(1)
+
+		Expect(controllers.ResetRemediationAttempts(context.TODO(), c, clusterSummary, helmChart)).To(Succeed())
+		verifyRemediation(0)
+	})
+
+	It("applyInstallOptions and applyUpgradeOptions set HelmChart options on helm actions", func() {
+		installObject := action.NewInstall(&action.Configuration{})
+		controllers.ApplyInstallOptions(installObject, nil)
+		Expect(installObject.Wait).To(BeFalse())
+		Expect(installObject.Timeout).To(Equal(5 * time.Minute))
+
+		options := &configv1alpha1.HelmOptions{
+			Install: &configv1alpha1.HelmInstallOptions{
+				Wait:         true,
+				WaitForJobs:  true,
+				Timeout:      &metav1.Duration{Duration: 2 * time.Minute},
+				SkipCRDs:     true,
+				DisableHooks: true,
+			},
+			Upgrade: &configv1alpha1.HelmUpgradeOptions{
+				Atomic:        true,
+				Force:         true,
+				CleanupOnFail: true,
+			},
+			Uninstall: &configv1alpha1.HelmUninstallOptions{
+				KeepHistory: true,
+				Timeout:     &metav1.Duration{Duration: time.Minute},
+			},
+		}
+
+		installObject = action.NewInstall(&action.Configuration{})
+		controllers.ApplyInstallOptions(installObject, options)
+		Expect(installObject.Wait).To(BeTrue())
+		Expect(installObject.WaitForJobs).To(BeTrue())
+		Expect(installObject.Timeout).To(Equal(2 * time.Minute))
+		Expect(installObject.SkipCRDs).To(BeTrue())
+		Expect(installObject.DisableHooks).To(BeTrue())
+		Expect(installObject.Atomic).To(BeFalse())
+
+		upgradeObject := action.NewUpgrade(&action.Configuration{})
+		controllers.ApplyUpgradeOptions(upgradeObject, options)
+		Expect(upgradeObject.Atomic).To(BeTrue())
+		Expect(upgradeObject.Force).To(BeTrue())
+		Expect(upgradeObject.CleanupOnFail).To(BeTrue())
+		Expect(upgradeObject.Timeout).To(Equal(5 * time.Minute))
+
+		uninstallObject := action.NewUninstall(&action.Configuration{})
+		controllers.ApplyUninstallOptions(uninstallObject, options)
+		Expect(uninstallObject.KeepHistory).To(BeTrue())
+		Expect(uninstallObject.Timeout).To(Equal(time.Minute))
+	})
+
+	It("getStorageDriver returns secret unless a different storage driver is requested", func() {
+		Expect(controllers.GetStorageDriver(nil)).To(Equal("secret"))
+		Expect(controllers.GetStorageDriver(&configv1alpha1.HelmOptions{})).To(Equal("secret"))
+		Expect(controllers.GetStorageDriver(&configv1alpha1.HelmOptions{
+			StorageDriver: configv1alpha1.HelmStorageDriverConfigMap})).To(Equal("configmap"))
+	})
+
+	It("isWaitRequested returns true only when helm action taken waits for resources to be ready", func() {
+		helmChart := &configv1alpha1.HelmChart{
+			RepositoryURL: randomString(), RepositoryName: randomString(),
+			ChartName: randomString(), ChartVersion: "1.0.0",
+			ReleaseName: randomString(), ReleaseNamespace: randomString(),
+		}
+		installReport := &configv1alpha1.ReleaseReport{Action: string(configv1alpha1.InstallHelmAction)}
+		upgradeReport := &configv1alpha1.ReleaseReport{Action: string(configv1alpha1.UpgradeHelmAction)}
+		noActionReport := &configv1alpha1.ReleaseReport{Action: string(configv1alpha1.NoHelmAction)}
+
+		Expect(controllers.IsWaitRequested(helmChart, installReport)).To(BeFalse())
+
+		helmChart.Options = &configv1alpha1.HelmOptions{
+			Install: &configv1alpha1.HelmInstallOptions{Wait: true},
+			Upgrade: &configv1alpha1.HelmUpgradeOptions{Atomic: true},
+		}
+		Expect(controllers.IsWaitRequested(helmChart, installReport)).To(BeTrue())
+		Expect(controllers.IsWaitRequested(helmChart, upgradeReport)).To(BeTrue())
+		Expect(controllers.IsWaitRequested(helmChart, noActionReport)).To(BeFalse())
+
+		helmChart.Options.Upgrade = nil
+		Expect(controllers.IsWaitRequested(helmChart, upgradeReport)).To(BeFalse())
+	})
 })
 
 var _ = Describe("Hash methods", func() {
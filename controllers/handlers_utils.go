@@ -217,6 +217,10 @@ func deployContent(ctx context.Context, remoteConfig *rest.Config, c, remoteClie
 				reports = append(reports, *conflictResourceReport)
 				continue
 			}
+			if ok {
+				recordEvent(clusterSummary, corev1.EventTypeWarning, eventReasonResourceConflict,
+					"%s %s/%s: %v", policy.GetKind(), policy.GetNamespace(), policy.GetName(), err)
+			}
 			return nil, err
 		}
 
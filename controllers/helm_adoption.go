@@ -0,0 +1,171 @@
+/*
+Copyright 2022-23. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/go-logr/logr"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/storage/driver"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	logs "github.com/projectsveltos/libsveltos/lib/logsettings"
+	configv1alpha1 "github.com/projectsveltos/sveltos-manager/api/v1alpha1"
+)
+
+const (
+	// releaseAdoptedMessage is reported when an helm release not installed by the
+	// ClusterSummary is adopted
+	releaseAdoptedMessage = "Helm release adopted"
+)
+
+// adoptionRefusedError is returned when an helm release, not installed by the ClusterSummary,
+// cannot be adopted because of the helm chart AdoptionPolicy
+type adoptionRefusedError struct {
+	message string
+}
+
+func (e *adoptionRefusedError) Error() string {
+	return e.message
+}
+
+// getReleaseManager returns the value of the annotation marking an helm release as managed
+// by a ClusterSummary
+func getReleaseManager(clusterSummary *configv1alpha1.ClusterSummary) string {
+	return fmt.Sprintf("%s/%s", clusterSummary.Namespace, clusterSummary.Name)
+}
+
+// markChartAsManaged annotates the chart an helm release is installed/upgraded with, so that
+// the helm release is marked as managed by the ClusterSummary
+func markChartAsManaged(chartRequested *chart.Chart, clusterSummary *configv1alpha1.ClusterSummary) {
+	if chartRequested.Metadata.Annotations == nil {
+		chartRequested.Metadata.Annotations = make(map[string]string)
+	}
+	chartRequested.Metadata.Annotations[ReleaseClusterSummaryAnnotation] = getReleaseManager(clusterSummary)
+}
+
+// isReleaseManagedBy returns true if helm release is marked as managed by the ClusterSummary.
+// Helm releases installed by Sveltos versions which did not mark helm releases are considered
+// managed by the ClusterSummary listing them in its Status (with AdoptionPending not set).
+func isReleaseManagedBy(clusterSummary *configv1alpha1.ClusterSummary, currentRelease *releaseInfo) bool {
+	if currentRelease.ManagedBy != "" {
+		return currentRelease.ManagedBy == getReleaseManager(clusterSummary)
+	}
+
+	summary := getHelmChartSummary(clusterSummary, currentRelease.ReleaseNamespace, currentRelease.ReleaseName)
+	return summary != nil && summary.Status == configv1alpha1.HelChartStatusManaging && !summary.AdoptionPending
+}
+
+// getAdoptionPolicy returns the helm chart AdoptionPolicy, defaulting to AdoptIfSameChart
+func getAdoptionPolicy(requestedChart *configv1alpha1.HelmChart) configv1alpha1.HelmAdoptionPolicy {
+	if requestedChart.AdoptionPolicy == "" {
+		return configv1alpha1.HelmAdoptionPolicyAdoptIfSameChart
+	}
+	return requestedChart.AdoptionPolicy
+}
+
+// getRequestedChartName returns the name of the chart requestedChart refers to, whatever form
+// (<repository>/<chart>, OCI or uploaded <chart>-<version>.tgz archive) ChartName is in
+func getRequestedChartName(requestedChart *configv1alpha1.HelmChart) string {
+	chartName := path.Base(getUploadedChartName(getChartName(requestedChart)))
+	if archiveName := strings.TrimSuffix(chartName, "."+chartExtension); archiveName != chartName {
+		return strings.TrimSuffix(archiveName, "-"+requestedChart.ChartVersion)
+	}
+	return chartName
+}
+
+// getAdoptionRefusal returns why the helm release, not managed by the ClusterSummary, cannot
+// be adopted. Returns an empty string if helm chart AdoptionPolicy allows adopting it.
+func getAdoptionRefusal(currentRelease *releaseInfo, requestedChart *configv1alpha1.HelmChart) string {
+	owner := "is not managed by Sveltos"
+	if currentRelease.ManagedBy != "" {
+		owner = fmt.Sprintf("is managed by ClusterSummary %s", currentRelease.ManagedBy)
+	}
+
+	policy := getAdoptionPolicy(requestedChart)
+	switch policy {
+	case configv1alpha1.HelmAdoptionPolicyForceAdopt:
+		return ""
+	case configv1alpha1.HelmAdoptionPolicyAdoptIfSameChart:
+		chartName := getRequestedChartName(requestedChart)
+		if currentRelease.Chart == chartName {
+			return ""
+		}
+		return fmt.Sprintf("Helm release %s/%s %s and its chart %s differs from %s. Adoption policy %s",
+			currentRelease.ReleaseNamespace, currentRelease.ReleaseName, owner, currentRelease.Chart,
+			chartName, policy)
+	default:
+		return fmt.Sprintf("Helm release %s/%s %s. Adoption policy %s",
+			currentRelease.ReleaseNamespace, currentRelease.ReleaseName, owner, policy)
+	}
+}
+
+// uninstallAdoptableRelease uninstalls the helm release. An helm release not managed by the
+// ClusterSummary, which helm chart AdoptionPolicy does not allow adopting, is left untouched.
+// In such a case, the reason is returned.
+func uninstallAdoptableRelease(clusterSummary *configv1alpha1.ClusterSummary, requestedChart *configv1alpha1.HelmChart,
+	clientGetter *restClientGetter, logger logr.Logger) (string, error) {
+
+	currentRelease, err := getReleaseInfo(requestedChart.ReleaseName, requestedChart.ReleaseNamespace,
+		clientGetter, requestedChart.Options, logger)
+	if err != nil {
+		if errors.Is(err, driver.ErrReleaseNotFound) {
+			return "", nil
+		}
+		return "", err
+	}
+
+	if !isReleaseManagedBy(clusterSummary, currentRelease) {
+		if refusal := getAdoptionRefusal(currentRelease, requestedChart); refusal != "" {
+			logger.V(logs.LogInfo).Info(refusal)
+			return refusal, nil
+		}
+	}
+
+	err = doUninstallRelease(clusterSummary, requestedChart, clientGetter, logger)
+	if err != nil && !errors.Is(err, driver.ErrReleaseNotFound) {
+		return "", err
+	}
+	return "", nil
+}
+
+// resetAdoptionPending records, in ClusterSummary Status, that helm release is installed, or
+// allowed to be adopted, by ClusterSummary.
+// No action in DryRun mode.
+func resetAdoptionPending(ctx context.Context, c client.Client, clusterSummary *configv1alpha1.ClusterSummary,
+	requestedChart *configv1alpha1.HelmChart) error {
+
+	// No-op in DryRun mode
+	if clusterSummary.Spec.ClusterProfileSpec.SyncMode == configv1alpha1.SyncModeDryRun {
+		return nil
+	}
+
+	summary := getHelmChartSummary(clusterSummary, requestedChart.ReleaseNamespace, requestedChart.ReleaseName)
+	if summary != nil && !summary.AdoptionPending {
+		return nil
+	}
+
+	return updateHelmChartSummary(ctx, c, clusterSummary, requestedChart,
+		func(summary *configv1alpha1.HelmChartSummary) {
+			summary.AdoptionPending = false
+		})
+}
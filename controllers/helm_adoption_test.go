@@ -0,0 +1,164 @@
+/*
+Copyright 2022-23. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers_test
+
+import (
+	"context"
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"helm.sh/helm/v3/pkg/chart"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	configv1alpha1 "github.com/projectsveltos/sveltos-manager/api/v1alpha1"
+	"github.com/projectsveltos/sveltos-manager/controllers"
+)
+
+var _ = Describe("Helm release adoption", func() {
+	var clusterSummary *configv1alpha1.ClusterSummary
+	var requestedChart *configv1alpha1.HelmChart
+	var currentRelease *controllers.ReleaseInfo
+
+	BeforeEach(func() {
+		clusterSummary = &configv1alpha1.ClusterSummary{
+			ObjectMeta: metav1.ObjectMeta{Namespace: randomString(), Name: randomString()},
+		}
+
+		requestedChart = &configv1alpha1.HelmChart{
+			RepositoryURL:    "https://charts.bitnami.com/bitnami",
+			RepositoryName:   "bitnami",
+			ChartName:        "bitnami/redis",
+			ChartVersion:     "17.7.1",
+			ReleaseName:      "redis",
+			ReleaseNamespace: "redis",
+		}
+
+		currentRelease = &controllers.ReleaseInfo{
+			ReleaseName:      requestedChart.ReleaseName,
+			ReleaseNamespace: requestedChart.ReleaseNamespace,
+			Chart:            "redis",
+			ChartVersion:     "17.0.0",
+		}
+	})
+
+	It("markChartAsManaged marks helm release as managed by ClusterSummary", func() {
+		chartRequested := &chart.Chart{
+			Metadata: &chart.Metadata{APIVersion: chart.APIVersionV2, Name: "redis", Version: "17.7.1"},
+		}
+
+		controllers.MarkChartAsManaged(chartRequested, clusterSummary)
+		Expect(chartRequested.Metadata.Annotations).To(HaveKeyWithValue(controllers.ReleaseClusterSummaryAnnotation,
+			fmt.Sprintf("%s/%s", clusterSummary.Namespace, clusterSummary.Name)))
+
+		currentRelease.ManagedBy = chartRequested.Metadata.Annotations[controllers.ReleaseClusterSummaryAnnotation]
+		Expect(controllers.IsReleaseManagedBy(clusterSummary, currentRelease)).To(BeTrue())
+
+		By("Considering a different ClusterSummary")
+		otherClusterSummary := &configv1alpha1.ClusterSummary{
+			ObjectMeta: metav1.ObjectMeta{Namespace: clusterSummary.Namespace, Name: randomString()},
+		}
+		Expect(controllers.IsReleaseManagedBy(otherClusterSummary, currentRelease)).To(BeFalse())
+
+		By("Considering an helm release not installed by Sveltos")
+		currentRelease.ManagedBy = ""
+		Expect(controllers.IsReleaseManagedBy(clusterSummary, currentRelease)).To(BeFalse())
+	})
+
+	It("isReleaseManagedBy considers helm releases installed by previous Sveltos versions", func() {
+		clusterSummary.Status.HelmReleaseSummaries = []configv1alpha1.HelmChartSummary{
+			{
+				ReleaseName:      currentRelease.ReleaseName,
+				ReleaseNamespace: currentRelease.ReleaseNamespace,
+				Status:           configv1alpha1.HelChartStatusManaging,
+				AdoptionPending:  true,
+			},
+		}
+
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(clusterSummary).Build()
+
+		By("Considering an helm release not installed, nor adopted, yet")
+		Expect(controllers.IsReleaseManagedBy(clusterSummary, currentRelease)).To(BeFalse())
+
+		Expect(controllers.ResetAdoptionPending(context.TODO(), c, clusterSummary, requestedChart)).To(Succeed())
+		Expect(clusterSummary.Status.HelmReleaseSummaries[0].AdoptionPending).To(BeFalse())
+		currentClusterSummary := &configv1alpha1.ClusterSummary{}
+		Expect(c.Get(context.TODO(),
+			types.NamespacedName{Namespace: clusterSummary.Namespace, Name: clusterSummary.Name},
+			currentClusterSummary)).To(Succeed())
+		Expect(currentClusterSummary.Status.HelmReleaseSummaries[0].AdoptionPending).To(BeFalse())
+
+		By("Considering an helm release listed in ClusterSummary Status with no annotation")
+		Expect(controllers.IsReleaseManagedBy(clusterSummary, currentRelease)).To(BeTrue())
+
+		By("Considering an helm release marked as managed by a different ClusterSummary")
+		currentRelease.ManagedBy = fmt.Sprintf("%s/%s", randomString(), randomString())
+		Expect(controllers.IsReleaseManagedBy(clusterSummary, currentRelease)).To(BeFalse())
+
+		By("Considering an helm release not listed in ClusterSummary Status")
+		currentRelease.ManagedBy = ""
+		currentRelease.ReleaseName = randomString()
+		Expect(controllers.IsReleaseManagedBy(clusterSummary, currentRelease)).To(BeFalse())
+	})
+
+	It("getAdoptionRefusal returns an empty string only if AdoptionPolicy allows adopting helm release", func() {
+		By("Using default policy (AdoptIfSameChart)")
+		Expect(controllers.GetAdoptionRefusal(currentRelease, requestedChart)).To(BeEmpty())
+
+		currentRelease.Chart = randomString()
+		refusal := controllers.GetAdoptionRefusal(currentRelease, requestedChart)
+		Expect(refusal).To(ContainSubstring("is not managed by Sveltos"))
+		Expect(refusal).To(ContainSubstring(string(configv1alpha1.HelmAdoptionPolicyAdoptIfSameChart)))
+
+		By("Using ForceAdopt policy")
+		requestedChart.AdoptionPolicy = configv1alpha1.HelmAdoptionPolicyForceAdopt
+		Expect(controllers.GetAdoptionRefusal(currentRelease, requestedChart)).To(BeEmpty())
+
+		By("Using Refuse policy")
+		requestedChart.AdoptionPolicy = configv1alpha1.HelmAdoptionPolicyRefuse
+		currentRelease.Chart = "redis"
+		currentRelease.ManagedBy = fmt.Sprintf("%s/%s", randomString(), randomString())
+		refusal = controllers.GetAdoptionRefusal(currentRelease, requestedChart)
+		Expect(refusal).To(ContainSubstring(fmt.Sprintf("is managed by ClusterSummary %s", currentRelease.ManagedBy)))
+		Expect(refusal).To(ContainSubstring(string(configv1alpha1.HelmAdoptionPolicyRefuse)))
+	})
+
+	It("getAdoptionRefusal compares chart name whatever form ChartName is in", func() {
+		charts := []configv1alpha1.HelmChart{
+			{RepositoryURL: "https://charts.bitnami.com/bitnami", RepositoryName: "bitnami", ChartName: "bitnami/redis"},
+			{RepositoryURL: "https://charts.bitnami.com/bitnami", RepositoryName: "bitnami", ChartName: "redis"},
+			{RepositoryURL: "oci://registry-1.docker.io/bitnamicharts", RepositoryName: "bitnamicharts", ChartName: "redis"},
+			{RepositoryURL: "oci://registry-1.docker.io/bitnamicharts/", RepositoryName: "bitnamicharts",
+				ChartName: "bitnamicharts/redis"},
+			{RepositoryURL: "oci://registry-1.docker.io/bitnamicharts", ChartName: "redis"},
+			{ChartName: "redis-17.7.1.tgz"},
+		}
+
+		for i := range charts {
+			charts[i].ChartVersion = "17.7.1"
+			currentRelease.Chart = "redis"
+			Expect(controllers.GetAdoptionRefusal(currentRelease, &charts[i])).To(BeEmpty())
+
+			currentRelease.Chart = "nginx"
+			refusal := controllers.GetAdoptionRefusal(currentRelease, &charts[i])
+			Expect(refusal).To(ContainSubstring("differs from redis"))
+		}
+	})
+})
@@ -0,0 +1,366 @@
+/*
+Copyright 2022-23. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/cli"
+	"helm.sh/helm/v3/pkg/repo"
+
+	logs "github.com/projectsveltos/libsveltos/lib/logsettings"
+)
+
+const (
+	// DefaultRepositoryIndexTTL is how long a downloaded repository index is considered
+	// up to date
+	DefaultRepositoryIndexTTL = 5 * time.Minute
+	// DefaultChartCacheMaxSize is the maximum size, in bytes, of all chart archives kept
+	// in the chart cache
+	DefaultChartCacheMaxSize = 1024 * 1024 * 1024
+
+	cacheTypeIndex = "index"
+	cacheTypeChart = "chart"
+
+	// chartsDir is the directory, in the repository cache, containing a directory per repository
+	// where chart archives are stored
+	chartsDir = "charts"
+)
+
+// repositoryIndexInfo contains information about the index last downloaded for a repository
+type repositoryIndexInfo struct {
+	// entryKey identifies the repository entry (see getRepositoryEntryKey) index was
+	// downloaded with
+	entryKey string
+	// downloaded is the time index was downloaded at
+	downloaded time.Time
+}
+
+// chartInfo contains information about a chart archive stored in the chart cache
+type chartInfo struct {
+	path     string
+	size     int64
+	lastUsed time.Time
+}
+
+// chartCache keeps track of downloaded repository indexes and chart archives.
+// - repository indexes are downloaded again only once older than indexTTL (or when repository
+// entry changes);
+// - chart archives are keyed by repository URL, chart name and version and stored in a per-repository
+// directory. When total size exceeds maxSize, least recently used archives are removed.
+// Locks are keyed by the file helm writes to: repository index is serialized per repository name,
+// chart download per chart name. Everything else is handled concurrently.
+type chartCache struct {
+	mu sync.Mutex
+	// locks contains per repository index and per chart archive lock
+	locks map[string]*sync.Mutex
+	// indexes contains, per repository name, info on the last index downloaded
+	indexes map[string]*repositoryIndexInfo
+	// charts contains, per repository URL/chart/version, info on the cached chart archive
+	charts map[string]*chartInfo
+	size   int64
+
+	maxSize  int64
+	indexTTL time.Duration
+}
+
+var (
+	helmChartCache = newChartCache(DefaultChartCacheMaxSize, DefaultRepositoryIndexTTL)
+
+	// repositoryFileMux serializes updates of the helm repository config file, which
+	// is shared by all repositories
+	repositoryFileMux sync.Mutex
+)
+
+func newChartCache(maxSize int64, indexTTL time.Duration) *chartCache {
+	return &chartCache{
+		locks:    make(map[string]*sync.Mutex),
+		indexes:  make(map[string]*repositoryIndexInfo),
+		charts:   make(map[string]*chartInfo),
+		maxSize:  maxSize,
+		indexTTL: indexTTL,
+	}
+}
+
+// SetChartCacheOptions sets the maximum size, in bytes, of chart archives kept in the chart
+// cache and how long a downloaded repository index is considered up to date
+func SetChartCacheOptions(maxSize int64, indexTTL time.Duration) {
+	helmChartCache.mu.Lock()
+	defer helmChartCache.mu.Unlock()
+
+	helmChartCache.maxSize = maxSize
+	helmChartCache.indexTTL = indexTTL
+}
+
+func (c *chartCache) getLock(key string) *sync.Mutex {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	lock, ok := c.locks[key]
+	if !ok {
+		lock = &sync.Mutex{}
+		c.locks[key] = lock
+	}
+	return lock
+}
+
+// getRepositoryLock returns the lock serializing access to the repository index.
+// Index file is named after the repository name, so lock is keyed by repository name.
+func (c *chartCache) getRepositoryLock(repositoryName string) *sync.Mutex {
+	return c.getLock(fmt.Sprintf("index:%s", repositoryName))
+}
+
+// getChartLock returns the lock serializing download of the chart archive.
+// Helm downloads every archive in the repository cache, named after chart name and version
+// only, so lock is keyed by chart name whatever the repository and the version (which might
+// not be known before download) are.
+func (c *chartCache) getChartLock(chartName string) *sync.Mutex {
+	return c.getLock(fmt.Sprintf("chart:%s", filepath.Base(chartName)))
+}
+
+func getRepositoryIndexKey(entry *repo.Entry) string {
+	return entry.Name
+}
+
+// isIndexUpToDate returns true if index for repository entry was downloaded less than
+// indexTTL ago and entry has not changed since
+func (c *chartCache) isIndexUpToDate(entry *repo.Entry) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	info, ok := c.indexes[getRepositoryIndexKey(entry)]
+	if !ok {
+		return false
+	}
+	return info.entryKey == getRepositoryEntryKey(entry) && time.Since(info.downloaded) < c.indexTTL
+}
+
+// isEntryAdded returns true if repository entry was already added (with current settings)
+func (c *chartCache) isEntryAdded(entry *repo.Entry) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	info, ok := c.indexes[getRepositoryIndexKey(entry)]
+	return ok && info.entryKey == getRepositoryEntryKey(entry)
+}
+
+// indexDownloaded records that index for repository entry was just downloaded
+func (c *chartCache) indexDownloaded(entry *repo.Entry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.indexes[getRepositoryIndexKey(entry)] = &repositoryIndexInfo{
+		entryKey:   getRepositoryEntryKey(entry),
+		downloaded: time.Now(),
+	}
+}
+
+// ensureRepository makes sure repository described by entry is added and its index is up to date.
+// Repository is added again every time any entry setting (for instance credentials) changes.
+// Index is downloaded again only once older than indexTTL.
+func (c *chartCache) ensureRepository(settings *cli.EnvSettings, entry *repo.Entry, logger logr.Logger) error {
+	lock := c.getRepositoryLock(entry.Name)
+	lock.Lock()
+	defer lock.Unlock()
+
+	if c.isIndexUpToDate(entry) {
+		logger.V(logs.LogDebug).Info("repository index is up to date", "repo", entry.URL)
+		helmCacheHits.WithLabelValues(cacheTypeIndex).Inc()
+		return nil
+	}
+	helmCacheMisses.WithLabelValues(cacheTypeIndex).Inc()
+
+	var err error
+	if c.isEntryAdded(entry) {
+		err = repoUpdate(settings, entry, logger)
+	} else {
+		err = repoAdd(settings, entry, logger)
+	}
+	if err != nil {
+		return err
+	}
+
+	c.indexDownloaded(entry)
+	return nil
+}
+
+func getChartCacheKey(repositoryURL, chartName, chartVersion string) string {
+	return fmt.Sprintf("%s:%s:%s", repositoryURL, chartName, chartVersion)
+}
+
+// getChart returns the path of the cached chart archive, if any
+func (c *chartCache) getChart(key string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	info, ok := c.charts[key]
+	if !ok {
+		return "", false
+	}
+
+	// Archive might have been removed from outside (for instance by helm itself)
+	if _, err := os.Stat(info.path); err != nil {
+		c.size -= info.size
+		delete(c.charts, key)
+		return "", false
+	}
+
+	info.lastUsed = time.Now()
+	return info.path, true
+}
+
+// addChart adds chart archive to the cache. If cache size exceeds maxSize, least recently
+// used archives (never the one just added) are removed.
+func (c *chartCache) addChart(key, path string, size int64, logger logr.Logger) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if info, ok := c.charts[key]; ok {
+		c.size -= info.size
+	}
+	c.charts[key] = &chartInfo{path: path, size: size, lastUsed: time.Now()}
+	c.size += size
+
+	for c.size > c.maxSize {
+		oldestKey := ""
+		var oldest *chartInfo
+		for k, info := range c.charts {
+			if k == key {
+				continue
+			}
+			if oldest == nil || info.lastUsed.Before(oldest.lastUsed) {
+				oldestKey = k
+				oldest = info
+			}
+		}
+		if oldest == nil {
+			break
+		}
+
+		logger.V(logs.LogDebug).Info(fmt.Sprintf("evicting chart archive %s", oldest.path))
+		if err := os.Remove(oldest.path); err != nil && !os.IsNotExist(err) {
+			logger.V(logs.LogInfo).Info(fmt.Sprintf("failed to remove chart archive %s: %v", oldest.path, err))
+		}
+		// Provenance file, if any, was downloaded alongside the archive
+		if err := os.Remove(oldest.path + provenanceExtension); err != nil && !os.IsNotExist(err) {
+			logger.V(logs.LogInfo).Info(fmt.Sprintf("failed to remove provenance file %s%s: %v",
+				oldest.path, provenanceExtension, err))
+		}
+		c.size -= oldest.size
+		delete(c.charts, oldestKey)
+	}
+
+	helmChartCacheSize.Set(float64(c.size))
+}
+
+// getChartRepositoryDir returns the directory chart archives downloaded from repositoryURL
+// are stored in
+func getChartRepositoryDir(settings *cli.EnvSettings, repositoryURL string) string {
+	return filepath.Join(settings.RepositoryCache, chartsDir,
+		fmt.Sprintf("%x", sha256.Sum256([]byte(repositoryURL))))
+}
+
+// moveChartToRepositoryDir moves chart archive, along with its provenance file if any,
+// to dir and returns its new path
+func moveChartToRepositoryDir(chartPath, dir string) (string, error) {
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return "", err
+	}
+
+	newPath := filepath.Join(dir, filepath.Base(chartPath))
+	if err := os.Rename(chartPath, newPath); err != nil {
+		return "", err
+	}
+
+	// A provenance file left from a previous download does not belong to this archive
+	err := os.Rename(chartPath+provenanceExtension, newPath+provenanceExtension)
+	if os.IsNotExist(err) {
+		err = os.Remove(newPath + provenanceExtension)
+	}
+	if err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+
+	return newPath, nil
+}
+
+// locateChart returns the path of the chart archive. Chart is downloaded only if not already
+// in the cache.
+// Archives downloaded from a repository are stored in a directory of their own (see
+// getChartRepositoryDir), so repositories serving same chart name and version never share
+// an archive.
+// Charts with no version (latest version is used) and local charts are never cached.
+// When chartPathOptions requires verification, chart provenance is verified, even for cached
+// chart archives.
+func (c *chartCache) locateChart(chartPathOptions *action.ChartPathOptions, repositoryURL, chartName string,
+	settings *cli.EnvSettings, logger logr.Logger) (string, error) {
+
+	if filepath.IsAbs(chartName) {
+		return locateAndVerifyChart(chartPathOptions, chartName, settings)
+	}
+
+	lock := c.getChartLock(chartName)
+	lock.Lock()
+	defer lock.Unlock()
+
+	cacheable := chartPathOptions.Version != ""
+	key := getChartCacheKey(repositoryURL, chartName, chartPathOptions.Version)
+	// A cached chart archive, whose provenance file was not downloaded along with it, is downloaded
+	// again when verification is required
+	if path, ok := c.getChart(key); cacheable && ok && (!chartPathOptions.Verify || hasProvenanceFile(path)) {
+		logger.V(logs.LogDebug).Info("chart found in cache")
+		helmCacheHits.WithLabelValues(cacheTypeChart).Inc()
+		if chartPathOptions.Verify {
+			if err := verifyChart(path, chartPathOptions.Keyring); err != nil {
+				return "", err
+			}
+		}
+		return path, nil
+	}
+	helmCacheMisses.WithLabelValues(cacheTypeChart).Inc()
+
+	path, err := locateAndVerifyChart(chartPathOptions, chartName, settings)
+	if err != nil {
+		return "", err
+	}
+
+	fileInfo, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	// Only archives downloaded in the repository cache are moved and tracked
+	if fileInfo.IsDir() || filepath.Dir(path) != filepath.Clean(settings.RepositoryCache) {
+		return path, nil
+	}
+
+	path, err = moveChartToRepositoryDir(path, getChartRepositoryDir(settings, repositoryURL))
+	if err != nil {
+		return "", err
+	}
+
+	if cacheable {
+		c.addChart(key, path, fileInfo.Size(), logger)
+	}
+	return path, nil
+}
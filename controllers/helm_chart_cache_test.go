@@ -0,0 +1,215 @@
+/*
+Copyright 2022-23. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/cli"
+	"helm.sh/helm/v3/pkg/provenance"
+	"helm.sh/helm/v3/pkg/repo"
+	"k8s.io/klog/v2/klogr"
+
+	"github.com/projectsveltos/sveltos-manager/controllers"
+)
+
+func createChartArchive(dir string, size int) string {
+	path := filepath.Join(dir, randomString()+".tgz")
+	Expect(os.WriteFile(path, make([]byte, size), 0600)).To(Succeed())
+	return path
+}
+
+// startChartRepository serves, from a directory in dir, a repository containing chart chartName.
+// Chart description is set to description.
+func startChartRepository(dir, chartName, description string) *httptest.Server {
+	repositoryDir := filepath.Join(dir, randomString())
+	Expect(os.Mkdir(repositoryDir, 0700)).To(Succeed())
+	server := httptest.NewServer(http.FileServer(http.Dir(repositoryDir)))
+
+	ch := &chart.Chart{
+		Metadata: &chart.Metadata{APIVersion: chart.APIVersionV2, Name: chartName, Version: provenanceChartVersion,
+			Description: description},
+	}
+	path, err := chartutil.Save(ch, repositoryDir)
+	Expect(err).To(BeNil())
+
+	digest, err := provenance.DigestFile(path)
+	Expect(err).To(BeNil())
+	index := repo.NewIndexFile()
+	Expect(index.MustAdd(ch.Metadata, filepath.Base(path), server.URL, digest)).To(Succeed())
+	Expect(index.WriteFile(filepath.Join(repositoryDir, "index.yaml"), 0600)).To(Succeed())
+
+	return server
+}
+
+var _ = Describe("Helm chart cache", func() {
+	var dir string
+
+	BeforeEach(func() {
+		var err error
+		dir, err = os.MkdirTemp("", randomString())
+		Expect(err).To(BeNil())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("getRepositoryLock returns same lock for same repository", func() {
+		cache := controllers.NewChartCache(controllers.DefaultChartCacheMaxSize, time.Minute)
+
+		lock := controllers.GetRepositoryLock(cache, "bitnami")
+		Expect(controllers.GetRepositoryLock(cache, "bitnami")).To(BeIdenticalTo(lock))
+		Expect(controllers.GetRepositoryLock(cache, "kyverno")).ToNot(BeIdenticalTo(lock))
+	})
+
+	It("getChartLock returns same lock for same chart name whatever the repository", func() {
+		cache := controllers.NewChartCache(controllers.DefaultChartCacheMaxSize, time.Minute)
+
+		lock := controllers.GetChartLock(cache, "bitnami/redis")
+		Expect(controllers.GetChartLock(cache, "oci://registry-1.docker.io/bitnamicharts/redis")).To(BeIdenticalTo(lock))
+		Expect(controllers.GetChartLock(cache, "bitnami/nginx")).ToNot(BeIdenticalTo(lock))
+		Expect(controllers.GetRepositoryLock(cache, "redis")).ToNot(BeIdenticalTo(lock))
+	})
+
+	It("locateChart keeps archives of repositories serving same chart name and version apart", func() {
+		const chartName = "shared"
+		first := startChartRepository(dir, chartName, "first")
+		defer first.Close()
+		second := startChartRepository(dir, chartName, "second")
+		defer second.Close()
+
+		helmDir := filepath.Join(dir, "helm")
+		GinkgoT().Setenv("HELM_CACHE_HOME", helmDir)
+		GinkgoT().Setenv("HELM_CONFIG_HOME", helmDir)
+		settings := cli.New()
+
+		cache := controllers.NewChartCache(controllers.DefaultChartCacheMaxSize, time.Minute)
+		locate := func(repositoryURL string) string {
+			chartPathOptions := &action.ChartPathOptions{RepoURL: repositoryURL, Version: provenanceChartVersion}
+			path, err := controllers.LocateChart(cache, chartPathOptions, repositoryURL, chartName, settings, klogr.New())
+			Expect(err).To(BeNil())
+			return path
+		}
+		getDescription := func(path string) string {
+			ch, err := loader.Load(path)
+			Expect(err).To(BeNil())
+			return ch.Metadata.Description
+		}
+
+		firstPath := locate(first.URL)
+		secondPath := locate(second.URL)
+		Expect(firstPath).ToNot(Equal(secondPath))
+		Expect(getDescription(firstPath)).To(Equal("first"))
+		Expect(getDescription(secondPath)).To(Equal("second"))
+
+		By("Cached archives still belong to their own repository")
+		Expect(locate(first.URL)).To(Equal(firstPath))
+		Expect(getDescription(firstPath)).To(Equal("first"))
+		Expect(locate(second.URL)).To(Equal(secondPath))
+		Expect(getDescription(secondPath)).To(Equal("second"))
+
+		By("Removing one archive does not affect the other")
+		Expect(os.Remove(secondPath)).To(Succeed())
+		Expect(getDescription(locate(first.URL))).To(Equal("first"))
+		Expect(getDescription(locate(second.URL))).To(Equal("second"))
+	})
+
+	It("isIndexUpToDate returns false once index is older than TTL or entry changes", func() {
+		cache := controllers.NewChartCache(controllers.DefaultChartCacheMaxSize, time.Minute)
+
+		entry := &repo.Entry{Name: "bitnami", URL: "https://charts.bitnami.com/bitnami"}
+		Expect(controllers.IsIndexUpToDate(cache, entry)).To(BeFalse())
+		Expect(controllers.IsEntryAdded(cache, entry)).To(BeFalse())
+
+		controllers.IndexDownloaded(cache, entry)
+		Expect(controllers.IsIndexUpToDate(cache, entry)).To(BeTrue())
+		Expect(controllers.IsEntryAdded(cache, entry)).To(BeTrue())
+
+		By("Changing entry credentials")
+		entry.Username = randomString()
+		Expect(controllers.IsIndexUpToDate(cache, entry)).To(BeFalse())
+		Expect(controllers.IsEntryAdded(cache, entry)).To(BeFalse())
+
+		By("Using an expired TTL")
+		cache = controllers.NewChartCache(controllers.DefaultChartCacheMaxSize, 0)
+		controllers.IndexDownloaded(cache, entry)
+		Expect(controllers.IsIndexUpToDate(cache, entry)).To(BeFalse())
+		Expect(controllers.IsEntryAdded(cache, entry)).To(BeTrue())
+	})
+
+	It("getChart returns cached chart and forgets archives removed from disk", func() {
+		cache := controllers.NewChartCache(controllers.DefaultChartCacheMaxSize, time.Minute)
+
+		key := controllers.GetChartCacheKey("https://charts.bitnami.com/bitnami", "bitnami/redis", "17.0.0")
+		_, ok := controllers.GetChartFromCache(cache, key)
+		Expect(ok).To(BeFalse())
+
+		path := createChartArchive(dir, 10)
+		controllers.AddChartToCache(cache, key, path, 10, klogr.New())
+		cachedPath, ok := controllers.GetChartFromCache(cache, key)
+		Expect(ok).To(BeTrue())
+		Expect(cachedPath).To(Equal(path))
+
+		Expect(os.Remove(path)).To(Succeed())
+		_, ok = controllers.GetChartFromCache(cache, key)
+		Expect(ok).To(BeFalse())
+	})
+
+	It("addChart evicts least recently used charts when size exceeds max size", func() {
+		const size = 10
+		cache := controllers.NewChartCache(2*size, time.Minute)
+
+		key1 := controllers.GetChartCacheKey("https://charts.bitnami.com/bitnami", "bitnami/redis", "17.0.0")
+		path1 := createChartArchive(dir, size)
+		controllers.AddChartToCache(cache, key1, path1, size, klogr.New())
+
+		key2 := controllers.GetChartCacheKey("https://charts.bitnami.com/bitnami", "bitnami/redis", "17.0.1")
+		path2 := createChartArchive(dir, size)
+		controllers.AddChartToCache(cache, key2, path2, size, klogr.New())
+
+		// Use first chart so second one is the least recently used
+		time.Sleep(time.Millisecond)
+		_, ok := controllers.GetChartFromCache(cache, key1)
+		Expect(ok).To(BeTrue())
+
+		key3 := controllers.GetChartCacheKey("https://kyverno.github.io/kyverno/", "kyverno/kyverno", "v2.6.0")
+		path3 := createChartArchive(dir, size)
+		controllers.AddChartToCache(cache, key3, path3, size, klogr.New())
+
+		_, ok = controllers.GetChartFromCache(cache, key2)
+		Expect(ok).To(BeFalse())
+		_, err := os.Stat(path2)
+		Expect(os.IsNotExist(err)).To(BeTrue())
+
+		_, ok = controllers.GetChartFromCache(cache, key1)
+		Expect(ok).To(BeTrue())
+		_, ok = controllers.GetChartFromCache(cache, key3)
+		Expect(ok).To(BeTrue())
+	})
+})
@@ -0,0 +1,197 @@
+/*
+Copyright 2022-23. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/go-logr/logr"
+	"helm.sh/helm/v3/pkg/cli"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	libsveltosv1alpha1 "github.com/projectsveltos/libsveltos/api/v1alpha1"
+	logs "github.com/projectsveltos/libsveltos/lib/logsettings"
+)
+
+// restClientGetter is an in-memory genericclioptions.RESTClientGetter used by helm to access
+// a managed cluster. No kubeconfig file is ever written.
+// It is safe for concurrent use: rest.Config is never modified (a copy is returned) while
+// discovery client and REST mapper are built once and shared.
+type restClientGetter struct {
+	restConfig      *rest.Config
+	discoveryClient discovery.CachedDiscoveryInterface
+	restMapper      meta.ResettableRESTMapper
+	namespace       string
+}
+
+var _ genericclioptions.RESTClientGetter = &restClientGetter{}
+
+// newRESTClientGetter returns a restClientGetter for the cluster restConfig gives access to
+func newRESTClientGetter(restConfig *rest.Config) (*restClientGetter, error) {
+	dc, err := discovery.NewDiscoveryClientForConfig(restConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	discoveryClient := memory.NewMemCacheClient(dc)
+	return &restClientGetter{
+		restConfig:      restConfig,
+		discoveryClient: discoveryClient,
+		restMapper:      restmapper.NewDeferredDiscoveryRESTMapper(discoveryClient),
+	}, nil
+}
+
+// withNamespace returns a restClientGetter, sharing discovery client and REST mapper,
+// whose default namespace is namespace
+func (g *restClientGetter) withNamespace(namespace string) *restClientGetter {
+	return &restClientGetter{
+		restConfig:      g.restConfig,
+		discoveryClient: g.discoveryClient,
+		restMapper:      g.restMapper,
+		namespace:       namespace,
+	}
+}
+
+func (g *restClientGetter) ToRESTConfig() (*rest.Config, error) {
+	return rest.CopyConfig(g.restConfig), nil
+}
+
+func (g *restClientGetter) ToDiscoveryClient() (discovery.CachedDiscoveryInterface, error) {
+	return g.discoveryClient, nil
+}
+
+func (g *restClientGetter) ToRESTMapper() (meta.RESTMapper, error) {
+	return g.restMapper, nil
+}
+
+func (g *restClientGetter) ToRawKubeConfigLoader() clientcmd.ClientConfig {
+	return &restClientConfig{getter: g}
+}
+
+// restClientConfig is the clientcmd.ClientConfig returned by restClientGetter.
+// Helm uses it only to get the default namespace.
+type restClientConfig struct {
+	getter *restClientGetter
+}
+
+func (c *restClientConfig) RawConfig() (clientcmdapi.Config, error) {
+	return clientcmdapi.Config{}, nil
+}
+
+func (c *restClientConfig) ClientConfig() (*rest.Config, error) {
+	return c.getter.ToRESTConfig()
+}
+
+func (c *restClientConfig) Namespace() (string, bool, error) {
+	if c.getter.namespace == "" {
+		return metav1.NamespaceDefault, false, nil
+	}
+	return c.getter.namespace, true, nil
+}
+
+func (c *restClientConfig) ConfigAccess() clientcmd.ConfigAccess {
+	// Kubeconfig is only kept in memory, there is no file to access
+	return nil
+}
+
+type restClientGetterEntry struct {
+	kubeconfig []byte
+	getter     *restClientGetter
+}
+
+var (
+	// restClientGetters contains, per cluster and admin, the restClientGetter last built
+	// along with the kubeconfig used to build it
+	restClientGetters   = make(map[string]*restClientGetterEntry)
+	restClientGettersMu sync.Mutex
+)
+
+func getRestClientGetterKey(clusterNamespace, clusterName, admin string,
+	clusterType libsveltosv1alpha1.ClusterType) string {
+
+	return fmt.Sprintf("%s:%s/%s:%s", clusterType, clusterNamespace, clusterName, admin)
+}
+
+// getRESTClientGetter returns the restClientGetter helm uses to access the cluster as admin.
+// restClientGetters are cached per cluster and admin. Cached instance is replaced as soon as
+// the kubeconfig, stored in the management cluster, changes.
+func getRESTClientGetter(ctx context.Context, c client.Client, clusterNamespace, clusterName, admin string,
+	clusterType libsveltosv1alpha1.ClusterType, logger logr.Logger) (*restClientGetter, error) {
+
+	kubeconfigContent, err := getSecretData(ctx, c, clusterNamespace, clusterName, admin, clusterType, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	key := getRestClientGetterKey(clusterNamespace, clusterName, admin, clusterType)
+
+	restClientGettersMu.Lock()
+	defer restClientGettersMu.Unlock()
+
+	if entry, ok := restClientGetters[key]; ok && bytes.Equal(entry.kubeconfig, kubeconfigContent) {
+		return entry.getter, nil
+	}
+
+	logger.V(logs.LogDebug).Info("building helm rest client getter")
+	restConfig, err := clientcmd.RESTConfigFromKubeConfig(kubeconfigContent)
+	if err != nil {
+		return nil, err
+	}
+
+	getter, err := newRESTClientGetter(restConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	restClientGetters[key] = &restClientGetterEntry{kubeconfig: kubeconfigContent, getter: getter}
+	return getter, nil
+}
+
+// removeRESTClientGetters removes all cached restClientGetters for a cluster (any admin).
+// Those are built again, if needed, next time helm accesses the cluster.
+func removeRESTClientGetters(clusterNamespace, clusterName string, clusterType libsveltosv1alpha1.ClusterType) {
+	restClientGettersMu.Lock()
+	defer restClientGettersMu.Unlock()
+
+	prefix := getRestClientGetterKey(clusterNamespace, clusterName, "", clusterType)
+	for key := range restClientGetters {
+		if strings.HasPrefix(key, prefix) {
+			delete(restClientGetters, key)
+		}
+	}
+}
+
+// getSettings returns helm settings. A new instance is returned on every call so that
+// concurrent deployments never share (nor modify) the same settings.
+func getSettings() *cli.EnvSettings {
+	settings := cli.New()
+	settings.Debug = true
+	return settings
+}
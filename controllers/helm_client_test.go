@@ -0,0 +1,155 @@
+/*
+Copyright 2022-23. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers_test
+
+import (
+	"context"
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+	"k8s.io/klog/v2/klogr"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	libsveltosv1alpha1 "github.com/projectsveltos/libsveltos/api/v1alpha1"
+	"github.com/projectsveltos/sveltos-manager/controllers"
+)
+
+const (
+	helmClientKubeconfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: %s
+  cluster:
+    server: %s
+contexts:
+- name: %s
+  context:
+    cluster: %s
+    user: %s
+current-context: %s
+users:
+- name: %s
+  user:
+    token: %s
+`
+)
+
+func getKubeconfig(server string) []byte {
+	name := randomString()
+	return []byte(fmt.Sprintf(helmClientKubeconfig, name, server, name, name, name, name, name, randomString()))
+}
+
+var _ = Describe("Helm client", func() {
+	var cluster *clusterv1.Cluster
+	var secret *corev1.Secret
+	var clusterNamespace string
+	var clusterName string
+
+	BeforeEach(func() {
+		clusterNamespace = randomString()
+		clusterName = randomString()
+
+		cluster = &clusterv1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: clusterNamespace,
+				Name:      clusterName,
+			},
+		}
+
+		secret = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: clusterNamespace,
+				Name:      clusterName + "-kubeconfig",
+			},
+			Data: map[string][]byte{
+				"data": getKubeconfig("https://192.168.1.10:6443"),
+			},
+		}
+	})
+
+	It("getRESTClientGetter caches getters per cluster and rebuilds those when kubeconfig changes", func() {
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(cluster, secret).Build()
+
+		getter, err := controllers.GetRESTClientGetter(context.TODO(), c, clusterNamespace, clusterName, "",
+			libsveltosv1alpha1.ClusterTypeCapi, klogr.New())
+		Expect(err).To(BeNil())
+
+		restConfig, err := getter.ToRESTConfig()
+		Expect(err).To(BeNil())
+		Expect(restConfig.Host).To(Equal("https://192.168.1.10:6443"))
+
+		cachedGetter, err := controllers.GetRESTClientGetter(context.TODO(), c, clusterNamespace, clusterName, "",
+			libsveltosv1alpha1.ClusterTypeCapi, klogr.New())
+		Expect(err).To(BeNil())
+		Expect(cachedGetter).To(BeIdenticalTo(getter))
+
+		By("Changing kubeconfig")
+		secret.Data["data"] = getKubeconfig("https://192.168.1.11:6443")
+		Expect(c.Update(context.TODO(), secret)).To(Succeed())
+
+		newGetter, err := controllers.GetRESTClientGetter(context.TODO(), c, clusterNamespace, clusterName, "",
+			libsveltosv1alpha1.ClusterTypeCapi, klogr.New())
+		Expect(err).To(BeNil())
+		Expect(newGetter).ToNot(BeIdenticalTo(getter))
+		restConfig, err = newGetter.ToRESTConfig()
+		Expect(err).To(BeNil())
+		Expect(restConfig.Host).To(Equal("https://192.168.1.11:6443"))
+
+		By("Removing cached getters for the cluster")
+		controllers.RemoveRESTClientGetters(clusterNamespace, clusterName, libsveltosv1alpha1.ClusterTypeCapi)
+		cachedGetter, err = controllers.GetRESTClientGetter(context.TODO(), c, clusterNamespace, clusterName, "",
+			libsveltosv1alpha1.ClusterTypeCapi, klogr.New())
+		Expect(err).To(BeNil())
+		Expect(cachedGetter).ToNot(BeIdenticalTo(newGetter))
+	})
+
+	It("restClientGetter never returns a shared rest.Config and reports namespace", func() {
+		getter, err := controllers.NewRESTClientGetter(&rest.Config{Host: "https://192.168.1.10:6443"})
+		Expect(err).To(BeNil())
+
+		restConfig, err := getter.ToRESTConfig()
+		Expect(err).To(BeNil())
+		restConfig.Host = "https://192.168.1.11:6443"
+
+		restConfig, err = getter.ToRESTConfig()
+		Expect(err).To(BeNil())
+		Expect(restConfig.Host).To(Equal("https://192.168.1.10:6443"))
+
+		namespace, overridden, err := getter.ToRawKubeConfigLoader().Namespace()
+		Expect(err).To(BeNil())
+		Expect(overridden).To(BeFalse())
+		Expect(namespace).To(Equal(metav1.NamespaceDefault))
+
+		releaseNamespace := randomString()
+		namespace, overridden, err = controllers.WithNamespace(getter, releaseNamespace).ToRawKubeConfigLoader().Namespace()
+		Expect(err).To(BeNil())
+		Expect(overridden).To(BeTrue())
+		Expect(namespace).To(Equal(releaseNamespace))
+	})
+
+	It("getSettings returns a new instance every time", func() {
+		settings := controllers.GetSettings()
+		Expect(settings.Debug).To(BeTrue())
+		Expect(controllers.GetSettings()).ToNot(BeIdenticalTo(settings))
+	})
+})
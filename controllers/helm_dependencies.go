@@ -0,0 +1,158 @@
+/*
+Copyright 2022-23. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-logr/logr"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/cli"
+	"helm.sh/helm/v3/pkg/downloader"
+	"helm.sh/helm/v3/pkg/getter"
+	"helm.sh/helm/v3/pkg/registry"
+	"helm.sh/helm/v3/pkg/repo"
+
+	logs "github.com/projectsveltos/libsveltos/lib/logsettings"
+)
+
+// resolveChartDependencies returns the chart with all dependencies declared in Chart.yaml.
+// If any dependency is missing, dependencies are downloaded (repositories are resolved through
+// the configured helm repositories) and chart is loaded again.
+// Chart archive is never modified: it is expanded in a temporary directory which is then updated.
+// Returns an error if any dependency cannot be resolved.
+func resolveChartDependencies(chartRequested *chart.Chart, chartPath, keyring string,
+	settings *cli.EnvSettings, registryClient *registry.Client, logger logr.Logger) (*chart.Chart, error) {
+
+	req := chartRequested.Metadata.Dependencies
+	if req == nil {
+		return chartRequested, nil
+	}
+
+	// If CheckDependencies returns an error, we have unfulfilled dependencies.
+	if err := action.CheckDependencies(chartRequested, req); err == nil {
+		return chartRequested, nil
+	}
+
+	logger.V(logs.LogDebug).Info("building missing chart dependencies")
+
+	chartDir := chartPath
+	fileInfo, err := os.Stat(chartPath)
+	if err != nil {
+		return nil, err
+	}
+	if !fileInfo.IsDir() {
+		var tmpDir string
+		tmpDir, err = os.MkdirTemp("", "chart-dependencies")
+		if err != nil {
+			return nil, err
+		}
+		defer os.RemoveAll(tmpDir)
+
+		err = chartutil.ExpandFile(tmpDir, chartPath)
+		if err != nil {
+			return nil, err
+		}
+		chartDir = filepath.Join(tmpDir, chartRequested.Name())
+	}
+
+	// Repositories are prepared through the chart cache, so their indexes are not updated
+	// by the downloader manager.
+	err = prepareDependencyRepositories(req, settings, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	man := &downloader.Manager{
+		Out:              io.Discard,
+		ChartPath:        chartDir,
+		Keyring:          keyring,
+		SkipUpdate:       true,
+		Getters:          getter.All(settings),
+		RegistryClient:   registryClient,
+		RepositoryConfig: settings.RepositoryConfig,
+		RepositoryCache:  settings.RepositoryCache,
+	}
+	err = man.Update()
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve dependencies of chart %s: %w", chartRequested.Name(), err)
+	}
+
+	chartRequested, err = loader.Load(chartDir)
+	if err != nil {
+		return nil, err
+	}
+
+	err = action.CheckDependencies(chartRequested, req)
+	if err != nil {
+		return nil, fmt.Errorf("chart %s has unresolved dependencies: %w", chartRequested.Name(), err)
+	}
+
+	return chartRequested, nil
+}
+
+// prepareDependencyRepositories makes sure every helm repository chart dependencies are stored in is
+// added and its index is up to date. Dependencies stored in OCI registries or local directories and
+// dependencies referencing a repository by name are skipped.
+func prepareDependencyRepositories(dependencies []*chart.Dependency, settings *cli.EnvSettings,
+	logger logr.Logger) error {
+
+	for i := range dependencies {
+		repositoryURL := dependencies[i].Repository
+		if !strings.HasPrefix(repositoryURL, "http://") && !strings.HasPrefix(repositoryURL, "https://") {
+			continue
+		}
+
+		entry := getDependencyRepositoryEntry(settings, repositoryURL)
+		err := helmChartCache.ensureRepository(settings, entry, logger)
+		if err != nil {
+			return fmt.Errorf("failed to prepare repository %s of dependency %s: %w",
+				repositoryURL, dependencies[i].Name, err)
+		}
+	}
+
+	return nil
+}
+
+// getDependencyRepositoryEntry returns the entry for the repository a dependency is stored in.
+// If repository was already added (for instance because an helm chart is stored there), same entry,
+// with its credentials, is used. Otherwise entry is named after repository URL.
+func getDependencyRepositoryEntry(settings *cli.EnvSettings, repositoryURL string) *repo.Entry {
+	repositoryFileMux.Lock()
+	f, err := repo.LoadFile(settings.RepositoryConfig)
+	repositoryFileMux.Unlock()
+
+	if err == nil {
+		for i := range f.Repositories {
+			if strings.TrimSuffix(f.Repositories[i].URL, "/") == strings.TrimSuffix(repositoryURL, "/") {
+				entry := *f.Repositories[i]
+				return &entry
+			}
+		}
+	}
+
+	h := sha256.Sum256([]byte(repositoryURL))
+	return &repo.Entry{Name: fmt.Sprintf("dependency-%x", h[:8]), URL: repositoryURL}
+}
@@ -0,0 +1,150 @@
+/*
+Copyright 2022-23. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/cli"
+	"helm.sh/helm/v3/pkg/repo"
+	"k8s.io/klog/v2/klogr"
+
+	"github.com/projectsveltos/sveltos-manager/controllers"
+)
+
+// saveChart saves a chart directory named after the chart in dir. Returns chart directory
+func saveChart(dir, chartName string, dependencies []*chart.Dependency) string {
+	ch := &chart.Chart{
+		Metadata: &chart.Metadata{
+			APIVersion:   chart.APIVersionV2,
+			Name:         chartName,
+			Version:      "0.1.0",
+			Dependencies: dependencies,
+		},
+	}
+	Expect(chartutil.SaveDir(ch, dir)).To(Succeed())
+	return filepath.Join(dir, chartName)
+}
+
+var _ = Describe("Helm chart dependencies", func() {
+	var workspace string
+	var settings *cli.EnvSettings
+
+	BeforeEach(func() {
+		var err error
+		workspace, err = os.MkdirTemp("", randomString())
+		Expect(err).To(BeNil())
+
+		settings = cli.New()
+		settings.RepositoryConfig = filepath.Join(workspace, "repositories.yaml")
+		settings.RepositoryCache = filepath.Join(workspace, "cache")
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(workspace)
+	})
+
+	It("resolveChartDependencies returns chart with no dependencies as it is", func() {
+		chartDir := saveChart(workspace, randomString(), nil)
+		chartRequested, err := loader.Load(chartDir)
+		Expect(err).To(BeNil())
+
+		resolvedChart, err := controllers.ResolveChartDependencies(chartRequested, chartDir, "", settings, nil, klogr.New())
+		Expect(err).To(BeNil())
+		Expect(resolvedChart).To(BeIdenticalTo(chartRequested))
+	})
+
+	It("resolveChartDependencies builds missing dependencies of a chart archive", func() {
+		dependencyName := randomString()
+		dependencyDir := saveChart(workspace, dependencyName, nil)
+
+		ch, err := loader.Load(saveChart(workspace, randomString(), []*chart.Dependency{
+			{Name: dependencyName, Version: "0.1.0", Repository: "file://" + dependencyDir},
+		}))
+		Expect(err).To(BeNil())
+		chartPath, err := chartutil.Save(ch, workspace)
+		Expect(err).To(BeNil())
+
+		chartRequested, err := loader.Load(chartPath)
+		Expect(err).To(BeNil())
+		Expect(chartRequested.Dependencies()).To(BeEmpty())
+
+		resolvedChart, err := controllers.ResolveChartDependencies(chartRequested, chartPath, "", settings, nil, klogr.New())
+		Expect(err).To(BeNil())
+		Expect(len(resolvedChart.Dependencies())).To(Equal(1))
+		Expect(resolvedChart.Dependencies()[0].Name()).To(Equal(dependencyName))
+
+		By("Verifying chart archive is not modified")
+		chartRequested, err = loader.Load(chartPath)
+		Expect(err).To(BeNil())
+		Expect(chartRequested.Dependencies()).To(BeEmpty())
+	})
+
+	It("resolveChartDependencies adds repositories dependencies are stored in", func() {
+		repositoryDir := filepath.Join(workspace, "repository")
+		Expect(os.Mkdir(repositoryDir, 0700)).To(Succeed())
+		server := httptest.NewServer(http.FileServer(http.Dir(repositoryDir)))
+		defer server.Close()
+
+		dependencyName := randomString()
+		index := repo.NewIndexFile()
+		addChartToRepository(repositoryDir, server.URL, dependencyName, index, nil)
+		Expect(index.WriteFile(filepath.Join(repositoryDir, "index.yaml"), 0600)).To(Succeed())
+
+		helmDir := filepath.Join(workspace, "helm")
+		GinkgoT().Setenv("HELM_CACHE_HOME", helmDir)
+		GinkgoT().Setenv("HELM_CONFIG_HOME", helmDir)
+		settings = cli.New()
+
+		chartDir := saveChart(workspace, randomString(), []*chart.Dependency{
+			{Name: dependencyName, Version: provenanceChartVersion, Repository: server.URL},
+		})
+		chartRequested, err := loader.Load(chartDir)
+		Expect(err).To(BeNil())
+
+		resolvedChart, err := controllers.ResolveChartDependencies(chartRequested, chartDir, "", settings, nil, klogr.New())
+		Expect(err).To(BeNil())
+		Expect(len(resolvedChart.Dependencies())).To(Equal(1))
+		Expect(resolvedChart.Dependencies()[0].Name()).To(Equal(dependencyName))
+
+		repositoryFile, err := repo.LoadFile(settings.RepositoryConfig)
+		Expect(err).To(BeNil())
+		Expect(len(repositoryFile.Repositories)).To(Equal(1))
+		Expect(repositoryFile.Repositories[0].URL).To(Equal(server.URL))
+	})
+
+	It("resolveChartDependencies fails when dependencies cannot be resolved", func() {
+		chartDir := saveChart(workspace, randomString(), []*chart.Dependency{
+			{Name: randomString(), Version: "0.1.0", Repository: "file://" + filepath.Join(workspace, randomString())},
+		})
+		chartRequested, err := loader.Load(chartDir)
+		Expect(err).To(BeNil())
+
+		_, err = controllers.ResolveChartDependencies(chartRequested, chartDir, "", settings, nil, klogr.New())
+		Expect(err).ToNot(BeNil())
+		Expect(err.Error()).To(ContainSubstring("failed to resolve dependencies"))
+	})
+})
@@ -0,0 +1,357 @@
+/*
+Copyright 2022-23. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/go-logr/logr"
+	"github.com/pmezard/go-difflib/difflib"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/release"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+
+	logs "github.com/projectsveltos/libsveltos/lib/logsettings"
+	"github.com/projectsveltos/libsveltos/lib/utils"
+	configv1alpha1 "github.com/projectsveltos/sveltos-manager/api/v1alpha1"
+)
+
+const (
+	// diffContextLines is the number of unchanged lines shown around each change in a diff
+	diffContextLines = 3
+	// maxReleaseDiffLength is the maximum total length of the diffs reported for an helm
+	// release, so that ClusterReport does not grow past the object size limit. Once reached,
+	// updated resources are reported with diffOmittedMessage instead of a diff.
+	maxReleaseDiffLength = 32 * 1024
+	diffOmittedMessage   = "Diff omitted: maximum diff length reached\n"
+
+	// Secret data is never reported. Values are replaced, in diffs, with placeholders.
+	redactedValue         = "<redacted>"
+	redactedDeployedValue = "<redacted deployed value>"
+	redactedNewValue      = "<redacted requested value>"
+)
+
+// renderRelease returns the manifest of the helm release requestedChart would deploy.
+// Helm dry-run is used against the managed cluster: nothing is changed in the cluster.
+// When there is a current release, an upgrade is simulated, otherwise an install.
+// requestedChart must be resolved (see resolveHelmChart).
+func renderRelease(ctx context.Context, requestedChart *configv1alpha1.HelmChart, currentRelease *releaseInfo,
+	values chartutil.Values, postRenderer *kustomizePostRenderer, clientGetter *restClientGetter,
+	logger logr.Logger) (string, error) {
+
+	logger.V(logs.LogDebug).Info("rendering helm release")
+
+	settings := getSettings()
+	err := prepareRepository(ctx, settings, requestedChart, logger)
+	if err != nil {
+		return "", err
+	}
+
+	registryClient, cleanup, err := getRegistryClient(ctx, getManagementClusterClient(), requestedChart, logger)
+	if err != nil {
+		return "", err
+	}
+	defer cleanup()
+
+	keyring, cleanupKeyring, err := getKeyring(ctx, getManagementClusterClient(), requestedChart, logger)
+	if err != nil {
+		return "", err
+	}
+	defer cleanupKeyring()
+
+	actionConfig, err := actionConfigInit(requestedChart.ReleaseNamespace, clientGetter,
+		getStorageDriver(requestedChart.Options), logger)
+	if err != nil {
+		return "", err
+	}
+	actionConfig.RegistryClient = registryClient
+
+	loadChart := func(chartPathOptions *action.ChartPathOptions) (*chart.Chart, error) {
+		applyVerifyOptions(chartPathOptions, keyring)
+		cp, err := helmChartCache.locateChart(chartPathOptions, requestedChart.RepositoryURL,
+			getUploadedChartName(getChartName(requestedChart)), settings, logger)
+		if err != nil {
+			return nil, err
+		}
+		chartRequested, err := loader.Load(cp)
+		if err != nil {
+			return nil, err
+		}
+		return resolveChartDependencies(chartRequested, cp, chartPathOptions.Keyring, settings, registryClient, logger)
+	}
+
+	var rel *release.Release
+	if currentRelease != nil {
+		upgradeObject := action.NewUpgrade(actionConfig)
+		upgradeObject.ResetValues = true
+		upgradeObject.Namespace = requestedChart.ReleaseNamespace
+		upgradeObject.Version = requestedChart.ChartVersion
+		applyUpgradeOptions(upgradeObject, requestedChart.Options)
+		upgradeObject.DryRun = true
+		if postRenderer != nil {
+			upgradeObject.PostRenderer = postRenderer
+		}
+
+		chartRequested, err := loadChart(&upgradeObject.ChartPathOptions)
+		if err != nil {
+			return "", err
+		}
+		rel, err = upgradeObject.Run(requestedChart.ReleaseName, chartRequested, values)
+		if err != nil {
+			return "", err
+		}
+	} else {
+		installObject := action.NewInstall(actionConfig)
+		installObject.ReleaseName = requestedChart.ReleaseName
+		installObject.Namespace = requestedChart.ReleaseNamespace
+		installObject.Version = requestedChart.ChartVersion
+		applyInstallOptions(installObject, requestedChart.Options)
+		installObject.DryRun = true
+		if postRenderer != nil {
+			installObject.PostRenderer = postRenderer
+		}
+
+		chartRequested, err := loadChart(&installObject.ChartPathOptions)
+		if err != nil {
+			return "", err
+		}
+		rel, err = installObject.Run(chartRequested, values)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return rel.Manifest, nil
+}
+
+// getDryRunResourceChanges returns the resources the helm release would create, update
+// or delete. On failure, error is only logged and reported in the returned message: DryRun
+// never fails because a release cannot be rendered.
+func getDryRunResourceChanges(ctx context.Context, requestedChart *configv1alpha1.HelmChart,
+	currentRelease *releaseInfo, values chartutil.Values, postRenderer *kustomizePostRenderer,
+	clientGetter *restClientGetter, logger logr.Logger) (changes []configv1alpha1.ReleaseResourceChange, message string) {
+
+	manifest, err := renderRelease(ctx, requestedChart, currentRelease, values, postRenderer, clientGetter, logger)
+	if err == nil {
+		var deployedManifest string
+		if currentRelease != nil {
+			deployedManifest = currentRelease.Manifest
+		}
+		changes, err = getReleaseResourceChanges(deployedManifest, manifest)
+	}
+	if err != nil {
+		logger.V(logs.LogInfo).Info(fmt.Sprintf("failed to compute helm release changes: %v", err))
+		return nil, fmt.Sprintf("Failed to compute resource changes: %v", err)
+	}
+
+	return changes, ""
+}
+
+// releaseResource is a resource part of an helm release manifest
+type releaseResource struct {
+	change configv1alpha1.ReleaseResourceChange
+	object *unstructured.Unstructured
+	// content is the normalized YAML of the resource
+	content string
+}
+
+func getReleaseResourceKey(group, kind, namespace, name string) string {
+	return fmt.Sprintf("%s/%s/%s/%s", group, kind, namespace, name)
+}
+
+// getReleaseResources returns the resources contained in an helm release manifest, keyed
+// by group, kind, namespace and name
+func getReleaseResources(manifest string) (map[string]*releaseResource, error) {
+	resources := make(map[string]*releaseResource)
+
+	elements := strings.Split(manifest, separator)
+	for i := range elements {
+		if strings.TrimSpace(elements[i]) == "" {
+			continue
+		}
+
+		policy, err := utils.GetUnstructured([]byte(elements[i]))
+		if err != nil {
+			return nil, err
+		}
+
+		// Normalize content so that formatting and fields order are not reported as changes
+		content, err := yaml.Marshal(policy.Object)
+		if err != nil {
+			return nil, err
+		}
+
+		gvk := policy.GroupVersionKind()
+		key := getReleaseResourceKey(gvk.Group, gvk.Kind, policy.GetNamespace(), policy.GetName())
+		resources[key] = &releaseResource{
+			change: configv1alpha1.ReleaseResourceChange{
+				Name:      policy.GetName(),
+				Namespace: policy.GetNamespace(),
+				Group:     gvk.Group,
+				Version:   gvk.Version,
+				Kind:      gvk.Kind,
+			},
+			object:  policy,
+			content: string(content),
+		}
+	}
+
+	return resources, nil
+}
+
+// getReleaseResourceChanges compares the manifest of the deployed release with the manifest
+// of the requested one and returns the resources which would be created, updated or deleted.
+// A unified diff is reported for each updated resource (Secret data redacted), till the
+// total diff length reaches maxReleaseDiffLength.
+func getReleaseResourceChanges(deployedManifest, requestedManifest string,
+) ([]configv1alpha1.ReleaseResourceChange, error) {
+
+	deployed, err := getReleaseResources(deployedManifest)
+	if err != nil {
+		return nil, err
+	}
+
+	requested, err := getReleaseResources(requestedManifest)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make([]configv1alpha1.ReleaseResourceChange, 0)
+	for key, resource := range requested {
+		deployedResource, ok := deployed[key]
+		if !ok {
+			resource.change.Action = string(configv1alpha1.CreateResourceAction)
+			changes = append(changes, resource.change)
+			continue
+		}
+		if deployedResource.content == resource.content {
+			continue
+		}
+		resource.change.Action = string(configv1alpha1.UpdateResourceAction)
+		changes = append(changes, resource.change)
+	}
+
+	for key, resource := range deployed {
+		if _, ok := requested[key]; !ok {
+			resource.change.Action = string(configv1alpha1.DeleteResourceAction)
+			changes = append(changes, resource.change)
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].Kind != changes[j].Kind {
+			return changes[i].Kind < changes[j].Kind
+		}
+		if changes[i].Namespace != changes[j].Namespace {
+			return changes[i].Namespace < changes[j].Namespace
+		}
+		return changes[i].Name < changes[j].Name
+	})
+
+	// Diffs are computed once changes are sorted, so that the same diffs are omitted
+	// every time maxReleaseDiffLength is reached
+	diffLength := 0
+	for i := range changes {
+		if changes[i].Action != string(configv1alpha1.UpdateResourceAction) {
+			continue
+		}
+
+		key := getReleaseResourceKey(changes[i].Group, changes[i].Kind, changes[i].Namespace, changes[i].Name)
+		diff, err := getResourceDiff(deployed[key].object, requested[key].object)
+		if err != nil {
+			return nil, err
+		}
+
+		if diffLength+len(diff) > maxReleaseDiffLength {
+			changes[i].Diff = diffOmittedMessage
+			continue
+		}
+		diffLength += len(diff)
+		changes[i].Diff = diff
+	}
+
+	return changes, nil
+}
+
+// getResourceDiff returns the unified diff between the deployed and the requested resource.
+// Secret data is redacted.
+func getResourceDiff(deployed, requested *unstructured.Unstructured) (string, error) {
+	if deployed.GroupVersionKind().Group == "" && deployed.GetKind() == "Secret" {
+		deployed = deployed.DeepCopy()
+		requested = requested.DeepCopy()
+		redactSecretData(deployed.Object, requested.Object)
+	}
+
+	from, err := yaml.Marshal(deployed.Object)
+	if err != nil {
+		return "", err
+	}
+	to, err := yaml.Marshal(requested.Object)
+	if err != nil {
+		return "", err
+	}
+
+	return getUnifiedDiff("deployed", "requested", string(from), string(to))
+}
+
+// redactSecretData replaces data and stringData values of the deployed and requested Secret
+// with placeholders. Values which differ get different placeholders, so changes are still
+// reported.
+func redactSecretData(deployed, requested map[string]interface{}) {
+	for _, field := range []string{"data", "stringData"} {
+		deployedData, _ := deployed[field].(map[string]interface{})
+		requestedData, _ := requested[field].(map[string]interface{})
+
+		for key, value := range deployedData {
+			requestedValue, ok := requestedData[key]
+			if !ok || reflect.DeepEqual(value, requestedValue) {
+				deployedData[key] = redactedValue
+				if ok {
+					requestedData[key] = redactedValue
+				}
+				continue
+			}
+			deployedData[key] = redactedDeployedValue
+			requestedData[key] = redactedNewValue
+		}
+
+		for key := range requestedData {
+			if _, ok := deployedData[key]; !ok {
+				requestedData[key] = redactedValue
+			}
+		}
+	}
+}
+
+// getUnifiedDiff returns the unified diff between from and to
+func getUnifiedDiff(fromName, toName, from, to string) (string, error) {
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(strings.TrimSuffix(from, "\n")),
+		B:        difflib.SplitLines(strings.TrimSuffix(to, "\n")),
+		FromFile: fromName,
+		ToFile:   toName,
+		Context:  diffContextLines,
+	})
+}
@@ -0,0 +1,193 @@
+/*
+Copyright 2022-23. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers_test
+
+import (
+	"fmt"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	configv1alpha1 "github.com/projectsveltos/sveltos-manager/api/v1alpha1"
+	"github.com/projectsveltos/sveltos-manager/controllers"
+)
+
+const (
+	deployedManifest = `---
+# Source: nginx/templates/svc.yaml
+apiVersion: v1
+kind: Service
+metadata:
+  name: nginx
+  namespace: nginx
+spec:
+  ports:
+  - port: 80
+---
+# Source: nginx/templates/deployment.yaml
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx
+  namespace: nginx
+spec:
+  replicas: 1
+---
+# Source: nginx/templates/configmap.yaml
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: nginx-old
+  namespace: nginx
+`
+
+	requestedManifest = `---
+# Source: nginx/templates/svc.yaml
+apiVersion: v1
+kind: Service
+metadata:
+  namespace: nginx
+  name: nginx
+spec:
+  ports:
+    - port: 80
+---
+# Source: nginx/templates/deployment.yaml
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx
+  namespace: nginx
+spec:
+  replicas: 3
+---
+# Source: nginx/templates/configmap.yaml
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: nginx-new
+  namespace: nginx
+`
+)
+
+var _ = Describe("Helm DryRun", func() {
+	It("getReleaseResourceChanges reports created, updated and deleted resources", func() {
+		changes, err := controllers.GetReleaseResourceChanges(deployedManifest, requestedManifest)
+		Expect(err).To(BeNil())
+		// Service only differs in formatting and fields order
+		Expect(len(changes)).To(Equal(3))
+
+		Expect(changes[0].Kind).To(Equal("ConfigMap"))
+		Expect(changes[0].Name).To(Equal("nginx-new"))
+		Expect(changes[0].Action).To(Equal(string(configv1alpha1.CreateResourceAction)))
+
+		Expect(changes[1].Kind).To(Equal("ConfigMap"))
+		Expect(changes[1].Name).To(Equal("nginx-old"))
+		Expect(changes[1].Action).To(Equal(string(configv1alpha1.DeleteResourceAction)))
+
+		Expect(changes[2].Kind).To(Equal("Deployment"))
+		Expect(changes[2].Group).To(Equal("apps"))
+		Expect(changes[2].Namespace).To(Equal("nginx"))
+		Expect(changes[2].Action).To(Equal(string(configv1alpha1.UpdateResourceAction)))
+		Expect(changes[2].Diff).To(ContainSubstring("-  replicas: 1\n+  replicas: 3\n"))
+
+		By("Installing a release for the first time")
+		changes, err = controllers.GetReleaseResourceChanges("", requestedManifest)
+		Expect(err).To(BeNil())
+		Expect(len(changes)).To(Equal(3))
+		for i := range changes {
+			Expect(changes[i].Action).To(Equal(string(configv1alpha1.CreateResourceAction)))
+		}
+	})
+
+	It("getUnifiedDiff returns hunks with context around each change", func() {
+		from := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\n"
+		to := "a\nB\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\nm\n"
+
+		diff, err := controllers.GetUnifiedDiff("deployed", "requested", from, to)
+		Expect(err).To(BeNil())
+		Expect(diff).To(Equal(`--- deployed
++++ requested
+@@ -1,5 +1,5 @@
+ a
+-b
++B
+ c
+ d
+ e
+@@ -10,3 +10,4 @@
+ j
+ k
+ l
++m
+`))
+	})
+
+	It("getReleaseResourceChanges redacts Secret data", func() {
+		secret := `---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: nginx
+  namespace: nginx
+data:
+  unchanged: %s
+  changed: %s
+stringData:
+  password: %s
+`
+		deployedSecret := fmt.Sprintf(secret, "dW5jaGFuZ2Vk", "b2xk", "deployed-password")
+		requestedSecret := fmt.Sprintf(secret, "dW5jaGFuZ2Vk", "bmV3", "requested-password")
+
+		changes, err := controllers.GetReleaseResourceChanges(deployedSecret, requestedSecret)
+		Expect(err).To(BeNil())
+		Expect(len(changes)).To(Equal(1))
+		Expect(changes[0].Action).To(Equal(string(configv1alpha1.UpdateResourceAction)))
+		Expect(changes[0].Diff).To(ContainSubstring("-  changed: <redacted deployed value>\n+  changed: <redacted requested value>\n"))
+		Expect(changes[0].Diff).To(ContainSubstring("-  password: <redacted deployed value>\n+  password: <redacted requested value>\n"))
+		Expect(changes[0].Diff).To(ContainSubstring("   unchanged: <redacted>\n"))
+		for _, value := range []string{"dW5jaGFuZ2Vk", "b2xk", "bmV3", "deployed-password", "requested-password"} {
+			Expect(changes[0].Diff).ToNot(ContainSubstring(value))
+		}
+	})
+
+	It("getReleaseResourceChanges caps the total diff length", func() {
+		configMap := `---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: %s
+  namespace: nginx
+data:
+  content: %s
+`
+		var deployed, requested string
+		for i := 0; i < 3; i++ {
+			name := fmt.Sprintf("nginx-%d", i)
+			deployed += fmt.Sprintf(configMap, name, strings.Repeat("a", controllers.MaxReleaseDiffLength/5))
+			requested += fmt.Sprintf(configMap, name, strings.Repeat("b", controllers.MaxReleaseDiffLength/5))
+		}
+
+		changes, err := controllers.GetReleaseResourceChanges(deployed, requested)
+		Expect(err).To(BeNil())
+		Expect(len(changes)).To(Equal(3))
+		Expect(changes[0].Diff).To(ContainSubstring("-  content: aaa"))
+		Expect(changes[1].Diff).To(ContainSubstring("-  content: aaa"))
+		Expect(changes[2].Diff).To(Equal(controllers.DiffOmittedMessage))
+	})
+})
@@ -0,0 +1,200 @@
+/*
+Copyright 2022-23. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+
+	"github.com/gdexlab/go-render/render"
+	"github.com/go-logr/logr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/kustomize/api/krusty"
+	kustomizetypes "sigs.k8s.io/kustomize/api/types"
+	"sigs.k8s.io/kustomize/kyaml/filesys"
+	"sigs.k8s.io/kustomize/kyaml/resid"
+	"sigs.k8s.io/yaml"
+
+	configv1alpha1 "github.com/projectsveltos/sveltos-manager/api/v1alpha1"
+)
+
+const (
+	kustomizationFile         = "kustomization.yaml"
+	postRendererResourcesFile = "resources.yaml"
+)
+
+// kustomizePostRenderer is an helm PostRenderer applying patches, using Kustomize,
+// to the manifests rendered by Helm
+type kustomizePostRenderer struct {
+	// patches are applied in order
+	patches []kustomizetypes.Patch
+}
+
+// Run applies patches to the manifests rendered by Helm
+func (r *kustomizePostRenderer) Run(renderedManifests *bytes.Buffer) (*bytes.Buffer, error) {
+	fs := filesys.MakeFsInMemory()
+	if err := fs.WriteFile(postRendererResourcesFile, renderedManifests.Bytes()); err != nil {
+		return nil, err
+	}
+
+	kustomization := kustomizetypes.Kustomization{
+		TypeMeta: kustomizetypes.TypeMeta{
+			APIVersion: kustomizetypes.KustomizationVersion,
+			Kind:       kustomizetypes.KustomizationKind,
+		},
+		Resources: []string{postRendererResourcesFile},
+		Patches:   r.patches,
+	}
+	data, err := yaml.Marshal(kustomization)
+	if err != nil {
+		return nil, err
+	}
+	if err := fs.WriteFile(kustomizationFile, data); err != nil {
+		return nil, err
+	}
+
+	kustomizer := krusty.MakeKustomizer(krusty.MakeDefaultOptions())
+	resMap, err := kustomizer.Run(fs, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply post renderers: %w", err)
+	}
+
+	yamlDoc, err := resMap.AsYaml()
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewBuffer(yamlDoc), nil
+}
+
+// hash returns a string representing the patches applied by the post renderer.
+// Returns an empty string for a nil post renderer.
+func (r *kustomizePostRenderer) hash() string {
+	if r == nil {
+		return ""
+	}
+
+	h := sha256.New()
+	h.Write([]byte(render.AsCode(r.patches)))
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+// getPostRenderer returns the post renderer applying all patches defined in helm chart
+// PostRenderers. Templated patches are instantiated.
+// Returns nil if helm chart has no post renderer.
+func getPostRenderer(ctx context.Context, clusterSummary *configv1alpha1.ClusterSummary,
+	requestedChart *configv1alpha1.HelmChart, logger logr.Logger) (*kustomizePostRenderer, error) {
+
+	if len(requestedChart.PostRenderers) == 0 {
+		return nil, nil
+	}
+
+	instantiate := func(postRenderer *configv1alpha1.HelmPostRenderer, patch string) (string, error) {
+		if !postRenderer.Template {
+			return patch, nil
+		}
+		return instantiateTemplateValues(ctx, getManagementClusterConfig(), getManagementClusterClient(),
+			clusterSummary.Spec.ClusterType, clusterSummary.Spec.ClusterNamespace, clusterSummary.Spec.ClusterName,
+			requestedChart.ChartName, patch, requestedChart.SecretRef, logger)
+	}
+
+	patches := make([]kustomizetypes.Patch, 0)
+	for i := range requestedChart.PostRenderers {
+		postRenderer := &requestedChart.PostRenderers[i]
+
+		for j := range postRenderer.PatchesStrategicMerge {
+			patch, err := instantiate(postRenderer, postRenderer.PatchesStrategicMerge[j])
+			if err != nil {
+				return nil, err
+			}
+			patches = append(patches, kustomizetypes.Patch{Patch: patch})
+		}
+
+		for j := range postRenderer.PatchesJSON6902 {
+			current := &postRenderer.PatchesJSON6902[j]
+			patch, err := instantiate(postRenderer, current.Patch)
+			if err != nil {
+				return nil, err
+			}
+			patches = append(patches, kustomizetypes.Patch{
+				Patch:  patch,
+				Target: getPatchTarget(&current.Target),
+			})
+		}
+	}
+
+	return &kustomizePostRenderer{patches: patches}, nil
+}
+
+func getPatchTarget(selector *configv1alpha1.PatchSelector) *kustomizetypes.Selector {
+	return &kustomizetypes.Selector{
+		ResId: resid.ResId{
+			Gvk: resid.Gvk{
+				Group:   selector.Group,
+				Version: selector.Version,
+				Kind:    selector.Kind,
+			},
+			Name:      selector.Name,
+			Namespace: selector.Namespace,
+		},
+		LabelSelector:      selector.LabelSelector,
+		AnnotationSelector: selector.AnnotationSelector,
+	}
+}
+
+// havePostRenderersChanged returns true if post renderers are different than the ones
+// the current release was deployed with
+func havePostRenderersChanged(clusterSummary *configv1alpha1.ClusterSummary, currentRelease *releaseInfo,
+	requestedChart *configv1alpha1.HelmChart, postRenderer *kustomizePostRenderer) bool {
+
+	if currentRelease == nil || requestedChart.HelmChartAction == configv1alpha1.HelmChartActionUninstall {
+		return false
+	}
+
+	var deployedHash string
+	summary := getHelmChartSummary(clusterSummary, requestedChart.ReleaseNamespace, requestedChart.ReleaseName)
+	if summary != nil {
+		deployedHash = summary.PostRenderersHash
+	}
+
+	return deployedHash != postRenderer.hash()
+}
+
+// updatePostRenderersHash records, in ClusterSummary Status, the hash of the post renderers
+// the helm release was deployed with.
+// No action if hash has not changed.
+// No action in DryRun mode.
+func updatePostRenderersHash(ctx context.Context, c client.Client, clusterSummary *configv1alpha1.ClusterSummary,
+	requestedChart *configv1alpha1.HelmChart, postRenderer *kustomizePostRenderer) error {
+
+	// No-op in DryRun mode
+	if clusterSummary.Spec.ClusterProfileSpec.SyncMode == configv1alpha1.SyncModeDryRun {
+		return nil
+	}
+
+	hash := postRenderer.hash()
+	summary := getHelmChartSummary(clusterSummary, requestedChart.ReleaseNamespace, requestedChart.ReleaseName)
+	if summary != nil && summary.PostRenderersHash == hash {
+		return nil
+	}
+
+	return updateHelmChartSummary(ctx, c, clusterSummary, requestedChart,
+		func(summary *configv1alpha1.HelmChartSummary) {
+			summary.PostRenderersHash = hash
+		})
+}
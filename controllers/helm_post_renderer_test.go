@@ -0,0 +1,173 @@
+/*
+Copyright 2022-23. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers_test
+
+import (
+	"bytes"
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"helm.sh/helm/v3/pkg/release"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2/klogr"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/yaml"
+
+	configv1alpha1 "github.com/projectsveltos/sveltos-manager/api/v1alpha1"
+	"github.com/projectsveltos/sveltos-manager/controllers"
+)
+
+const (
+	renderedDeployment = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx
+  namespace: default
+  labels:
+    app: nginx
+spec:
+  replicas: 1
+`
+)
+
+var _ = Describe("Helm post renderers", func() {
+	var clusterSummary *configv1alpha1.ClusterSummary
+	var requestedChart *configv1alpha1.HelmChart
+
+	BeforeEach(func() {
+		requestedChart = &configv1alpha1.HelmChart{
+			RepositoryURL:    "https://charts.bitnami.com/bitnami",
+			RepositoryName:   "bitnami",
+			ChartName:        "bitnami/nginx",
+			ChartVersion:     "13.2.23",
+			ReleaseName:      "nginx",
+			ReleaseNamespace: "default",
+			PostRenderers: []configv1alpha1.HelmPostRenderer{
+				{
+					PatchesStrategicMerge: []string{`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx
+  namespace: default
+spec:
+  replicas: 3`},
+					PatchesJSON6902: []configv1alpha1.JSON6902Patch{
+						{
+							Target: configv1alpha1.PatchSelector{Kind: "Deployment", LabelSelector: "app=nginx"},
+							Patch: `- op: add
+  path: /metadata/labels/env
+  value: production`,
+						},
+					},
+				},
+			},
+		}
+
+		clusterSummary = &configv1alpha1.ClusterSummary{
+			ObjectMeta: metav1.ObjectMeta{Namespace: randomString(), Name: randomString()},
+			Spec: configv1alpha1.ClusterSummarySpec{
+				ClusterNamespace: randomString(),
+				ClusterName:      randomString(),
+				ClusterProfileSpec: configv1alpha1.ClusterProfileSpec{
+					HelmCharts: []configv1alpha1.HelmChart{*requestedChart},
+				},
+			},
+		}
+	})
+
+	It("post renderer applies strategic merge and JSON6902 patches to rendered manifests", func() {
+		postRenderer, err := controllers.GetPostRenderer(context.TODO(), clusterSummary, requestedChart, klogr.New())
+		Expect(err).To(BeNil())
+		Expect(postRenderer).ToNot(BeNil())
+
+		output, err := postRenderer.Run(bytes.NewBufferString(renderedDeployment))
+		Expect(err).To(BeNil())
+
+		depl := &appsv1.Deployment{}
+		Expect(yaml.Unmarshal(output.Bytes(), depl)).To(Succeed())
+		Expect(depl.Spec.Replicas).ToNot(BeNil())
+		Expect(*depl.Spec.Replicas).To(Equal(int32(3)))
+		Expect(depl.Labels["env"]).To(Equal("production"))
+		Expect(depl.Labels["app"]).To(Equal("nginx"))
+	})
+
+	It("getPostRenderer returns nil when no post renderer is defined", func() {
+		requestedChart.PostRenderers = nil
+		postRenderer, err := controllers.GetPostRenderer(context.TODO(), clusterSummary, requestedChart, klogr.New())
+		Expect(err).To(BeNil())
+		Expect(postRenderer).To(BeNil())
+	})
+
+	It("havePostRenderersChanged returns true when patches differ from the deployed ones", func() {
+		currentRelease := &controllers.ReleaseInfo{
+			ReleaseName:      requestedChart.ReleaseName,
+			ReleaseNamespace: requestedChart.ReleaseNamespace,
+			Status:           release.StatusDeployed.String(),
+			ChartVersion:     requestedChart.ChartVersion,
+		}
+
+		postRenderer, err := controllers.GetPostRenderer(context.TODO(), clusterSummary, requestedChart, klogr.New())
+		Expect(err).To(BeNil())
+
+		// Helm release was deployed with no post renderer
+		Expect(controllers.HavePostRenderersChanged(clusterSummary, currentRelease, requestedChart,
+			postRenderer)).To(BeTrue())
+
+		By("Recording post renderers helm release was deployed with")
+		clusterSummary.Status.HelmReleaseSummaries = []configv1alpha1.HelmChartSummary{
+			{
+				ReleaseName:      requestedChart.ReleaseName,
+				ReleaseNamespace: requestedChart.ReleaseNamespace,
+				Status:           configv1alpha1.HelChartStatusManaging,
+			},
+		}
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(clusterSummary).Build()
+		Expect(controllers.UpdatePostRenderersHash(context.TODO(), c, clusterSummary, requestedChart,
+			postRenderer)).To(Succeed())
+		Expect(controllers.HavePostRenderersChanged(clusterSummary, currentRelease, requestedChart,
+			postRenderer)).To(BeFalse())
+
+		By("Changing a patch")
+		requestedChart.PostRenderers[0].PatchesJSON6902[0].Patch = `- op: add
+  path: /metadata/labels/env
+  value: staging`
+		postRenderer, err = controllers.GetPostRenderer(context.TODO(), clusterSummary, requestedChart, klogr.New())
+		Expect(err).To(BeNil())
+		Expect(controllers.HavePostRenderersChanged(clusterSummary, currentRelease, requestedChart,
+			postRenderer)).To(BeTrue())
+
+		By("Removing all post renderers")
+		Expect(controllers.HavePostRenderersChanged(clusterSummary, currentRelease, requestedChart,
+			nil)).To(BeTrue())
+	})
+
+	It("helmHash changes when post renderers change", func() {
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(clusterSummary).Build()
+		clusterSummaryScope := getClusterSummaryScope(c, klogr.New(), &configv1alpha1.ClusterProfile{}, clusterSummary)
+
+		hash, err := controllers.HelmHash(context.TODO(), c, clusterSummaryScope, klogr.New())
+		Expect(err).To(BeNil())
+
+		clusterSummary.Spec.ClusterProfileSpec.HelmCharts[0].PostRenderers[0].PatchesStrategicMerge = nil
+		newHash, err := controllers.HelmHash(context.TODO(), c, clusterSummaryScope, klogr.New())
+		Expect(err).To(BeNil())
+		Expect(newHash).ToNot(Equal(hash))
+	})
+})
@@ -0,0 +1,160 @@
+/*
+Copyright 2022-23. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/gdexlab/go-render/render"
+	"github.com/go-logr/logr"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/cli"
+	"helm.sh/helm/v3/pkg/downloader"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	logs "github.com/projectsveltos/libsveltos/lib/logsettings"
+	configv1alpha1 "github.com/projectsveltos/sveltos-manager/api/v1alpha1"
+)
+
+const (
+	// keyringKey is the key, in the Secret referenced by helm chart Verify, containing the keyring
+	keyringKey = "keyring"
+
+	provenanceExtension = ".prov"
+)
+
+// getKeyring stores, in a file, the keyring used to verify the chart provenance.
+// Returns an empty path if helm chart does not require provenance verification.
+func getKeyring(ctx context.Context, c client.Client, requestedChart *configv1alpha1.HelmChart,
+	logger logr.Logger) (string, func(), error) {
+
+	cleanup := func() {}
+	if requestedChart.Verify == nil {
+		return "", cleanup, nil
+	}
+
+	secretRef := &requestedChart.Verify.KeyringSecretRef
+	logger.V(logs.LogDebug).Info(fmt.Sprintf("using keyring from secret %s/%s",
+		secretRef.Namespace, secretRef.Name))
+
+	secret, err := getKeyringSecret(ctx, c, requestedChart)
+	if err != nil {
+		return "", cleanup, err
+	}
+
+	keyring, ok := secret.Data[keyringKey]
+	if !ok {
+		return "", cleanup, fmt.Errorf("secret %s/%s does not contain %s key",
+			secretRef.Namespace, secretRef.Name, keyringKey)
+	}
+
+	keyringFile, err := os.CreateTemp("", "keyring")
+	if err != nil {
+		return "", cleanup, err
+	}
+	defer keyringFile.Close()
+
+	_, err = keyringFile.Write(keyring)
+	if err != nil {
+		os.Remove(keyringFile.Name())
+		return "", cleanup, err
+	}
+
+	return keyringFile.Name(), func() { os.Remove(keyringFile.Name()) }, nil
+}
+
+// getKeyringSecret returns the Secret containing the keyring used to verify the chart provenance.
+// Returns nil if helm chart does not require provenance verification.
+func getKeyringSecret(ctx context.Context, c client.Client, requestedChart *configv1alpha1.HelmChart,
+) (*corev1.Secret, error) {
+
+	if requestedChart.Verify == nil {
+		return nil, nil
+	}
+
+	secretRef := &requestedChart.Verify.KeyringSecretRef
+	secret := &corev1.Secret{}
+	err := c.Get(ctx, types.NamespacedName{Namespace: secretRef.Namespace, Name: secretRef.Name}, secret)
+	if err != nil {
+		return nil, err
+	}
+
+	return secret, nil
+}
+
+// getKeyringConfig returns the keyring used to verify the chart provenance, so that helm
+// charts are verified (and deployed) again when keyring changes
+func getKeyringConfig(ctx context.Context, c client.Client, requestedChart *configv1alpha1.HelmChart,
+) (string, error) {
+
+	secret, err := getKeyringSecret(ctx, c, requestedChart)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	if secret == nil {
+		return "", nil
+	}
+
+	return render.AsCode(secret.Data), nil
+}
+
+// applyVerifyOptions requires chart provenance to be verified, using keyring, when chart
+// is located. No action if keyring is empty.
+func applyVerifyOptions(chartPathOptions *action.ChartPathOptions, keyring string) {
+	if keyring == "" {
+		return
+	}
+
+	chartPathOptions.Verify = true
+	chartPathOptions.Keyring = keyring
+}
+
+// verifyChart verifies the chart archive against the provenance file stored alongside it
+func verifyChart(path, keyring string) error {
+	_, err := downloader.VerifyChart(path, keyring)
+	if err != nil {
+		return fmt.Errorf("chart %s provenance verification failed: %w", filepath.Base(path), err)
+	}
+	return nil
+}
+
+// locateAndVerifyChart returns the path of the chart archive, downloading it if needed.
+// When chartPathOptions requires verification, chart provenance is verified.
+func locateAndVerifyChart(chartPathOptions *action.ChartPathOptions, chartName string,
+	settings *cli.EnvSettings) (string, error) {
+
+	path, err := chartPathOptions.LocateChart(chartName, settings)
+	if err != nil && chartPathOptions.Verify {
+		return "", fmt.Errorf("failed to locate and verify chart %s: %w", chartName, err)
+	}
+	return path, err
+}
+
+// hasProvenanceFile returns true if the provenance file of the chart archive is present
+func hasProvenanceFile(path string) bool {
+	_, err := os.Stat(path + provenanceExtension)
+	return err == nil
+}
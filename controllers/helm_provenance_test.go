@@ -0,0 +1,249 @@
+/*
+Copyright 2022-23. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"golang.org/x/crypto/openpgp" //nolint:staticcheck // same package used by helm
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/cli"
+	"helm.sh/helm/v3/pkg/provenance"
+	"helm.sh/helm/v3/pkg/repo"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2/klogr"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	libsveltosv1alpha1 "github.com/projectsveltos/libsveltos/api/v1alpha1"
+	configv1alpha1 "github.com/projectsveltos/sveltos-manager/api/v1alpha1"
+	"github.com/projectsveltos/sveltos-manager/controllers"
+)
+
+const (
+	provenanceChartVersion = "0.1.0"
+)
+
+// newKeyring returns a new signing entity along with its public keyring
+func newKeyring() (*openpgp.Entity, []byte) {
+	entity, err := openpgp.NewEntity(randomString(), "", "test@projectsveltos.io", nil)
+	Expect(err).To(BeNil())
+
+	var keyring bytes.Buffer
+	Expect(entity.Serialize(&keyring)).To(Succeed())
+	return entity, keyring.Bytes()
+}
+
+// writeKeyring stores keyring in a file in dir
+func writeKeyring(dir string, keyring []byte) string {
+	path := filepath.Join(dir, randomString())
+	Expect(os.WriteFile(path, keyring, 0600)).To(Succeed())
+	return path
+}
+
+// addChartToRepository creates a chart archive in dir and adds it to the repository index.
+// If signer is set, the chart provenance file is created as well.
+func addChartToRepository(dir, url, chartName string, index *repo.IndexFile, signer *openpgp.Entity) {
+	ch := &chart.Chart{
+		Metadata: &chart.Metadata{APIVersion: chart.APIVersionV2, Name: chartName, Version: provenanceChartVersion},
+		Templates: []*chart.File{
+			{Name: "templates/configmap.yaml", Data: []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: test\n")},
+		},
+	}
+	path, err := chartutil.Save(ch, dir)
+	Expect(err).To(BeNil())
+
+	digest, err := provenance.DigestFile(path)
+	Expect(err).To(BeNil())
+	Expect(index.MustAdd(ch.Metadata, filepath.Base(path), url, digest)).To(Succeed())
+
+	if signer != nil {
+		sig := &provenance.Signatory{Entity: signer}
+		prov, err := sig.ClearSign(path)
+		Expect(err).To(BeNil())
+		Expect(os.WriteFile(path+".prov", []byte(prov), 0600)).To(Succeed())
+	}
+}
+
+var _ = Describe("Helm chart provenance", func() {
+	var dir string
+
+	BeforeEach(func() {
+		var err error
+		dir, err = os.MkdirTemp("", randomString())
+		Expect(err).To(BeNil())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("getKeyring stores keyring contained in the referenced Secret in a file", func() {
+		_, keyring := newKeyring()
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: randomString(), Name: randomString()},
+			Data:       map[string][]byte{"keyring": keyring},
+		}
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).Build()
+
+		requestedChart := &configv1alpha1.HelmChart{ChartName: randomString()}
+		path, cleanup, err := controllers.GetKeyring(context.TODO(), c, requestedChart, klogr.New())
+		Expect(err).To(BeNil())
+		Expect(path).To(BeEmpty())
+		cleanup()
+
+		requestedChart.Verify = &configv1alpha1.HelmChartVerify{
+			KeyringSecretRef: corev1.ObjectReference{Namespace: secret.Namespace, Name: secret.Name},
+		}
+		path, cleanup, err = controllers.GetKeyring(context.TODO(), c, requestedChart, klogr.New())
+		Expect(err).To(BeNil())
+		content, err := os.ReadFile(path)
+		Expect(err).To(BeNil())
+		Expect(content).To(Equal(keyring))
+
+		cleanup()
+		_, err = os.Stat(path)
+		Expect(os.IsNotExist(err)).To(BeTrue())
+
+		By("Referencing a Secret with no keyring")
+		delete(secret.Data, "keyring")
+		Expect(c.Update(context.TODO(), secret)).To(Succeed())
+		_, _, err = controllers.GetKeyring(context.TODO(), c, requestedChart, klogr.New())
+		Expect(err).ToNot(BeNil())
+	})
+
+	It("HelmHash and getHelmReferences consider keyring Secret", func() {
+		_, keyring := newKeyring()
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: randomString(), Name: randomString()},
+			Data:       map[string][]byte{"keyring": keyring},
+		}
+
+		clusterSummary := &configv1alpha1.ClusterSummary{
+			ObjectMeta: metav1.ObjectMeta{Namespace: randomString(), Name: randomString()},
+			Spec: configv1alpha1.ClusterSummarySpec{
+				ClusterProfileSpec: configv1alpha1.ClusterProfileSpec{
+					HelmCharts: []configv1alpha1.HelmChart{
+						{
+							RepositoryURL:    "https://charts.bitnami.com/bitnami",
+							RepositoryName:   "bitnami",
+							ChartName:        "bitnami/redis",
+							ChartVersion:     "17.7.1",
+							ReleaseName:      "redis",
+							ReleaseNamespace: "redis",
+							Verify: &configv1alpha1.HelmChartVerify{
+								KeyringSecretRef: corev1.ObjectReference{Namespace: secret.Namespace, Name: secret.Name},
+							},
+						},
+					},
+				},
+			},
+		}
+
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(clusterSummary, secret).Build()
+		clusterSummaryScope := getClusterSummaryScope(c, klogr.New(), &configv1alpha1.ClusterProfile{}, clusterSummary)
+
+		references, err := controllers.GetHelmReferences(context.TODO(), c, clusterSummary)
+		Expect(err).To(BeNil())
+		Expect(references.Has(&corev1.ObjectReference{
+			APIVersion: corev1.SchemeGroupVersion.String(), Kind: string(libsveltosv1alpha1.SecretReferencedResourceKind),
+			Namespace: secret.Namespace, Name: secret.Name,
+		})).To(BeTrue())
+
+		hash, err := controllers.HelmHash(context.TODO(), c, clusterSummaryScope, klogr.New())
+		Expect(err).To(BeNil())
+
+		By("Rotating keyring")
+		_, secret.Data["keyring"] = newKeyring()
+		Expect(c.Update(context.TODO(), secret)).To(Succeed())
+
+		newHash, err := controllers.HelmHash(context.TODO(), c, clusterSummaryScope, klogr.New())
+		Expect(err).To(BeNil())
+		Expect(newHash).ToNot(Equal(hash))
+	})
+
+	It("locateChart verifies provenance of downloaded and cached charts", func() {
+		repositoryDir := filepath.Join(dir, "repository")
+		Expect(os.Mkdir(repositoryDir, 0700)).To(Succeed())
+		server := httptest.NewServer(http.FileServer(http.Dir(repositoryDir)))
+		defer server.Close()
+
+		signer, keyring := newKeyring()
+		_, otherKeyring := newKeyring()
+
+		index := repo.NewIndexFile()
+		addChartToRepository(repositoryDir, server.URL, "signed", index, signer)
+		addChartToRepository(repositoryDir, server.URL, "unsigned", index, nil)
+		Expect(index.WriteFile(filepath.Join(repositoryDir, "index.yaml"), 0600)).To(Succeed())
+
+		helmDir := filepath.Join(dir, "helm")
+		GinkgoT().Setenv("HELM_CACHE_HOME", helmDir)
+		GinkgoT().Setenv("HELM_CONFIG_HOME", helmDir)
+		settings := cli.New()
+
+		keyringFile := writeKeyring(dir, keyring)
+		otherKeyringFile := writeKeyring(dir, otherKeyring)
+
+		cache := controllers.NewChartCache(controllers.DefaultChartCacheMaxSize, time.Minute)
+		locate := func(chartName, keyring string) (string, error) {
+			chartPathOptions := &action.ChartPathOptions{RepoURL: server.URL, Version: provenanceChartVersion}
+			controllers.ApplyVerifyOptions(chartPathOptions, keyring)
+			return controllers.LocateChart(cache, chartPathOptions, server.URL, chartName, settings, klogr.New())
+		}
+
+		By("Using a keyring not containing the signing key")
+		_, err := locate("signed", otherKeyringFile)
+		Expect(err).ToNot(BeNil())
+		Expect(err.Error()).To(ContainSubstring("failed to locate and verify chart signed"))
+
+		By("Using the keyring containing the signing key")
+		path, err := locate("signed", keyringFile)
+		Expect(err).To(BeNil())
+		_, err = os.Stat(path + ".prov")
+		Expect(err).To(BeNil())
+
+		By("Verifying cached chart with a keyring not containing the signing key")
+		_, err = locate("signed", otherKeyringFile)
+		Expect(err).ToNot(BeNil())
+		Expect(err.Error()).To(ContainSubstring("provenance verification failed"))
+
+		By("Not requiring verification")
+		cachedPath, err := locate("signed", "")
+		Expect(err).To(BeNil())
+		Expect(cachedPath).To(Equal(path))
+
+		By("Requiring verification of a chart with no provenance file")
+		_, err = locate("unsigned", keyringFile)
+		Expect(err).ToNot(BeNil())
+
+		_, err = locate("unsigned", "")
+		Expect(err).To(BeNil())
+		_, err = locate("unsigned", keyringFile)
+		Expect(err).ToNot(BeNil())
+	})
+})
@@ -0,0 +1,165 @@
+/*
+Copyright 2022-23. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/go-logr/logr"
+	"helm.sh/helm/v3/pkg/registry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	logs "github.com/projectsveltos/libsveltos/lib/logsettings"
+	configv1alpha1 "github.com/projectsveltos/sveltos-manager/api/v1alpha1"
+)
+
+// isOCIChart returns true if helm chart is stored in an OCI registry
+func isOCIChart(requestedChart *configv1alpha1.HelmChart) bool {
+	return registry.IsOCI(requestedChart.RepositoryURL)
+}
+
+// getOCIChartName returns the name used to locate a chart stored in an OCI registry
+// (oci://<registry>/<repository>/<chart>).
+// Same as for helm repositories, ChartName can be prefixed by RepositoryName.
+func getOCIChartName(requestedChart *configv1alpha1.HelmChart) string {
+	chartName := strings.TrimPrefix(requestedChart.ChartName, requestedChart.RepositoryName+"/")
+	return fmt.Sprintf("%s/%s", strings.TrimSuffix(requestedChart.RepositoryURL, "/"), chartName)
+}
+
+// getOCIChartRef returns the reference of a chart stored in an OCI registry
+// (oci://<registry>/<repository>/<chart>:<version>).
+// Returns an empty string for charts stored in helm repositories.
+func getOCIChartRef(requestedChart *configv1alpha1.HelmChart) string {
+	if !isOCIChart(requestedChart) {
+		return ""
+	}
+
+	return fmt.Sprintf("%s:%s", getOCIChartName(requestedChart), requestedChart.ChartVersion)
+}
+
+// getChartName returns the name used to locate the chart
+func getChartName(requestedChart *configv1alpha1.HelmChart) string {
+	if isOCIChart(requestedChart) {
+		return getOCIChartName(requestedChart)
+	}
+
+	return requestedChart.ChartName
+}
+
+// getRegistryHost returns the host of the OCI registry storing the chart
+func getRegistryHost(requestedChart *configv1alpha1.HelmChart) string {
+	host := strings.TrimPrefix(requestedChart.RepositoryURL, fmt.Sprintf("%s://", registry.OCIScheme))
+	return strings.Split(host, "/")[0]
+}
+
+// getRegistryCredentials returns username and password contained in the Secret referenced
+// by HelmChart CredentialsSecretRef. When Secret contains a bearer token, returned username
+// is blank and password is set to the token (blank username is how helm registry client
+// identifies tokens).
+// requestedChart must be resolved (see resolveHelmChart).
+func getRegistryCredentials(ctx context.Context, c client.Client,
+	requestedChart *configv1alpha1.HelmChart) (username, password string, err error) {
+
+	secret, err := getCredentialsSecret(ctx, c, requestedChart)
+	if err != nil || secret == nil {
+		return "", "", err
+	}
+
+	if token, ok := secret.Data[configv1alpha1.HelmRepositoryBearerTokenKey]; ok {
+		return "", string(token), nil
+	}
+
+	return string(secret.Data[configv1alpha1.HelmRepositoryUsernameKey]),
+		string(secret.Data[configv1alpha1.HelmRepositoryPasswordKey]), nil
+}
+
+// createRegistryCredentialsFile creates a credentials file (same format as docker config file)
+// containing the credentials to access the OCI registry host
+func createRegistryCredentialsFile(host, username, password string) (string, error) {
+	type authConfig struct {
+		Auth string `json:"auth"`
+	}
+	type configFile struct {
+		Auths map[string]authConfig `json:"auths"`
+	}
+
+	auth := base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("%s:%s", username, password)))
+	content, err := json.Marshal(configFile{Auths: map[string]authConfig{host: {Auth: auth}}})
+	if err != nil {
+		return "", err
+	}
+
+	credentialsFile, err := os.CreateTemp("", "registry-credentials")
+	if err != nil {
+		return "", err
+	}
+	defer credentialsFile.Close()
+
+	_, err = credentialsFile.Write(content)
+	if err != nil {
+		os.Remove(credentialsFile.Name())
+		return "", err
+	}
+
+	return credentialsFile.Name(), nil
+}
+
+// getRegistryClient returns the client used to pull a chart from an OCI registry.
+// Credentials, if any, are stored in a credentials file used only by this client.
+// Returned function removes such file and must be called once client is not needed anymore.
+// Returns a nil client for charts stored in helm repositories.
+// requestedChart must be resolved (see resolveHelmChart).
+func getRegistryClient(ctx context.Context, c client.Client, requestedChart *configv1alpha1.HelmChart,
+	logger logr.Logger) (*registry.Client, func(), error) {
+
+	cleanup := func() {}
+	if !isOCIChart(requestedChart) {
+		return nil, cleanup, nil
+	}
+
+	options := []registry.ClientOption{registry.ClientOptWriter(io.Discard)}
+
+	if requestedChart.CredentialsSecretRef != nil {
+		logger.V(logs.LogDebug).Info(fmt.Sprintf("using credentials from secret %s/%s",
+			requestedChart.CredentialsSecretRef.Namespace, requestedChart.CredentialsSecretRef.Name))
+		username, password, err := getRegistryCredentials(ctx, c, requestedChart)
+		if err != nil {
+			return nil, cleanup, err
+		}
+
+		credentialsFile, err := createRegistryCredentialsFile(getRegistryHost(requestedChart), username, password)
+		if err != nil {
+			return nil, cleanup, err
+		}
+		cleanup = func() { os.Remove(credentialsFile) }
+		options = append(options, registry.ClientOptCredentialsFile(credentialsFile))
+	}
+
+	registryClient, err := registry.NewClient(options...)
+	if err != nil {
+		cleanup()
+		return nil, func() {}, err
+	}
+
+	return registryClient, cleanup, nil
+}
@@ -0,0 +1,247 @@
+/*
+Copyright 2022-23. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers_test
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/distribution/distribution/v3/configuration"
+	dockerregistry "github.com/distribution/distribution/v3/registry"
+	_ "github.com/distribution/distribution/v3/registry/auth/htpasswd"
+	_ "github.com/distribution/distribution/v3/registry/storage/driver/inmemory"
+	"golang.org/x/crypto/bcrypt"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/cli"
+	"helm.sh/helm/v3/pkg/registry"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2/klogr"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	configv1alpha1 "github.com/projectsveltos/sveltos-manager/api/v1alpha1"
+	"github.com/projectsveltos/sveltos-manager/controllers"
+)
+
+const (
+	registryUsername = "sveltos"
+	registryPassword = "projectsveltos"
+)
+
+var _ = Describe("Helm OCI registry", func() {
+	var helmChart *configv1alpha1.HelmChart
+
+	BeforeEach(func() {
+		helmChart = &configv1alpha1.HelmChart{
+			RepositoryURL:    "oci://registry-1.docker.io/bitnamicharts",
+			RepositoryName:   "bitnamicharts",
+			ChartName:        "bitnamicharts/vault",
+			ChartVersion:     "0.2.1",
+			ReleaseName:      "vault",
+			ReleaseNamespace: "vault",
+		}
+	})
+
+	It("getChartName and getOCIChartRef return OCI chart references", func() {
+		Expect(controllers.IsOCIChart(helmChart)).To(BeTrue())
+		Expect(controllers.GetChartName(helmChart)).To(Equal("oci://registry-1.docker.io/bitnamicharts/vault"))
+		Expect(controllers.GetOCIChartRef(helmChart)).To(Equal("oci://registry-1.docker.io/bitnamicharts/vault:0.2.1"))
+
+		// ChartName not prefixed by RepositoryName
+		helmChart.ChartName = "vault"
+		helmChart.RepositoryURL = "oci://registry-1.docker.io/bitnamicharts/"
+		Expect(controllers.GetChartName(helmChart)).To(Equal("oci://registry-1.docker.io/bitnamicharts/vault"))
+
+		helmChart.RepositoryURL = "https://helm.releases.hashicorp.com"
+		helmChart.ChartName = "hashicorp/vault"
+		Expect(controllers.IsOCIChart(helmChart)).To(BeFalse())
+		Expect(controllers.GetChartName(helmChart)).To(Equal(helmChart.ChartName))
+		Expect(controllers.GetOCIChartRef(helmChart)).To(BeEmpty())
+	})
+
+	It("HelmHash considers OCI registry credentials", func() {
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: randomString(), Name: randomString()},
+			Data: map[string][]byte{
+				"username": []byte(registryUsername),
+				"password": []byte(registryPassword),
+			},
+		}
+		helmChart.CredentialsSecretRef = &corev1.ObjectReference{Namespace: secret.Namespace, Name: secret.Name}
+
+		clusterSummary := &configv1alpha1.ClusterSummary{
+			ObjectMeta: metav1.ObjectMeta{Namespace: randomString(), Name: randomString()},
+			Spec: configv1alpha1.ClusterSummarySpec{
+				ClusterProfileSpec: configv1alpha1.ClusterProfileSpec{
+					HelmCharts: []configv1alpha1.HelmChart{*helmChart},
+				},
+			},
+		}
+
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(clusterSummary, secret).Build()
+		clusterSummaryScope := getClusterSummaryScope(c, klogr.New(), &configv1alpha1.ClusterProfile{}, clusterSummary)
+
+		hash, err := controllers.HelmHash(context.TODO(), c, clusterSummaryScope, klogr.New())
+		Expect(err).To(BeNil())
+
+		secret.Data["password"] = []byte(randomString())
+		Expect(c.Update(context.TODO(), secret)).To(Succeed())
+
+		newHash, err := controllers.HelmHash(context.TODO(), c, clusterSummaryScope, klogr.New())
+		Expect(err).To(BeNil())
+		Expect(newHash).ToNot(Equal(hash))
+	})
+
+	It("getRegistryClient returns a client able to pull charts from an OCI registry", func() {
+		workspace, err := os.MkdirTemp("", "oci-registry")
+		Expect(err).To(BeNil())
+		defer os.RemoveAll(workspace)
+
+		registryHost := startRegistry(workspace)
+
+		chartVersion := "0.1.0"
+		chartName := randomString()
+		pushChart(workspace, registryHost, chartName, chartVersion)
+
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: randomString(), Name: randomString()},
+			Data: map[string][]byte{
+				"username": []byte(registryUsername),
+				"password": []byte(registryPassword),
+			},
+		}
+
+		helmChart.RepositoryURL = fmt.Sprintf("oci://%s/charts", registryHost)
+		helmChart.RepositoryName = "charts"
+		helmChart.ChartName = chartName
+		helmChart.ChartVersion = chartVersion
+		helmChart.CredentialsSecretRef = &corev1.ObjectReference{Namespace: secret.Namespace, Name: secret.Name}
+
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).Build()
+
+		registryClient, cleanup, err := controllers.GetRegistryClient(context.TODO(), c, helmChart, klogr.New())
+		Expect(err).To(BeNil())
+		defer cleanup()
+
+		settings := cli.New()
+		settings.RepositoryCache = workspace
+		installObject := action.NewInstall(&action.Configuration{RegistryClient: registryClient})
+		installObject.Version = helmChart.ChartVersion
+		chartPath, err := installObject.ChartPathOptions.LocateChart(controllers.GetChartName(helmChart), settings)
+		Expect(err).To(BeNil())
+
+		chartRequested, err := loader.Load(chartPath)
+		Expect(err).To(BeNil())
+		Expect(chartRequested.Metadata.Name).To(Equal(chartName))
+		Expect(chartRequested.Metadata.Version).To(Equal(chartVersion))
+
+		By("Using wrong credentials pulling chart fails")
+		secret.Data["password"] = []byte(randomString())
+		Expect(c.Update(context.TODO(), secret)).To(Succeed())
+
+		registryClient, wrongCredentialsCleanup, err := controllers.GetRegistryClient(context.TODO(), c, helmChart, klogr.New())
+		Expect(err).To(BeNil())
+		defer wrongCredentialsCleanup()
+
+		Expect(os.RemoveAll(filepath.Join(workspace, fmt.Sprintf("%s-%s.tgz", chartName, chartVersion)))).To(Succeed())
+		installObject = action.NewInstall(&action.Configuration{RegistryClient: registryClient})
+		installObject.Version = helmChart.ChartVersion
+		_, err = installObject.ChartPathOptions.LocateChart(controllers.GetChartName(helmChart), settings)
+		Expect(err).ToNot(BeNil())
+	})
+})
+
+// startRegistry starts an in-process OCI registry requiring basic authentication.
+// Returns registry host.
+func startRegistry(workspace string) string {
+	password, err := bcrypt.GenerateFromPassword([]byte(registryPassword), bcrypt.DefaultCost)
+	Expect(err).To(BeNil())
+	htpasswdPath := filepath.Join(workspace, "htpasswd")
+	Expect(os.WriteFile(htpasswdPath,
+		[]byte(fmt.Sprintf("%s:%s\n", registryUsername, string(password))), 0600)).To(Succeed())
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	Expect(err).To(BeNil())
+	registryHost := listener.Addr().String()
+	Expect(listener.Close()).To(Succeed())
+
+	config := &configuration.Configuration{}
+	config.HTTP.Addr = registryHost
+	config.Storage = map[string]configuration.Parameters{"inmemory": map[string]interface{}{}}
+	config.Auth = configuration.Auth{
+		"htpasswd": configuration.Parameters{
+			"realm": "localhost",
+			"path":  htpasswdPath,
+		},
+	}
+	config.Log.Level = "error"
+
+	ociRegistry, err := dockerregistry.NewRegistry(context.TODO(), config)
+	Expect(err).To(BeNil())
+	go func() {
+		_ = ociRegistry.ListenAndServe()
+	}()
+
+	Eventually(func() error {
+		conn, err := net.Dial("tcp", registryHost)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}, timeout, pollingInterval).Should(BeNil())
+
+	return registryHost
+}
+
+// pushChart packages a chart and pushes it to the OCI registry
+func pushChart(workspace, registryHost, chartName, chartVersion string) {
+	ch := &chart.Chart{
+		Metadata: &chart.Metadata{
+			APIVersion: chart.APIVersionV2,
+			Name:       chartName,
+			Version:    chartVersion,
+		},
+	}
+	chartDir, err := os.MkdirTemp(workspace, "chart")
+	Expect(err).To(BeNil())
+	chartPath, err := chartutil.Save(ch, chartDir)
+	Expect(err).To(BeNil())
+	data, err := os.ReadFile(chartPath)
+	Expect(err).To(BeNil())
+
+	helmChart := &configv1alpha1.HelmChart{
+		RepositoryURL: fmt.Sprintf("oci://%s/charts", registryHost),
+	}
+	credentialsFile, err := controllers.CreateRegistryCredentialsFile(controllers.GetRegistryHost(helmChart),
+		registryUsername, registryPassword)
+	Expect(err).To(BeNil())
+	defer os.Remove(credentialsFile)
+
+	registryClient, err := registry.NewClient(registry.ClientOptCredentialsFile(credentialsFile))
+	Expect(err).To(BeNil())
+	_, err = registryClient.Push(data, fmt.Sprintf("%s/charts/%s:%s", registryHost, chartName, chartVersion))
+	Expect(err).To(BeNil())
+}
@@ -0,0 +1,281 @@
+/*
+Copyright 2022-23. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/gdexlab/go-render/render"
+	"helm.sh/helm/v3/pkg/cli"
+	"helm.sh/helm/v3/pkg/repo"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	libsveltosv1alpha1 "github.com/projectsveltos/libsveltos/api/v1alpha1"
+	libsveltosset "github.com/projectsveltos/libsveltos/lib/set"
+	configv1alpha1 "github.com/projectsveltos/sveltos-manager/api/v1alpha1"
+)
+
+const (
+	// tlsDirectory is the directory, relative to the helm repository config directory,
+	// where TLS material of each helm repository is stored
+	tlsDirectory = "tls"
+)
+
+// getHelmRepository returns the HelmRepository referenced by helm chart
+func getHelmRepository(ctx context.Context, c client.Client,
+	requestedChart *configv1alpha1.HelmChart) (*configv1alpha1.HelmRepository, error) {
+
+	helmRepository := &configv1alpha1.HelmRepository{}
+	err := c.Get(ctx, types.NamespacedName{Name: requestedChart.HelmRepositoryRef}, helmRepository)
+	if err != nil {
+		return nil, err
+	}
+
+	return helmRepository, nil
+}
+
+// resolveHelmChart returns the helm chart to deploy. When helm chart references a HelmRepository,
+// returned copy has repository URL set from such HelmRepository and, unless helm chart
+// directly references a Secret with credentials, credentials set from HelmRepository Secret.
+func resolveHelmChart(ctx context.Context, c client.Client,
+	requestedChart *configv1alpha1.HelmChart) (*configv1alpha1.HelmChart, error) {
+
+	if requestedChart.HelmRepositoryRef == "" {
+		return requestedChart, nil
+	}
+
+	helmRepository, err := getHelmRepository(ctx, c, requestedChart)
+	if err != nil {
+		return nil, err
+	}
+
+	resolvedChart := requestedChart.DeepCopy()
+	resolvedChart.RepositoryURL = helmRepository.Spec.URL
+	if resolvedChart.CredentialsSecretRef == nil {
+		resolvedChart.CredentialsSecretRef = helmRepository.Spec.SecretRef
+	}
+
+	return resolvedChart, nil
+}
+
+// getCredentialsSecret returns the Secret containing credentials and TLS material to
+// access the repository helm chart is stored in. Returns nil if no Secret is referenced.
+// requestedChart must be resolved (see resolveHelmChart).
+func getCredentialsSecret(ctx context.Context, c client.Client,
+	requestedChart *configv1alpha1.HelmChart) (*corev1.Secret, error) {
+
+	secretRef := requestedChart.CredentialsSecretRef
+	if secretRef == nil {
+		return nil, nil
+	}
+
+	secret := &corev1.Secret{}
+	err := c.Get(ctx, types.NamespacedName{Namespace: secretRef.Namespace, Name: secretRef.Name}, secret)
+	if err != nil {
+		return nil, err
+	}
+
+	return secret, nil
+}
+
+// getRepositoryEntry returns the helm repository entry used to add/update the repository
+// helm chart is stored in. Credentials and TLS material are taken from the referenced Secret.
+// TLS material is stored in files, one directory per repository, in the helm repository config
+// directory.
+// requestedChart must be resolved (see resolveHelmChart).
+func getRepositoryEntry(ctx context.Context, c client.Client, settings *cli.EnvSettings,
+	requestedChart *configv1alpha1.HelmChart) (*repo.Entry, error) {
+
+	entry := &repo.Entry{Name: requestedChart.RepositoryName, URL: requestedChart.RepositoryURL}
+
+	if requestedChart.HelmRepositoryRef != "" {
+		helmRepository, err := getHelmRepository(ctx, c, requestedChart)
+		if err != nil {
+			return nil, err
+		}
+		entry.InsecureSkipTLSverify = helmRepository.Spec.InsecureSkipTLSVerify
+		entry.PassCredentialsAll = helmRepository.Spec.PassCredentialsAll
+	}
+
+	secret, err := getCredentialsSecret(ctx, c, requestedChart)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil {
+		return entry, nil
+	}
+
+	entry.Username = string(secret.Data[configv1alpha1.HelmRepositoryUsernameKey])
+	entry.Password = string(secret.Data[configv1alpha1.HelmRepositoryPasswordKey])
+
+	tlsDir := filepath.Join(filepath.Dir(settings.RepositoryConfig), tlsDirectory, entry.Name)
+	entry.CAFile, err = writeTLSFile(tlsDir, configv1alpha1.HelmRepositoryCAKey,
+		secret.Data[configv1alpha1.HelmRepositoryCAKey])
+	if err != nil {
+		return nil, err
+	}
+	entry.CertFile, err = writeTLSFile(tlsDir, configv1alpha1.HelmRepositoryCertKey,
+		secret.Data[configv1alpha1.HelmRepositoryCertKey])
+	if err != nil {
+		return nil, err
+	}
+	entry.KeyFile, err = writeTLSFile(tlsDir, configv1alpha1.HelmRepositoryKeyKey,
+		secret.Data[configv1alpha1.HelmRepositoryKeyKey])
+	if err != nil {
+		return nil, err
+	}
+
+	return entry, nil
+}
+
+// writeTLSFile writes data to file name in directory dir. File is first written
+// to a temporary file and then renamed, so concurrent readers never see a partial file.
+// Returns file path, or an empty string if there is no data.
+func writeTLSFile(dir, name string, data []byte) (string, error) {
+	if len(data) == 0 {
+		return "", nil
+	}
+
+	err := os.MkdirAll(dir, os.ModePerm)
+	if err != nil {
+		return "", err
+	}
+
+	tmpFile, err := os.CreateTemp(dir, name)
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmpFile.Name())
+
+	_, err = tmpFile.Write(data)
+	if closeErr := tmpFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", err
+	}
+
+	fileName := filepath.Join(dir, name)
+	err = os.Rename(tmpFile.Name(), fileName)
+	if err != nil {
+		return "", err
+	}
+
+	return fileName, nil
+}
+
+// getRepositoryEntryKey returns a key identifying a repository entry. Key changes every
+// time any entry setting (for instance credentials) changes.
+func getRepositoryEntryKey(entry *repo.Entry) string {
+	h := sha256.New()
+	h.Write([]byte(render.AsCode(*entry)))
+	return fmt.Sprintf("%s:%s:%x", entry.URL, entry.Name, h.Sum(nil))
+}
+
+// getRepositoryConfig returns a string representing everything used to access the repository
+// helm chart is stored in: repository URL, HelmRepository settings and content of the Secret
+// containing credentials and TLS material.
+func getRepositoryConfig(ctx context.Context, c client.Client,
+	requestedChart *configv1alpha1.HelmChart) (string, error) {
+
+	var config string
+
+	if requestedChart.HelmRepositoryRef != "" {
+		helmRepository, err := getHelmRepository(ctx, c, requestedChart)
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				return config, nil
+			}
+			return "", err
+		}
+		config += render.AsCode(helmRepository.Spec)
+	}
+
+	resolvedChart, err := resolveHelmChart(ctx, c, requestedChart)
+	if err != nil {
+		return "", err
+	}
+	config += getOCIChartRef(resolvedChart)
+
+	secret, err := getCredentialsSecret(ctx, c, resolvedChart)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return "", err
+	}
+	if secret != nil {
+		config += render.AsCode(secret.Data)
+	}
+
+	return config, nil
+}
+
+// getHelmReferences returns the HelmRepositories, credentials and keyring Secrets and ConfigMaps/Secrets
+// containing values referenced by the helm charts of a ClusterSummary
+func getHelmReferences(ctx context.Context, c client.Client,
+	clusterSummary *configv1alpha1.ClusterSummary) (*libsveltosset.Set, error) {
+
+	references := &libsveltosset.Set{}
+	for i := range clusterSummary.Spec.ClusterProfileSpec.HelmCharts {
+		currentChart := &clusterSummary.Spec.ClusterProfileSpec.HelmCharts[i]
+
+		for j := range currentChart.ValuesFrom {
+			references.Insert(getValuesFromReference(clusterSummary.Spec.ClusterNamespace, &currentChart.ValuesFrom[j]))
+		}
+
+		if currentChart.Verify != nil {
+			references.Insert(&corev1.ObjectReference{
+				APIVersion: corev1.SchemeGroupVersion.String(),
+				Kind:       string(libsveltosv1alpha1.SecretReferencedResourceKind),
+				Namespace:  currentChart.Verify.KeyringSecretRef.Namespace,
+				Name:       currentChart.Verify.KeyringSecretRef.Name,
+			})
+		}
+
+		if currentChart.HelmRepositoryRef != "" {
+			references.Insert(&corev1.ObjectReference{
+				APIVersion: configv1alpha1.GroupVersion.String(),
+				Kind:       configv1alpha1.HelmRepositoryKind,
+				Name:       currentChart.HelmRepositoryRef,
+			})
+		}
+
+		resolvedChart, err := resolveHelmChart(ctx, c, currentChart)
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return nil, err
+		}
+
+		if resolvedChart.CredentialsSecretRef != nil {
+			references.Insert(&corev1.ObjectReference{
+				APIVersion: corev1.SchemeGroupVersion.String(),
+				Kind:       string(libsveltosv1alpha1.SecretReferencedResourceKind),
+				Namespace:  resolvedChart.CredentialsSecretRef.Namespace,
+				Name:       resolvedChart.CredentialsSecretRef.Name,
+			})
+		}
+	}
+
+	return references, nil
+}
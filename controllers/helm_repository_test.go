@@ -0,0 +1,221 @@
+/*
+Copyright 2022-23. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"helm.sh/helm/v3/pkg/cli"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2/klogr"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	libsveltosv1alpha1 "github.com/projectsveltos/libsveltos/api/v1alpha1"
+	libsveltosset "github.com/projectsveltos/libsveltos/lib/set"
+	configv1alpha1 "github.com/projectsveltos/sveltos-manager/api/v1alpha1"
+	"github.com/projectsveltos/sveltos-manager/controllers"
+)
+
+var _ = Describe("HelmRepository", func() {
+	var helmRepository *configv1alpha1.HelmRepository
+	var secret *corev1.Secret
+	var helmChart *configv1alpha1.HelmChart
+
+	BeforeEach(func() {
+		secret = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: randomString(), Name: randomString()},
+			Data: map[string][]byte{
+				configv1alpha1.HelmRepositoryUsernameKey: []byte(randomString()),
+				configv1alpha1.HelmRepositoryPasswordKey: []byte(randomString()),
+			},
+		}
+
+		helmRepository = &configv1alpha1.HelmRepository{
+			ObjectMeta: metav1.ObjectMeta{Name: randomString()},
+			Spec: configv1alpha1.HelmRepositorySpec{
+				URL:                   "https://charts.bitnami.com/bitnami",
+				SecretRef:             &corev1.ObjectReference{Namespace: secret.Namespace, Name: secret.Name},
+				InsecureSkipTLSVerify: true,
+				PassCredentialsAll:    true,
+			},
+		}
+
+		helmChart = &configv1alpha1.HelmChart{
+			HelmRepositoryRef: helmRepository.Name,
+			RepositoryName:    "bitnami",
+			ChartName:         "bitnami/redis",
+			ChartVersion:      "17.7.1",
+			ReleaseName:       "redis",
+			ReleaseNamespace:  "redis",
+		}
+	})
+
+	It("resolveHelmChart takes repository URL and credentials from HelmRepository", func() {
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(helmRepository, secret).Build()
+
+		resolvedChart, err := controllers.ResolveHelmChart(context.TODO(), c, helmChart)
+		Expect(err).To(BeNil())
+		Expect(resolvedChart.RepositoryURL).To(Equal(helmRepository.Spec.URL))
+		Expect(resolvedChart.CredentialsSecretRef).ToNot(BeNil())
+		Expect(resolvedChart.CredentialsSecretRef.Name).To(Equal(secret.Name))
+		// Original chart is not modified
+		Expect(helmChart.RepositoryURL).To(BeEmpty())
+
+		By("Using credentials directly referenced by helm chart")
+		helmChart.CredentialsSecretRef = &corev1.ObjectReference{Namespace: randomString(), Name: randomString()}
+		resolvedChart, err = controllers.ResolveHelmChart(context.TODO(), c, helmChart)
+		Expect(err).To(BeNil())
+		Expect(resolvedChart.CredentialsSecretRef.Name).To(Equal(helmChart.CredentialsSecretRef.Name))
+
+		By("Referencing a non existing HelmRepository")
+		helmChart.HelmRepositoryRef = randomString()
+		_, err = controllers.ResolveHelmChart(context.TODO(), c, helmChart)
+		Expect(err).ToNot(BeNil())
+	})
+
+	It("getRepositoryEntry sets credentials and TLS material", func() {
+		caData := []byte(randomString())
+		secret.Data[configv1alpha1.HelmRepositoryCAKey] = caData
+
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(helmRepository, secret).Build()
+
+		workspace, err := os.MkdirTemp("", "helm-repository")
+		Expect(err).To(BeNil())
+		defer os.RemoveAll(workspace)
+
+		settings := cli.New()
+		settings.RepositoryConfig = filepath.Join(workspace, "repositories.yaml")
+
+		resolvedChart, err := controllers.ResolveHelmChart(context.TODO(), c, helmChart)
+		Expect(err).To(BeNil())
+
+		entry, err := controllers.GetRepositoryEntry(context.TODO(), c, settings, resolvedChart)
+		Expect(err).To(BeNil())
+		Expect(entry.Name).To(Equal(helmChart.RepositoryName))
+		Expect(entry.URL).To(Equal(helmRepository.Spec.URL))
+		Expect(entry.Username).To(Equal(string(secret.Data[configv1alpha1.HelmRepositoryUsernameKey])))
+		Expect(entry.Password).To(Equal(string(secret.Data[configv1alpha1.HelmRepositoryPasswordKey])))
+		Expect(entry.InsecureSkipTLSverify).To(BeTrue())
+		Expect(entry.PassCredentialsAll).To(BeTrue())
+		Expect(entry.CertFile).To(BeEmpty())
+		Expect(entry.KeyFile).To(BeEmpty())
+		Expect(entry.CAFile).ToNot(BeEmpty())
+
+		content, err := os.ReadFile(entry.CAFile)
+		Expect(err).To(BeNil())
+		Expect(content).To(Equal(caData))
+	})
+
+	It("getRegistryCredentials returns bearer token with blank username", func() {
+		token := randomString()
+		secret.Data = map[string][]byte{configv1alpha1.HelmRepositoryBearerTokenKey: []byte(token)}
+		helmRepository.Spec.URL = "oci://registry-1.docker.io/bitnamicharts"
+
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(helmRepository, secret).Build()
+
+		resolvedChart, err := controllers.ResolveHelmChart(context.TODO(), c, helmChart)
+		Expect(err).To(BeNil())
+
+		username, password, err := controllers.GetRegistryCredentials(context.TODO(), c, resolvedChart)
+		Expect(err).To(BeNil())
+		Expect(username).To(BeEmpty())
+		Expect(password).To(Equal(token))
+	})
+
+	It("HelmHash changes when HelmRepository or its credentials change", func() {
+		clusterSummary := &configv1alpha1.ClusterSummary{
+			ObjectMeta: metav1.ObjectMeta{Namespace: randomString(), Name: randomString()},
+			Spec: configv1alpha1.ClusterSummarySpec{
+				ClusterProfileSpec: configv1alpha1.ClusterProfileSpec{
+					HelmCharts: []configv1alpha1.HelmChart{*helmChart},
+				},
+			},
+		}
+
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(clusterSummary, helmRepository, secret).Build()
+		clusterSummaryScope := getClusterSummaryScope(c, klogr.New(), &configv1alpha1.ClusterProfile{}, clusterSummary)
+
+		hash, err := controllers.HelmHash(context.TODO(), c, clusterSummaryScope, klogr.New())
+		Expect(err).To(BeNil())
+
+		By("Rotating credentials")
+		secret.Data[configv1alpha1.HelmRepositoryPasswordKey] = []byte(randomString())
+		Expect(c.Update(context.TODO(), secret)).To(Succeed())
+
+		newHash, err := controllers.HelmHash(context.TODO(), c, clusterSummaryScope, klogr.New())
+		Expect(err).To(BeNil())
+		Expect(newHash).ToNot(Equal(hash))
+
+		By("Changing HelmRepository URL")
+		helmRepository.Spec.URL = "https://charts.bitnami.com/bitnami/mirror"
+		Expect(c.Update(context.TODO(), helmRepository)).To(Succeed())
+
+		hash, err = controllers.HelmHash(context.TODO(), c, clusterSummaryScope, klogr.New())
+		Expect(err).To(BeNil())
+		Expect(hash).ToNot(Equal(newHash))
+	})
+
+	It("getHelmReferences returns HelmRepositories and Secrets ClusterSummary must be requeued for", func() {
+		clusterSummary := &configv1alpha1.ClusterSummary{
+			ObjectMeta: metav1.ObjectMeta{Namespace: randomString(), Name: randomString()},
+			Spec: configv1alpha1.ClusterSummarySpec{
+				ClusterProfileSpec: configv1alpha1.ClusterProfileSpec{
+					HelmCharts: []configv1alpha1.HelmChart{*helmChart},
+				},
+			},
+		}
+
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(helmRepository, secret).Build()
+
+		references, err := controllers.GetHelmReferences(context.TODO(), c, clusterSummary)
+		Expect(err).To(BeNil())
+		Expect(references.Len()).To(Equal(2))
+		Expect(references.Has(&corev1.ObjectReference{
+			APIVersion: configv1alpha1.GroupVersion.String(), Kind: configv1alpha1.HelmRepositoryKind,
+			Name: helmRepository.Name,
+		})).To(BeTrue())
+		Expect(references.Has(&corev1.ObjectReference{
+			APIVersion: corev1.SchemeGroupVersion.String(), Kind: string(libsveltosv1alpha1.SecretReferencedResourceKind),
+			Namespace: secret.Namespace, Name: secret.Name,
+		})).To(BeTrue())
+
+		reconciler := getClusterSummaryReconciler(c, nil)
+		for _, reference := range references.Items() {
+			tmpReference := reference
+			reconciler.ReferenceMap[tmpReference] = &libsveltosset.Set{}
+			reconciler.ReferenceMap[tmpReference].Insert(&corev1.ObjectReference{
+				APIVersion: configv1alpha1.GroupVersion.String(), Kind: configv1alpha1.ClusterSummaryKind,
+				Namespace: clusterSummary.Namespace, Name: clusterSummary.Name,
+			})
+		}
+
+		requests := controllers.RequeueClusterSummaryForReference(reconciler, helmRepository)
+		Expect(requests).To(HaveLen(1))
+		Expect(requests[0].Name).To(Equal(clusterSummary.Name))
+		Expect(requests[0].Namespace).To(Equal(clusterSummary.Namespace))
+
+		requests = controllers.RequeueClusterSummaryForReference(reconciler, secret)
+		Expect(requests).To(HaveLen(1))
+		Expect(requests[0].Name).To(Equal(clusterSummary.Name))
+	})
+})
@@ -0,0 +1,251 @@
+/*
+Copyright 2022-23. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	libsveltosv1alpha1 "github.com/projectsveltos/libsveltos/api/v1alpha1"
+	logs "github.com/projectsveltos/libsveltos/lib/logsettings"
+	configv1alpha1 "github.com/projectsveltos/sveltos-manager/api/v1alpha1"
+)
+
+const (
+	// defaultValuesKey is the key containing values when ValuesFrom Key is not set
+	defaultValuesKey = "values.yaml"
+)
+
+// valuesSchemaError is returned when helm values do not match the chart values schema
+type valuesSchemaError struct {
+	err error
+}
+
+func (e *valuesSchemaError) Error() string {
+	return fmt.Sprintf("values do not match chart values schema: %v", e.err)
+}
+
+func (e *valuesSchemaError) Unwrap() error {
+	return e.err
+}
+
+// getValuesFromReference returns a reference to the ConfigMap/Secret valuesFrom points to
+func getValuesFromReference(clusterNamespace string, valuesFrom *configv1alpha1.ValuesFrom) *corev1.ObjectReference {
+	return &corev1.ObjectReference{
+		APIVersion: corev1.SchemeGroupVersion.String(),
+		Kind:       valuesFrom.Kind,
+		Namespace:  getReferenceResourceNamespace(clusterNamespace, valuesFrom.Namespace),
+		Name:       valuesFrom.Name,
+	}
+}
+
+func getValuesFromKey(valuesFrom *configv1alpha1.ValuesFrom) string {
+	if valuesFrom.Key == "" {
+		return defaultValuesKey
+	}
+	return valuesFrom.Key
+}
+
+// fetchValuesFrom returns the content of the key valuesFrom points to.
+// Returns false if either ConfigMap/Secret or key do not exist.
+func fetchValuesFrom(ctx context.Context, c client.Client, clusterNamespace string,
+	valuesFrom *configv1alpha1.ValuesFrom) (content string, found bool, err error) {
+
+	ref := getValuesFromReference(clusterNamespace, valuesFrom)
+	name := types.NamespacedName{Namespace: ref.Namespace, Name: ref.Name}
+	key := getValuesFromKey(valuesFrom)
+
+	if valuesFrom.Kind == string(libsveltosv1alpha1.ConfigMapReferencedResourceKind) {
+		configMap, err := getConfigMap(ctx, c, name)
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				return "", false, nil
+			}
+			return "", false, err
+		}
+		content, found = configMap.Data[key]
+		return content, found, nil
+	}
+
+	secret, err := getSecret(ctx, c, name)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return "", false, nil
+		}
+		return "", false, err
+	}
+	data, found := secret.Data[key]
+	return string(data), found, nil
+}
+
+// getValuesFrom returns the values helm chart ValuesFrom point to, merged in order.
+// Templated values are instantiated.
+func getValuesFrom(ctx context.Context, clusterSummary *configv1alpha1.ClusterSummary,
+	requestedChart *configv1alpha1.HelmChart, logger logr.Logger) (chartutil.Values, error) {
+
+	values := chartutil.Values{}
+	for i := range requestedChart.ValuesFrom {
+		valuesFrom := &requestedChart.ValuesFrom[i]
+
+		content, found, err := fetchValuesFrom(ctx, getManagementClusterClient(),
+			clusterSummary.Spec.ClusterNamespace, valuesFrom)
+		if err != nil {
+			return nil, err
+		}
+		if !found {
+			ref := getValuesFromReference(clusterSummary.Spec.ClusterNamespace, valuesFrom)
+			if valuesFrom.Optional {
+				logger.V(logs.LogDebug).Info(fmt.Sprintf("optional values %s %s/%s key %s not found",
+					ref.Kind, ref.Namespace, ref.Name, getValuesFromKey(valuesFrom)))
+				continue
+			}
+			return nil, fmt.Errorf("values %s %s/%s key %s not found",
+				ref.Kind, ref.Namespace, ref.Name, getValuesFromKey(valuesFrom))
+		}
+
+		if valuesFrom.Template {
+			content, err = instantiateTemplateValues(ctx, getManagementClusterConfig(), getManagementClusterClient(),
+				clusterSummary.Spec.ClusterType, clusterSummary.Spec.ClusterNamespace, clusterSummary.Spec.ClusterName,
+				requestedChart.ChartName, content, requestedChart.SecretRef, logger)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		currentValues, err := chartutil.ReadValues([]byte(content))
+		if err != nil {
+			return nil, err
+		}
+		values = mergeValues(values, currentValues)
+	}
+
+	return values, nil
+}
+
+// mergeValues merges src into dst. Nested maps are merged, any other value in src
+// overrides the one in dst.
+func mergeValues(dst, src map[string]interface{}) map[string]interface{} {
+	for k, v := range src {
+		if srcMap, ok := v.(map[string]interface{}); ok {
+			if dstMap, ok := dst[k].(map[string]interface{}); ok {
+				dst[k] = mergeValues(dstMap, srcMap)
+				continue
+			}
+		}
+		dst[k] = v
+	}
+	return dst
+}
+
+// getValuesFromConfig returns a string representing the content of all keys helm chart
+// ValuesFrom point to
+func getValuesFromConfig(ctx context.Context, c client.Client, clusterNamespace string,
+	requestedChart *configv1alpha1.HelmChart) (string, error) {
+
+	var config string
+	for i := range requestedChart.ValuesFrom {
+		content, _, err := fetchValuesFrom(ctx, c, clusterNamespace, &requestedChart.ValuesFrom[i])
+		if err != nil {
+			return "", err
+		}
+		config += content
+	}
+
+	return config, nil
+}
+
+// validateValuesAgainstSchema validates values against the values.schema.json of the chart
+// and of its (enabled) dependencies. Chart default values are considered.
+// Returns a valuesSchemaError if values do not match the schema.
+func validateValuesAgainstSchema(chartRequested *chart.Chart, values chartutil.Values) error {
+	err := chartutil.ProcessDependencies(chartRequested, values)
+	if err != nil {
+		return err
+	}
+
+	finalValues, err := chartutil.CoalesceValues(chartRequested, values)
+	if err != nil {
+		return err
+	}
+
+	err = chartutil.ValidateAgainstSchema(chartRequested, finalValues)
+	if err != nil {
+		return &valuesSchemaError{err: err}
+	}
+
+	return nil
+}
+
+// validateValues locates and loads the helm chart, then validates values against its schema.
+// Returns a valuesSchemaError if values do not match the schema.
+// requestedChart must be resolved (see resolveHelmChart).
+func validateValues(ctx context.Context, requestedChart *configv1alpha1.HelmChart, values chartutil.Values,
+	logger logr.Logger) error {
+
+	logger.V(logs.LogDebug).Info("validating values against chart schema")
+
+	settings := getSettings()
+	err := prepareRepository(ctx, settings, requestedChart, logger)
+	if err != nil {
+		return err
+	}
+
+	registryClient, cleanup, err := getRegistryClient(ctx, getManagementClusterClient(), requestedChart, logger)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	keyring, cleanupKeyring, err := getKeyring(ctx, getManagementClusterClient(), requestedChart, logger)
+	if err != nil {
+		return err
+	}
+	defer cleanupKeyring()
+
+	// Chart provenance is verified before any chart dependency is downloaded
+	installObject := action.NewInstall(&action.Configuration{RegistryClient: registryClient})
+	installObject.Version = requestedChart.ChartVersion
+	applyVerifyOptions(&installObject.ChartPathOptions, keyring)
+	cp, err := helmChartCache.locateChart(&installObject.ChartPathOptions, requestedChart.RepositoryURL,
+		getUploadedChartName(getChartName(requestedChart)), settings, logger)
+	if err != nil {
+		return err
+	}
+
+	chartRequested, err := loader.Load(cp)
+	if err != nil {
+		return err
+	}
+
+	chartRequested, err = resolveChartDependencies(chartRequested, cp, installObject.Keyring, settings,
+		registryClient, logger)
+	if err != nil {
+		return err
+	}
+
+	return validateValuesAgainstSchema(chartRequested, values)
+}
@@ -0,0 +1,185 @@
+/*
+Copyright 2022-23. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chartutil"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2/klogr"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	libsveltosv1alpha1 "github.com/projectsveltos/libsveltos/api/v1alpha1"
+	configv1alpha1 "github.com/projectsveltos/sveltos-manager/api/v1alpha1"
+	"github.com/projectsveltos/sveltos-manager/controllers"
+)
+
+var _ = Describe("Helm values", func() {
+	var clusterNamespace string
+	var configMap *corev1.ConfigMap
+	var secret *corev1.Secret
+
+	BeforeEach(func() {
+		clusterNamespace = randomString()
+
+		configMap = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: clusterNamespace, Name: randomString()},
+			Data: map[string]string{
+				"values.yaml": "replicaCount: 1",
+			},
+		}
+
+		secret = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: randomString(), Name: randomString()},
+			Type:       libsveltosv1alpha1.ClusterProfileSecretType,
+			Data: map[string][]byte{
+				"password.yaml": []byte("auth:\n  password: " + randomString()),
+			},
+		}
+	})
+
+	It("mergeValues merges nested maps with later values taking precedence", func() {
+		dst, err := chartutil.ReadValues([]byte("replicaCount: 1\nimage:\n  repository: nginx\n  tag: \"1.0\""))
+		Expect(err).To(BeNil())
+		src, err := chartutil.ReadValues([]byte("replicaCount: 3\nimage:\n  tag: \"2.0\""))
+		Expect(err).To(BeNil())
+
+		values := chartutil.Values(controllers.MergeValues(dst, src))
+		Expect(values["replicaCount"]).To(Equal(float64(3)))
+		repository, err := values.PathValue("image.repository")
+		Expect(err).To(BeNil())
+		Expect(repository).To(Equal("nginx"))
+		tag, err := values.PathValue("image.tag")
+		Expect(err).To(BeNil())
+		Expect(tag).To(Equal("2.0"))
+	})
+
+	It("fetchValuesFrom returns content of ConfigMap and Secret keys", func() {
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(configMap, secret).Build()
+
+		// Namespace not set, cluster namespace is used
+		content, found, err := controllers.FetchValuesFrom(context.TODO(), c, clusterNamespace,
+			&configv1alpha1.ValuesFrom{Kind: string(libsveltosv1alpha1.ConfigMapReferencedResourceKind),
+				Name: configMap.Name})
+		Expect(err).To(BeNil())
+		Expect(found).To(BeTrue())
+		Expect(content).To(Equal(configMap.Data["values.yaml"]))
+
+		content, found, err = controllers.FetchValuesFrom(context.TODO(), c, clusterNamespace,
+			&configv1alpha1.ValuesFrom{Kind: string(libsveltosv1alpha1.SecretReferencedResourceKind),
+				Namespace: secret.Namespace, Name: secret.Name, Key: "password.yaml"})
+		Expect(err).To(BeNil())
+		Expect(found).To(BeTrue())
+		Expect(content).To(Equal(string(secret.Data["password.yaml"])))
+
+		By("Referencing a missing key")
+		_, found, err = controllers.FetchValuesFrom(context.TODO(), c, clusterNamespace,
+			&configv1alpha1.ValuesFrom{Kind: string(libsveltosv1alpha1.ConfigMapReferencedResourceKind),
+				Name: configMap.Name, Key: randomString()})
+		Expect(err).To(BeNil())
+		Expect(found).To(BeFalse())
+
+		By("Referencing a missing ConfigMap")
+		_, found, err = controllers.FetchValuesFrom(context.TODO(), c, clusterNamespace,
+			&configv1alpha1.ValuesFrom{Kind: string(libsveltosv1alpha1.ConfigMapReferencedResourceKind),
+				Name: randomString()})
+		Expect(err).To(BeNil())
+		Expect(found).To(BeFalse())
+	})
+
+	It("HelmHash changes when content of referenced ConfigMap changes", func() {
+		clusterSummary := &configv1alpha1.ClusterSummary{
+			ObjectMeta: metav1.ObjectMeta{Namespace: randomString(), Name: randomString()},
+			Spec: configv1alpha1.ClusterSummarySpec{
+				ClusterNamespace: clusterNamespace,
+				ClusterProfileSpec: configv1alpha1.ClusterProfileSpec{
+					HelmCharts: []configv1alpha1.HelmChart{
+						{
+							RepositoryURL:    "https://charts.bitnami.com/bitnami",
+							RepositoryName:   "bitnami",
+							ChartName:        "bitnami/redis",
+							ChartVersion:     "17.7.1",
+							ReleaseName:      "redis",
+							ReleaseNamespace: "redis",
+							ValuesFrom: []configv1alpha1.ValuesFrom{
+								{Kind: string(libsveltosv1alpha1.ConfigMapReferencedResourceKind), Name: configMap.Name},
+							},
+						},
+					},
+				},
+			},
+		}
+
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(clusterSummary, configMap).Build()
+		clusterSummaryScope := getClusterSummaryScope(c, klogr.New(), &configv1alpha1.ClusterProfile{}, clusterSummary)
+
+		hash, err := controllers.HelmHash(context.TODO(), c, clusterSummaryScope, klogr.New())
+		Expect(err).To(BeNil())
+
+		configMap.Data["values.yaml"] = "replicaCount: 3"
+		Expect(c.Update(context.TODO(), configMap)).To(Succeed())
+
+		newHash, err := controllers.HelmHash(context.TODO(), c, clusterSummaryScope, klogr.New())
+		Expect(err).To(BeNil())
+		Expect(newHash).ToNot(Equal(hash))
+
+		By("Verifying referenced ConfigMap is tracked")
+		references, err := controllers.GetHelmReferences(context.TODO(), c, clusterSummary)
+		Expect(err).To(BeNil())
+		Expect(references.Has(&corev1.ObjectReference{
+			APIVersion: corev1.SchemeGroupVersion.String(),
+			Kind:       string(libsveltosv1alpha1.ConfigMapReferencedResourceKind),
+			Namespace:  clusterNamespace, Name: configMap.Name,
+		})).To(BeTrue())
+	})
+
+	It("validateValuesAgainstSchema validates values, along with chart defaults, against chart schema", func() {
+		chartRequested := &chart.Chart{
+			Metadata: &chart.Metadata{APIVersion: chart.APIVersionV2, Name: randomString(), Version: "0.1.0"},
+			Values:   map[string]interface{}{"image": map[string]interface{}{"repository": "nginx"}},
+			Schema: []byte(`{
+  "$schema": "http://json-schema.org/draft-07/schema#",
+  "type": "object",
+  "required": ["image", "replicaCount"],
+  "properties": {
+    "image": {"type": "object", "required": ["repository"]},
+    "replicaCount": {"type": "integer"}
+  }
+}`),
+		}
+
+		values, err := chartutil.ReadValues([]byte("replicaCount: 3"))
+		Expect(err).To(BeNil())
+		Expect(controllers.ValidateValuesAgainstSchema(chartRequested, values)).To(Succeed())
+
+		By("Using values not matching the schema")
+		values, err = chartutil.ReadValues([]byte("replicaCount: three"))
+		Expect(err).To(BeNil())
+		err = controllers.ValidateValuesAgainstSchema(chartRequested, values)
+		Expect(err).ToNot(BeNil())
+		Expect(err.Error()).To(ContainSubstring("values do not match chart values schema"))
+
+		By("Missing a required value")
+		Expect(controllers.ValidateValuesAgainstSchema(chartRequested, chartutil.Values{})).ToNot(Succeed())
+	})
+})
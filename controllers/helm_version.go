@@ -0,0 +1,246 @@
+/*
+Copyright 2022-23. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/go-logr/logr"
+	"helm.sh/helm/v3/pkg/helmpath"
+	"helm.sh/helm/v3/pkg/registry"
+	"helm.sh/helm/v3/pkg/repo"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	logs "github.com/projectsveltos/libsveltos/lib/logsettings"
+	configv1alpha1 "github.com/projectsveltos/sveltos-manager/api/v1alpha1"
+)
+
+// isChartVersionConstraint returns true if chart version is a semantic version constraint
+// (for instance "~1.12" or ">=2.0 <3.0") rather than a version
+func isChartVersionConstraint(chartVersion string) bool {
+	if _, err := semver.NewVersion(chartVersion); err == nil {
+		return false
+	}
+
+	_, err := semver.NewConstraint(chartVersion)
+	return err == nil
+}
+
+// hasChartVersionConstraints returns true if any helm chart referenced by ClusterSummary
+// uses a chart version constraint
+func hasChartVersionConstraints(clusterSummary *configv1alpha1.ClusterSummary) bool {
+	for i := range clusterSummary.Spec.ClusterProfileSpec.HelmCharts {
+		if isChartVersionConstraint(clusterSummary.Spec.ClusterProfileSpec.HelmCharts[i].ChartVersion) {
+			return true
+		}
+	}
+	return false
+}
+
+// getChartVersionConstraintRequeueAfter returns how long to wait before checking again whether
+// a newer chart version matching a constraint was published. Repository indexes are not
+// downloaded again before that.
+func getChartVersionConstraintRequeueAfter() time.Duration {
+	helmChartCache.mu.Lock()
+	indexTTL := helmChartCache.indexTTL
+	helmChartCache.mu.Unlock()
+
+	if indexTTL < normalRequeueAfter {
+		return normalRequeueAfter
+	}
+	return indexTTL
+}
+
+// resolveChartVersion returns the helm chart with ChartVersion set to the latest version
+// matching the ChartVersion constraint. Versions are read from the repository index (for
+// charts stored in OCI registries, from the repository tags).
+// Helm chart is returned as it is when ChartVersion is not a constraint.
+// requestedChart must be resolved (see resolveHelmChart).
+func resolveChartVersion(ctx context.Context, requestedChart *configv1alpha1.HelmChart,
+	logger logr.Logger) (*configv1alpha1.HelmChart, error) {
+
+	if !isChartVersionConstraint(requestedChart.ChartVersion) {
+		return requestedChart, nil
+	}
+
+	var version string
+	var err error
+	if isOCIChart(requestedChart) {
+		version, err = getLatestOCIChartVersion(ctx, getManagementClusterClient(), requestedChart, logger)
+	} else {
+		version, err = getLatestRepositoryChartVersion(ctx, requestedChart, logger)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	logger.V(logs.LogDebug).Info(fmt.Sprintf("chart version constraint %q resolved to %s",
+		requestedChart.ChartVersion, version))
+
+	resolvedChart := requestedChart.DeepCopy()
+	resolvedChart.ChartVersion = version
+	return resolvedChart, nil
+}
+
+// getLatestRepositoryChartVersion returns the latest version, in the helm repository index,
+// matching the ChartVersion constraint
+func getLatestRepositoryChartVersion(ctx context.Context, requestedChart *configv1alpha1.HelmChart,
+	logger logr.Logger) (string, error) {
+
+	settings := getSettings()
+	err := prepareRepository(ctx, settings, requestedChart, logger)
+	if err != nil {
+		return "", err
+	}
+
+	// Index is updated while holding the repository lock
+	lock := helmChartCache.getRepositoryLock(requestedChart.RepositoryName)
+	lock.Lock()
+	defer lock.Unlock()
+
+	indexFile, err := repo.LoadIndexFile(filepath.Join(settings.RepositoryCache,
+		helmpath.CacheIndexFile(requestedChart.RepositoryName)))
+	if err != nil {
+		return "", err
+	}
+
+	chartName := strings.TrimPrefix(requestedChart.ChartName, requestedChart.RepositoryName+"/")
+	chartVersion, err := indexFile.Get(chartName, requestedChart.ChartVersion)
+	if err != nil {
+		return "", fmt.Errorf("no version of chart %s matches constraint %q: %w",
+			chartName, requestedChart.ChartVersion, err)
+	}
+
+	return chartVersion.Version, nil
+}
+
+// getLatestOCIChartVersion returns the latest tag, in the OCI repository, matching the
+// ChartVersion constraint
+func getLatestOCIChartVersion(ctx context.Context, c client.Client, requestedChart *configv1alpha1.HelmChart,
+	logger logr.Logger) (string, error) {
+
+	registryClient, cleanup, err := getRegistryClient(ctx, c, requestedChart, logger)
+	if err != nil {
+		return "", err
+	}
+	defer cleanup()
+
+	ref := strings.TrimPrefix(getOCIChartName(requestedChart), fmt.Sprintf("%s://", registry.OCIScheme))
+	tags, err := registryClient.Tags(ref)
+	if err != nil {
+		return "", err
+	}
+
+	return getLatestMatchingVersion(tags, requestedChart.ChartVersion)
+}
+
+// getLatestMatchingVersion returns the latest version matching the constraint.
+// Entries which are not semantic versions are ignored.
+func getLatestMatchingVersion(versions []string, versionConstraint string) (string, error) {
+	constraint, err := semver.NewConstraint(versionConstraint)
+	if err != nil {
+		return "", err
+	}
+
+	var latest *semver.Version
+	var latestVersion string
+	for i := range versions {
+		version, err := semver.NewVersion(versions[i])
+		if err != nil {
+			continue
+		}
+		if !constraint.Check(version) {
+			continue
+		}
+		if latest == nil || version.GreaterThan(latest) {
+			latest = version
+			latestVersion = versions[i]
+		}
+	}
+
+	if latest == nil {
+		return "", fmt.Errorf("no version matches constraint %q", versionConstraint)
+	}
+
+	return latestVersion, nil
+}
+
+// refreshResolvedChartVersions resolves again each helm chart ChartVersion constraint and records, in
+// ClusterSummary Status, the version it currently resolves to. When a newer matching version is published,
+// helm feature hash changes and helm charts are deployed again.
+// Failing to resolve a constraint (for instance because repository is unreachable) is only logged and
+// previously resolved version is kept. ClusterSummary Status is only updated in memory.
+// No action in DryRun mode.
+func refreshResolvedChartVersions(ctx context.Context, c client.Client, clusterSummary *configv1alpha1.ClusterSummary,
+	logger logr.Logger) {
+
+	if clusterSummary.Spec.ClusterProfileSpec.SyncMode == configv1alpha1.SyncModeDryRun {
+		return
+	}
+
+	for i := range clusterSummary.Spec.ClusterProfileSpec.HelmCharts {
+		currentChart := &clusterSummary.Spec.ClusterProfileSpec.HelmCharts[i]
+		if !isChartVersionConstraint(currentChart.ChartVersion) {
+			continue
+		}
+
+		// Entry is created, and constraint resolved, when helm charts are deployed
+		summary := getHelmChartSummary(clusterSummary, currentChart.ReleaseNamespace, currentChart.ReleaseName)
+		if summary == nil || summary.Status != configv1alpha1.HelChartStatusManaging {
+			continue
+		}
+
+		resolvedChart, err := resolveHelmChart(ctx, c, currentChart)
+		if err == nil {
+			resolvedChart, err = resolveChartVersion(ctx, resolvedChart, logger)
+		}
+		if err != nil {
+			logger.V(logs.LogInfo).Info(fmt.Sprintf("failed to resolve chart version constraint %q of release %s/%s: %v",
+				currentChart.ChartVersion, currentChart.ReleaseNamespace, currentChart.ReleaseName, err))
+			continue
+		}
+
+		summary.ResolvedChartVersion = resolvedChart.ChartVersion
+	}
+}
+
+// updateResolvedChartVersion records, in ClusterSummary Status, the version the helm chart
+// ChartVersion constraint was resolved to.
+// No action in DryRun mode.
+func updateResolvedChartVersion(ctx context.Context, c client.Client, clusterSummary *configv1alpha1.ClusterSummary,
+	requestedChart *configv1alpha1.HelmChart, resolvedVersion string) error {
+
+	// No-op in DryRun mode
+	if clusterSummary.Spec.ClusterProfileSpec.SyncMode == configv1alpha1.SyncModeDryRun {
+		return nil
+	}
+
+	summary := getHelmChartSummary(clusterSummary, requestedChart.ReleaseNamespace, requestedChart.ReleaseName)
+	if summary != nil && summary.ResolvedChartVersion == resolvedVersion {
+		return nil
+	}
+
+	return updateHelmChartSummary(ctx, c, clusterSummary, requestedChart,
+		func(summary *configv1alpha1.HelmChartSummary) {
+			summary.ResolvedChartVersion = resolvedVersion
+		})
+}
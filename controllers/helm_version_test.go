@@ -0,0 +1,211 @@
+/*
+Copyright 2022-23. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2/klogr"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	configv1alpha1 "github.com/projectsveltos/sveltos-manager/api/v1alpha1"
+	"github.com/projectsveltos/sveltos-manager/controllers"
+	"github.com/projectsveltos/sveltos-manager/pkg/scope"
+)
+
+const (
+	repositoryIndex = `apiVersion: v1
+entries:
+  nginx:
+  - apiVersion: v2
+    name: nginx
+    version: 1.12.1
+    urls:
+    - nginx-1.12.1.tgz
+  - apiVersion: v2
+    name: nginx
+    version: 1.12.3
+    urls:
+    - nginx-1.12.3.tgz
+  - apiVersion: v2
+    name: nginx
+    version: 1.13.0
+    urls:
+    - nginx-1.13.0.tgz
+  - apiVersion: v2
+    name: nginx
+    version: 2.0.0
+    urls:
+    - nginx-2.0.0.tgz
+`
+)
+
+var _ = Describe("Helm chart version", func() {
+	It("isChartVersionConstraint returns true only for semantic version constraints", func() {
+		Expect(controllers.IsChartVersionConstraint("1.12.1")).To(BeFalse())
+		Expect(controllers.IsChartVersionConstraint("v2.5.0")).To(BeFalse())
+		Expect(controllers.IsChartVersionConstraint("latest")).To(BeFalse())
+		Expect(controllers.IsChartVersionConstraint("~1.12")).To(BeTrue())
+		Expect(controllers.IsChartVersionConstraint(">=2.0 <3.0")).To(BeTrue())
+	})
+
+	It("getLatestMatchingVersion returns the latest version matching the constraint", func() {
+		versions := []string{"1.12.1", "1.13.0", "1.12.3", "latest", "2.0.0"}
+
+		version, err := controllers.GetLatestMatchingVersion(versions, "~1.12")
+		Expect(err).To(BeNil())
+		Expect(version).To(Equal("1.12.3"))
+
+		version, err = controllers.GetLatestMatchingVersion(versions, ">=1.0 <2.0")
+		Expect(err).To(BeNil())
+		Expect(version).To(Equal("1.13.0"))
+
+		_, err = controllers.GetLatestMatchingVersion(versions, ">=3.0")
+		Expect(err).ToNot(BeNil())
+	})
+
+	It("resolveChartVersion resolves the constraint against the repository index", func() {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/index.yaml" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_, _ = w.Write([]byte(repositoryIndex))
+		}))
+		defer server.Close()
+
+		dir, err := os.MkdirTemp("", randomString())
+		Expect(err).To(BeNil())
+		defer os.RemoveAll(dir)
+		GinkgoT().Setenv("HELM_CACHE_HOME", dir)
+		GinkgoT().Setenv("HELM_CONFIG_HOME", dir)
+
+		requestedChart := &configv1alpha1.HelmChart{
+			RepositoryURL:    server.URL,
+			RepositoryName:   randomString(),
+			ChartVersion:     "~1.12",
+			ReleaseName:      "nginx",
+			ReleaseNamespace: "nginx",
+		}
+		requestedChart.ChartName = requestedChart.RepositoryName + "/nginx"
+
+		resolvedChart, err := controllers.ResolveChartVersion(context.TODO(), requestedChart, klogr.New())
+		Expect(err).To(BeNil())
+		Expect(resolvedChart.ChartVersion).To(Equal("1.12.3"))
+		// Requested chart is not modified
+		Expect(requestedChart.ChartVersion).To(Equal("~1.12"))
+
+		By("Using a constraint no version matches")
+		requestedChart.ChartVersion = ">=3.0"
+		_, err = controllers.ResolveChartVersion(context.TODO(), requestedChart, klogr.New())
+		Expect(err).ToNot(BeNil())
+
+		By("Using a version")
+		requestedChart.ChartVersion = "1.13.0"
+		resolvedChart, err = controllers.ResolveChartVersion(context.TODO(), requestedChart, klogr.New())
+		Expect(err).To(BeNil())
+		Expect(resolvedChart).To(Equal(requestedChart))
+	})
+
+	It("refreshResolvedChartVersions updates resolved version considered by helmHash", func() {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/index.yaml" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_, _ = w.Write([]byte(repositoryIndex))
+		}))
+
+		dir, err := os.MkdirTemp("", randomString())
+		Expect(err).To(BeNil())
+		defer os.RemoveAll(dir)
+		GinkgoT().Setenv("HELM_CACHE_HOME", dir)
+		GinkgoT().Setenv("HELM_CONFIG_HOME", dir)
+
+		repositoryName := randomString()
+		clusterSummary := &configv1alpha1.ClusterSummary{
+			ObjectMeta: metav1.ObjectMeta{Name: randomString()},
+			Spec: configv1alpha1.ClusterSummarySpec{
+				ClusterNamespace: randomString(),
+				ClusterName:      randomString(),
+				ClusterProfileSpec: configv1alpha1.ClusterProfileSpec{
+					HelmCharts: []configv1alpha1.HelmChart{
+						{
+							RepositoryURL:    server.URL,
+							RepositoryName:   repositoryName,
+							ChartName:        repositoryName + "/nginx",
+							ChartVersion:     "~1.12",
+							ReleaseName:      "nginx",
+							ReleaseNamespace: "nginx",
+							HelmChartAction:  configv1alpha1.HelmChartActionInstall,
+						},
+					},
+				},
+			},
+			Status: configv1alpha1.ClusterSummaryStatus{
+				HelmReleaseSummaries: []configv1alpha1.HelmChartSummary{
+					{
+						ReleaseName:          "nginx",
+						ReleaseNamespace:     "nginx",
+						Status:               configv1alpha1.HelChartStatusManaging,
+						ResolvedChartVersion: "1.12.1",
+					},
+				},
+			},
+		}
+
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(clusterSummary).Build()
+
+		clusterSummaryScope, err := scope.NewClusterSummaryScope(scope.ClusterSummaryScopeParams{
+			Client:         c,
+			Logger:         klogr.New(),
+			ClusterSummary: clusterSummary,
+			ControllerName: "clustersummary",
+		})
+		Expect(err).To(BeNil())
+
+		hash, err := controllers.HelmHash(context.TODO(), c, clusterSummaryScope, klogr.New())
+		Expect(err).To(BeNil())
+
+		controllers.RefreshResolvedChartVersions(context.TODO(), c, clusterSummary, klogr.New())
+		Expect(clusterSummary.Status.HelmReleaseSummaries[0].ResolvedChartVersion).To(Equal("1.12.3"))
+
+		newHash, err := controllers.HelmHash(context.TODO(), c, clusterSummaryScope, klogr.New())
+		Expect(err).To(BeNil())
+		Expect(newHash).ToNot(Equal(hash))
+
+		By("Making repository unreachable")
+		server.Close()
+		controllers.SetChartCacheOptions(controllers.DefaultChartCacheMaxSize, 0)
+		defer controllers.SetChartCacheOptions(controllers.DefaultChartCacheMaxSize, controllers.DefaultRepositoryIndexTTL)
+		clusterSummary.Status.HelmReleaseSummaries[0].ResolvedChartVersion = "1.12.1"
+		controllers.RefreshResolvedChartVersions(context.TODO(), c, clusterSummary, klogr.New())
+		Expect(clusterSummary.Status.HelmReleaseSummaries[0].ResolvedChartVersion).To(Equal("1.12.1"))
+
+		// helmHash does not access the repository
+		unreachableHash, err := controllers.HelmHash(context.TODO(), c, clusterSummaryScope, klogr.New())
+		Expect(err).To(BeNil())
+		Expect(unreachableHash).To(Equal(hash))
+	})
+})
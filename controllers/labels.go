@@ -48,6 +48,11 @@ const (
 	// PolicyTemplate is the annotation that must be set on a policy when the
 	// policy is a template and needs variable sustitution.
 	PolicyTemplate = "projectsveltos.io/template"
+
+	// ReleaseClusterSummaryAnnotation is the annotation set on the chart of each helm release
+	// installed (or adopted) by a ClusterSummary instance. Value is the ClusterSummary
+	// namespace/name.
+	ReleaseClusterSummaryAnnotation = "projectsveltos.io/cluster-summary"
 )
 
 // addLabel adds label to an object
@@ -46,12 +46,39 @@ var (
 			Buckets:   []float64{1, 10, 30, 60, 120, 180, 240},
 		},
 	)
+
+	helmCacheHits = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "projectsveltos",
+			Name:      "helm_cache_hits_total",
+			Help:      "Number of helm repository indexes and charts found in the cache",
+		},
+		[]string{"type"},
+	)
+
+	helmCacheMisses = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "projectsveltos",
+			Name:      "helm_cache_misses_total",
+			Help:      "Number of helm repository indexes and charts not found in the cache",
+		},
+		[]string{"type"},
+	)
+
+	helmChartCacheSize = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: "projectsveltos",
+			Name:      "helm_chart_cache_size_bytes",
+			Help:      "Total size of helm chart archives in the cache",
+		},
+	)
 )
 
 //nolint:gochecknoinits // forced pattern, can't workaround
 func init() {
 	// Register custom metrics with the global prometheus registry
-	metrics.Registry.MustRegister(programResourceDurationHistogram, programChartDurationHistogram)
+	metrics.Registry.MustRegister(programResourceDurationHistogram, programChartDurationHistogram,
+		helmCacheHits, helmCacheMisses, helmChartCacheSize)
 }
 
 func newResourceHistogram(clusterNamespace, clusterName string, clusterType libsveltosv1alpha1.ClusterType,
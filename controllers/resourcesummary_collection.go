@@ -153,12 +153,16 @@ func processResourceSummary(ctx context.Context, c, remoteClient client.Client,
 		if clusterSummary.Status.FeatureSummaries[i].FeatureID == configv1alpha1.FeatureHelm {
 			if rs.Status.HelmResourcesChanged {
 				l.V(logs.LogDebug).Info("redeploy helm")
+				recordEvent(clusterSummary, corev1.EventTypeNormal, eventReasonDriftDetected,
+					"configuration drift detected for helm resources. Redeploying %s feature", configv1alpha1.FeatureHelm)
 				clusterSummary.Status.FeatureSummaries[i].Hash = nil
 				clusterSummary.Status.FeatureSummaries[i].Status = configv1alpha1.FeatureStatusProvisioning
 			}
 		} else if clusterSummary.Status.FeatureSummaries[i].FeatureID == configv1alpha1.FeatureResources {
 			if rs.Status.ResourcesChanged {
 				l.V(logs.LogDebug).Info("redeploy resources")
+				recordEvent(clusterSummary, corev1.EventTypeNormal, eventReasonDriftDetected,
+					"configuration drift detected for resources. Redeploying %s feature", configv1alpha1.FeatureResources)
 				clusterSummary.Status.FeatureSummaries[i].Hash = nil
 				clusterSummary.Status.FeatureSummaries[i].Status = configv1alpha1.FeatureStatusProvisioning
 			}
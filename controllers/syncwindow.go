@@ -0,0 +1,189 @@
+/*
+Copyright 2022-23. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+	// Embed time zone database so SyncWindow TimeZone can be resolved
+	// even if the container image does not ship one.
+	_ "time/tzdata"
+
+	"github.com/go-logr/logr"
+	"github.com/robfig/cron/v3"
+
+	logs "github.com/projectsveltos/libsveltos/lib/logsettings"
+	configv1alpha1 "github.com/projectsveltos/sveltos-manager/api/v1alpha1"
+	"github.com/projectsveltos/sveltos-manager/pkg/scope"
+)
+
+const (
+	// maxOverlappingActivations is the maximum number of overlapping activations following current time
+	// considered when computing when a sync window closes. If window is still open after those, sync
+	// windows are simply evaluated again at that time.
+	maxOverlappingActivations = 100
+)
+
+// getSyncWindowState returns whether syncWindow is open at time now.
+// If window is open, it also returns when window closes. Otherwise it returns when window opens next.
+func getSyncWindowState(syncWindow *configv1alpha1.SyncWindow, now time.Time) (open bool, next time.Time, err error) {
+	if syncWindow.Duration.Duration <= 0 {
+		return false, time.Time{}, fmt.Errorf("sync window %q: duration must be positive", syncWindow.Schedule)
+	}
+
+	schedule, err := cron.ParseStandard(syncWindow.Schedule)
+	if err != nil {
+		return false, time.Time{}, fmt.Errorf("sync window %q: invalid schedule: %w", syncWindow.Schedule, err)
+	}
+
+	location := time.UTC
+	if syncWindow.TimeZone != "" {
+		location, err = time.LoadLocation(syncWindow.TimeZone)
+		if err != nil {
+			return false, time.Time{}, fmt.Errorf("sync window %q: invalid time zone: %w", syncWindow.Schedule, err)
+		}
+	}
+
+	// Schedule is evaluated in the location of the time passed to it.
+	// First activation after (now - duration) is either an activation currently open
+	// (if not after now) or the next activation.
+	now = now.In(location)
+	start := schedule.Next(now.Add(-syncWindow.Duration.Duration))
+	if start.After(now) {
+		return false, start, nil
+	}
+
+	// When duration is longer than the schedule period, consecutive activations overlap.
+	// Window closes duration after the latest activation at or before now, unless following
+	// activations open it again before it closes.
+	next = schedule.Next(start)
+	for !next.After(now) {
+		start = next
+		next = schedule.Next(start)
+	}
+
+	end := start.Add(syncWindow.Duration.Duration)
+	for i := 0; i < maxOverlappingActivations && !next.After(end); i++ {
+		end = next.Add(syncWindow.Duration.Duration)
+		next = schedule.Next(next)
+	}
+
+	return true, end, nil
+}
+
+// isInSyncWindow returns true if changes can be deployed at time now:
+// - no Deny window is open;
+// - if any Allow window is defined, at least one of those is open.
+// If changes cannot be deployed, it also returns when sync windows need to be evaluated again.
+func isInSyncWindow(syncWindows []configv1alpha1.SyncWindow, now time.Time) (bool, time.Time, error) {
+	var next time.Time
+	setNext := func(t time.Time) {
+		if next.IsZero() || t.Before(next) {
+			next = t
+		}
+	}
+
+	denied := false
+	hasAllowWindows := false
+	allowed := false
+	for i := range syncWindows {
+		open, t, err := getSyncWindowState(&syncWindows[i], now)
+		if err != nil {
+			return false, time.Time{}, err
+		}
+
+		if syncWindows[i].Kind == configv1alpha1.SyncWindowKindDeny {
+			if open {
+				denied = true
+				// Once deny window closes, changes might be deployed
+				setNext(t)
+			}
+			continue
+		}
+
+		hasAllowWindows = true
+		if open {
+			allowed = true
+		} else {
+			setNext(t)
+		}
+	}
+
+	if denied || (hasAllowWindows && !allowed) {
+		return false, next, nil
+	}
+
+	return true, time.Time{}, nil
+}
+
+// getSyncWindowRequeueAfter returns how long to wait before evaluating sync windows again
+func getSyncWindowRequeueAfter(nextSyncTime time.Time) time.Duration {
+	const minRequeueAfter = time.Second
+
+	requeueAfter := time.Until(nextSyncTime)
+	if requeueAfter < minRequeueAfter {
+		requeueAfter = minRequeueAfter
+	}
+	return requeueAfter
+}
+
+// holdFeatures marks as WaitingForWindow any feature whose configuration still needs to be deployed
+// (configuration has changed since last deployment or feature was never successfully deployed).
+func (r *ClusterSummaryReconciler) holdFeatures(ctx context.Context, clusterSummaryScope *scope.ClusterSummaryScope,
+	logger logr.Logger) error {
+
+	clusterSummary := clusterSummaryScope.ClusterSummary
+
+	features := []configv1alpha1.FeatureID{configv1alpha1.FeatureResources, configv1alpha1.FeatureHelm}
+	for i := range features {
+		featureID := features[i]
+
+		switch featureID {
+		case configv1alpha1.FeatureResources:
+			if clusterSummary.Spec.ClusterProfileSpec.PolicyRefs == nil &&
+				!r.isFeatureStatusPresent(clusterSummaryScope, featureID) {
+
+				continue
+			}
+		case configv1alpha1.FeatureHelm:
+			if clusterSummary.Spec.ClusterProfileSpec.HelmCharts == nil &&
+				!r.isFeatureStatusPresent(clusterSummaryScope, featureID) {
+
+				continue
+			}
+		}
+
+		f := getHandlersForFeature(featureID)
+		currentHash, err := f.currentHash(ctx, r.Client, clusterSummaryScope, logger)
+		if err != nil {
+			return err
+		}
+
+		hash := r.getHash(clusterSummaryScope, featureID)
+		if reflect.DeepEqual(hash, currentHash) && r.isFeatureDeployed(clusterSummaryScope, featureID) {
+			continue
+		}
+
+		logger.V(logs.LogDebug).Info(fmt.Sprintf("feature %s is waiting for sync window", featureID))
+		// Keep previous hash so, once window opens, change is still detected
+		clusterSummaryScope.SetFeatureStatus(featureID, configv1alpha1.FeatureStatusWaitingForWindow, hash)
+	}
+
+	return nil
+}
@@ -0,0 +1,233 @@
+/*
+Copyright 2022-23. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers_test
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2/klogr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	libsveltosv1alpha1 "github.com/projectsveltos/libsveltos/api/v1alpha1"
+	fakedeployer "github.com/projectsveltos/libsveltos/lib/deployer/fake"
+	configv1alpha1 "github.com/projectsveltos/sveltos-manager/api/v1alpha1"
+	"github.com/projectsveltos/sveltos-manager/controllers"
+)
+
+var _ = Describe("Sync windows", func() {
+	// Monday, 6 February 2023 10:30 UTC
+	now := time.Date(2023, time.February, 6, 10, 30, 0, 0, time.UTC)
+
+	It("getSyncWindowState returns whether window is open and when it closes or opens next", func() {
+		syncWindow := &configv1alpha1.SyncWindow{
+			Schedule: "0 10 * * *",
+			Duration: metav1.Duration{Duration: time.Hour},
+		}
+
+		open, next, err := controllers.GetSyncWindowState(syncWindow, now)
+		Expect(err).To(BeNil())
+		Expect(open).To(BeTrue())
+		Expect(next.Equal(time.Date(2023, time.February, 6, 11, 0, 0, 0, time.UTC))).To(BeTrue())
+
+		syncWindow.Duration = metav1.Duration{Duration: 15 * time.Minute}
+		open, next, err = controllers.GetSyncWindowState(syncWindow, now)
+		Expect(err).To(BeNil())
+		Expect(open).To(BeFalse())
+		Expect(next.Equal(time.Date(2023, time.February, 7, 10, 0, 0, 0, time.UTC))).To(BeTrue())
+	})
+
+	It("getSyncWindowState returns when window closes when activations overlap", func() {
+		// Activations at 10:00 and 11:00 lasting 90 minutes keep window open till 12:30
+		syncWindow := &configv1alpha1.SyncWindow{
+			Schedule: "0 10,11 * * *",
+			Duration: metav1.Duration{Duration: 90 * time.Minute},
+		}
+		closeTime := time.Date(2023, time.February, 6, 12, 30, 0, 0, time.UTC)
+
+		open, next, err := controllers.GetSyncWindowState(syncWindow, now)
+		Expect(err).To(BeNil())
+		Expect(open).To(BeTrue())
+		Expect(next.Equal(closeTime)).To(BeTrue())
+
+		open, next, err = controllers.GetSyncWindowState(syncWindow, now.Add(45*time.Minute))
+		Expect(err).To(BeNil())
+		Expect(open).To(BeTrue())
+		Expect(next.Equal(closeTime)).To(BeTrue())
+
+		open, next, err = controllers.GetSyncWindowState(syncWindow, closeTime)
+		Expect(err).To(BeNil())
+		Expect(open).To(BeFalse())
+		Expect(next.Equal(time.Date(2023, time.February, 7, 10, 0, 0, 0, time.UTC))).To(BeTrue())
+
+		By("Window never closing")
+		syncWindow.Schedule = "*/30 * * * *"
+		open, next, err = controllers.GetSyncWindowState(syncWindow, now)
+		Expect(err).To(BeNil())
+		Expect(open).To(BeTrue())
+		Expect(next.After(now)).To(BeTrue())
+	})
+
+	It("getSyncWindowState evaluates schedule in the window time zone", func() {
+		// 10:30 UTC is 11:30 in Rome (CET)
+		syncWindow := &configv1alpha1.SyncWindow{
+			Schedule: "0 11 * * *",
+			Duration: metav1.Duration{Duration: time.Hour},
+			TimeZone: "Europe/Rome",
+		}
+
+		open, _, err := controllers.GetSyncWindowState(syncWindow, now)
+		Expect(err).To(BeNil())
+		Expect(open).To(BeTrue())
+
+		syncWindow.TimeZone = ""
+		open, next, err := controllers.GetSyncWindowState(syncWindow, now)
+		Expect(err).To(BeNil())
+		Expect(open).To(BeFalse())
+		Expect(next.Equal(time.Date(2023, time.February, 6, 11, 0, 0, 0, time.UTC))).To(BeTrue())
+	})
+
+	It("getSyncWindowState returns an error for invalid windows", func() {
+		syncWindow := &configv1alpha1.SyncWindow{
+			Schedule: "not a schedule",
+			Duration: metav1.Duration{Duration: time.Hour},
+		}
+		_, _, err := controllers.GetSyncWindowState(syncWindow, now)
+		Expect(err).ToNot(BeNil())
+
+		syncWindow.Schedule = "0 10 * * *"
+		syncWindow.TimeZone = randomString()
+		_, _, err = controllers.GetSyncWindowState(syncWindow, now)
+		Expect(err).ToNot(BeNil())
+
+		syncWindow.TimeZone = ""
+		syncWindow.Duration = metav1.Duration{}
+		_, _, err = controllers.GetSyncWindowState(syncWindow, now)
+		Expect(err).ToNot(BeNil())
+	})
+
+	It("isInSyncWindow combines allow and deny windows", func() {
+		By("No sync window means changes are always deployed")
+		inSyncWindow, _, err := controllers.IsInSyncWindow(nil, now)
+		Expect(err).To(BeNil())
+		Expect(inSyncWindow).To(BeTrue())
+
+		By("Allow window open")
+		syncWindows := []configv1alpha1.SyncWindow{
+			{Kind: configv1alpha1.SyncWindowKindAllow, Schedule: "0 10 * * 1-5", Duration: metav1.Duration{Duration: time.Hour}},
+		}
+		inSyncWindow, _, err = controllers.IsInSyncWindow(syncWindows, now)
+		Expect(err).To(BeNil())
+		Expect(inSyncWindow).To(BeTrue())
+
+		By("Allow window closed")
+		syncWindows[0].Schedule = "0 22 * * 1-5"
+		inSyncWindow, next, err := controllers.IsInSyncWindow(syncWindows, now)
+		Expect(err).To(BeNil())
+		Expect(inSyncWindow).To(BeFalse())
+		Expect(next.Equal(time.Date(2023, time.February, 6, 22, 0, 0, 0, time.UTC))).To(BeTrue())
+
+		By("Deny window open wins over open allow window")
+		syncWindows = []configv1alpha1.SyncWindow{
+			{Kind: configv1alpha1.SyncWindowKindAllow, Schedule: "0 10 * * *", Duration: metav1.Duration{Duration: 4 * time.Hour}},
+			{Kind: configv1alpha1.SyncWindowKindDeny, Schedule: "0 9 * * *", Duration: metav1.Duration{Duration: 2 * time.Hour}},
+		}
+		inSyncWindow, next, err = controllers.IsInSyncWindow(syncWindows, now)
+		Expect(err).To(BeNil())
+		Expect(inSyncWindow).To(BeFalse())
+		Expect(next.Equal(time.Date(2023, time.February, 6, 11, 0, 0, 0, time.UTC))).To(BeTrue())
+
+		By("Closed deny window only does not hold changes")
+		syncWindows = syncWindows[1:]
+		syncWindows[0].Schedule = "0 20 * * *"
+		inSyncWindow, _, err = controllers.IsInSyncWindow(syncWindows, now)
+		Expect(err).To(BeNil())
+		Expect(inSyncWindow).To(BeTrue())
+	})
+
+	It("holdFeatures marks features with pending changes as waiting for window", func() {
+		namespace := "syncwindow" + randomString()
+		configMap := createConfigMapWithPolicy(namespace, randomString(), randomString())
+
+		clusterProfile := &configv1alpha1.ClusterProfile{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: clusterProfileNamePrefix + randomString(),
+			},
+		}
+
+		clusterSummary := &configv1alpha1.ClusterSummary{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      randomString(),
+				Namespace: namespace,
+			},
+			Spec: configv1alpha1.ClusterSummarySpec{
+				ClusterNamespace: namespace,
+				ClusterName:      randomString(),
+				ClusterType:      libsveltosv1alpha1.ClusterTypeCapi,
+				ClusterProfileSpec: configv1alpha1.ClusterProfileSpec{
+					PolicyRefs: []libsveltosv1alpha1.PolicyRef{
+						{
+							Namespace: configMap.Namespace,
+							Name:      configMap.Name,
+							Kind:      string(libsveltosv1alpha1.ConfigMapReferencedResourceKind),
+						},
+					},
+				},
+			},
+			Status: configv1alpha1.ClusterSummaryStatus{
+				FeatureSummaries: []configv1alpha1.FeatureSummary{
+					{
+						FeatureID: configv1alpha1.FeatureResources,
+						Hash:      []byte(randomString()),
+						Status:    configv1alpha1.FeatureStatusProvisioned,
+					},
+				},
+			},
+		}
+
+		initObjects := []client.Object{
+			configMap,
+			clusterProfile,
+			clusterSummary,
+		}
+
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(initObjects...).Build()
+
+		dep := fakedeployer.GetClient(context.TODO(), klogr.New(), c)
+		reconciler := getClusterSummaryReconciler(c, dep)
+		clusterSummaryScope := getClusterSummaryScope(c, klogr.New(), clusterProfile, clusterSummary)
+
+		By("Hash has changed since last deployment")
+		Expect(controllers.HoldFeatures(reconciler, context.TODO(), clusterSummaryScope, klogr.New())).To(Succeed())
+		Expect(len(clusterSummary.Status.FeatureSummaries)).To(Equal(1))
+		Expect(clusterSummary.Status.FeatureSummaries[0].Status).To(Equal(configv1alpha1.FeatureStatusWaitingForWindow))
+
+		By("Nothing has changed since last deployment")
+		currentHash, err := controllers.ResourcesHash(context.TODO(), c, clusterSummaryScope, klogr.New())
+		Expect(err).To(BeNil())
+		clusterSummary.Status.FeatureSummaries[0].Hash = currentHash
+		clusterSummary.Status.FeatureSummaries[0].Status = configv1alpha1.FeatureStatusProvisioned
+
+		Expect(controllers.HoldFeatures(reconciler, context.TODO(), clusterSummaryScope, klogr.New())).To(Succeed())
+		Expect(clusterSummary.Status.FeatureSummaries[0].Status).To(Equal(configv1alpha1.FeatureStatusProvisioned))
+	})
+})
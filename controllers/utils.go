@@ -35,6 +35,7 @@ import (
 
 	libsveltosv1alpha1 "github.com/projectsveltos/libsveltos/api/v1alpha1"
 	configv1alpha1 "github.com/projectsveltos/sveltos-manager/api/v1alpha1"
+	configv1beta1 "github.com/projectsveltos/sveltos-manager/api/v1beta1"
 )
 
 //+kubebuilder:rbac:groups=lib.projectsveltos.io,resources=debuggingconfigurations,verbs=get;list;watch
@@ -51,6 +52,9 @@ func InitScheme() (*runtime.Scheme, error) {
 	if err := configv1alpha1.AddToScheme(s); err != nil {
 		return nil, err
 	}
+	if err := configv1beta1.AddToScheme(s); err != nil {
+		return nil, err
+	}
 	if err := libsveltosv1alpha1.AddToScheme(s); err != nil {
 		return nil, err
 	}
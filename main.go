@@ -71,6 +71,8 @@ var (
 	concurrentReconciles int
 	reportMode           controllers.ReportMode
 	tmpReportMode        int
+	chartCacheMaxSize    int64
+	repositoryIndexTTL   time.Duration
 )
 
 const (
@@ -116,6 +118,8 @@ func main() {
 	controllers.RegisterFeatures(d, setupLog)
 
 	controllers.SetManagementClusterAccess(mgr.GetClient(), mgr.GetConfig())
+	controllers.SetEventRecorder(mgr.GetEventRecorderFor("sveltos-manager"))
+	controllers.SetChartCacheOptions(chartCacheMaxSize, repositoryIndexTTL)
 
 	logsettings.RegisterForLogSettings(ctx,
 		libsveltosv1alpha1.ComponentSveltosManager, ctrl.Log.WithName("log-setter"),
@@ -155,6 +159,25 @@ func main() {
 		setupLog.Error(err, "unable to create controller", "controller", configv1alpha1.ClusterSummaryKind)
 		os.Exit(1)
 	}
+
+	if os.Getenv("ENABLE_WEBHOOKS") != "false" {
+		if err = (&configv1alpha1.ClusterProfile{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", configv1alpha1.ClusterProfileKind)
+			os.Exit(1)
+		}
+		if err = (&configv1alpha1.ClusterSummary{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", configv1alpha1.ClusterSummaryKind)
+			os.Exit(1)
+		}
+		if err = (&configv1alpha1.ClusterConfiguration{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", configv1alpha1.ClusterConfigurationKind)
+			os.Exit(1)
+		}
+		if err = (&configv1alpha1.ClusterReport{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", configv1alpha1.ClusterReportKind)
+			os.Exit(1)
+		}
+	}
 	//+kubebuilder:scaffold:builder
 
 	setupChecks(mgr)
@@ -204,6 +227,18 @@ func initFlags(fs *pflag.FlagSet) {
 		"concurrent-reconciles",
 		defaultReconcilers,
 		"concurrent reconciles is the maximum number of concurrent Reconciles which can be run. Defaults to 10")
+
+	fs.Int64Var(
+		&chartCacheMaxSize,
+		"chart-cache-max-size",
+		controllers.DefaultChartCacheMaxSize,
+		"Maximum size, in bytes, of helm chart archives kept in the cache. Least recently used are evicted first")
+
+	fs.DurationVar(
+		&repositoryIndexTTL,
+		"repository-index-ttl",
+		controllers.DefaultRepositoryIndexTTL,
+		"How long a downloaded helm repository index is used before being downloaded again")
 }
 
 func setupIndexes(ctx context.Context, mgr ctrl.Manager) {
@@ -22,6 +22,8 @@ import (
 	"github.com/go-logr/logr"
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/cluster-api/util/patch"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
@@ -117,3 +119,41 @@ func (s *ClusterProfileScope) IsOneTimeSync() bool {
 func (s *ClusterProfileScope) IsDryRunSync() bool {
 	return s.ClusterProfile.Spec.SyncMode == configv1alpha1.SyncModeDryRun
 }
+
+// SetRolloutStatus sets the rollout status.
+func (s *ClusterProfileScope) SetRolloutStatus(rolloutStatus *configv1alpha1.RolloutStatus) {
+	s.ClusterProfile.Status.RolloutStatus = rolloutStatus
+}
+
+// SetFailureMessage sets the failure message.
+func (s *ClusterProfileScope) SetFailureMessage(failureMessage *string) {
+	s.ClusterProfile.Status.FailureMessage = failureMessage
+}
+
+// SetFeatureClusterCounts sets the per feature cluster counts.
+func (s *ClusterProfileScope) SetFeatureClusterCounts(counts []configv1alpha1.FeatureClusterCount) {
+	s.ClusterProfile.Status.FeatureClusterCounts = counts
+}
+
+// SetClusterStatuses sets the per cluster statuses.
+func (s *ClusterProfileScope) SetClusterStatuses(clusterStatuses []configv1alpha1.ClusterProfileClusterStatus) {
+	s.ClusterProfile.Status.ClusterStatuses = clusterStatuses
+}
+
+// SetFailingClusters sets the failing clusters.
+func (s *ClusterProfileScope) SetFailingClusters(failingClusters []configv1alpha1.FailingCluster) {
+	s.ClusterProfile.Status.FailingClusters = failingClusters
+}
+
+// SetCondition sets a condition. ObservedGeneration is set to ClusterProfile current generation.
+func (s *ClusterProfileScope) SetCondition(conditionType string, status metav1.ConditionStatus,
+	reason, message string) {
+
+	meta.SetStatusCondition(&s.ClusterProfile.Status.Conditions, metav1.Condition{
+		Type:               conditionType,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: s.ClusterProfile.Generation,
+	})
+}
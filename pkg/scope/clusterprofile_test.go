@@ -253,4 +253,91 @@ var _ = Describe("ClusterProfileScope", func() {
 		clusterProfile.Spec.SyncMode = configv1alpha1.SyncModeOneTime
 		Expect(scope.IsDryRunSync()).To(BeFalse())
 	})
+	It("SetRolloutStatus sets ClusterProfile.Status.RolloutStatus", func() {
+		params := scope.ClusterProfileScopeParams{
+			Client:         c,
+			ClusterProfile: clusterProfile,
+			Logger:         klogr.New(),
+		}
+
+		scope, err := scope.NewClusterProfileScope(params)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(scope).ToNot(BeNil())
+
+		reason := randomString()
+		rolloutStatus := &configv1alpha1.RolloutStatus{
+			UpdatedClusters: 3,
+			FailedClusters:  2,
+			PendingClusters: 5,
+			Halted:          true,
+			HaltReason:      &reason,
+		}
+		scope.SetRolloutStatus(rolloutStatus)
+		Expect(reflect.DeepEqual(clusterProfile.Status.RolloutStatus, rolloutStatus)).To(BeTrue())
+	})
+	It("SetFailureMessage sets ClusterProfile.Status.FailureMessage", func() {
+		params := scope.ClusterProfileScopeParams{
+			Client:         c,
+			ClusterProfile: clusterProfile,
+			Logger:         klogr.New(),
+		}
+
+		scope, err := scope.NewClusterProfileScope(params)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(scope).ToNot(BeNil())
+
+		failureMessage := randomString()
+		scope.SetFailureMessage(&failureMessage)
+		Expect(clusterProfile.Status.FailureMessage).ToNot(BeNil())
+		Expect(*clusterProfile.Status.FailureMessage).To(Equal(failureMessage))
+
+		scope.SetFailureMessage(nil)
+		Expect(clusterProfile.Status.FailureMessage).To(BeNil())
+	})
+	It("SetCondition sets ClusterProfile.Status.Conditions", func() {
+		params := scope.ClusterProfileScopeParams{
+			Client:         c,
+			ClusterProfile: clusterProfile,
+			Logger:         klogr.New(),
+		}
+
+		scope, err := scope.NewClusterProfileScope(params)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(scope).ToNot(BeNil())
+
+		clusterProfile.Generation = 3
+		scope.SetCondition(configv1alpha1.ReadyCondition, metav1.ConditionFalse,
+			configv1alpha1.ProvisioningReason, randomString())
+		Expect(len(clusterProfile.Status.Conditions)).To(Equal(1))
+		Expect(clusterProfile.Status.Conditions[0].Type).To(Equal(configv1alpha1.ReadyCondition))
+		Expect(clusterProfile.Status.Conditions[0].ObservedGeneration).To(Equal(int64(3)))
+
+		scope.SetCondition(configv1alpha1.ReadyCondition, metav1.ConditionTrue,
+			configv1alpha1.ProvisionedReason, "")
+		Expect(len(clusterProfile.Status.Conditions)).To(Equal(1))
+		Expect(clusterProfile.Status.Conditions[0].Status).To(Equal(metav1.ConditionTrue))
+		Expect(clusterProfile.Status.Conditions[0].Reason).To(Equal(configv1alpha1.ProvisionedReason))
+	})
+	It("SetFailingClusters sets ClusterProfile.Status.FailingClusters", func() {
+		params := scope.ClusterProfileScopeParams{
+			Client:         c,
+			ClusterProfile: clusterProfile,
+			Logger:         klogr.New(),
+		}
+
+		scope, err := scope.NewClusterProfileScope(params)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(scope).ToNot(BeNil())
+
+		failureMessage := randomString()
+		failingClusters := []configv1alpha1.FailingCluster{
+			{
+				ClusterRef:     corev1.ObjectReference{Namespace: randomString(), Name: randomString()},
+				FeatureID:      configv1alpha1.FeatureHelm,
+				FailureMessage: &failureMessage,
+			},
+		}
+		scope.SetFailingClusters(failingClusters)
+		Expect(reflect.DeepEqual(clusterProfile.Status.FailingClusters, failingClusters)).To(BeTrue())
+	})
 })
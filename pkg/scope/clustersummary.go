@@ -22,6 +22,7 @@ import (
 
 	"github.com/go-logr/logr"
 	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"sigs.k8s.io/cluster-api/util/patch"
@@ -240,6 +241,27 @@ func (s *ClusterSummaryScope) SetLastAppliedTime(featureID configv1alpha1.Featur
 	)
 }
 
+// SetFeatureDeployedGeneration records that feature status was evaluated against the current
+// ClusterSummary Spec.
+func (s *ClusterSummaryScope) SetFeatureDeployedGeneration(featureID configv1alpha1.FeatureID) {
+	for i := range s.ClusterSummary.Status.FeatureSummaries {
+		if s.ClusterSummary.Status.FeatureSummaries[i].FeatureID == featureID {
+			s.ClusterSummary.Status.FeatureSummaries[i].DeployedGeneration = s.ClusterSummary.Generation
+			return
+		}
+	}
+
+	s.initializeFeatureStatusSummary()
+
+	s.ClusterSummary.Status.FeatureSummaries = append(
+		s.ClusterSummary.Status.FeatureSummaries,
+		configv1alpha1.FeatureSummary{
+			FeatureID:          featureID,
+			DeployedGeneration: s.ClusterSummary.Generation,
+		},
+	)
+}
+
 // IsContinuousSync returns true if ClusterProfile is set to keep updating workload cluster
 func (s *ClusterSummaryScope) IsContinuousSync() bool {
 	return s.ClusterSummary.Spec.ClusterProfileSpec.SyncMode == configv1alpha1.SyncModeContinuous ||
@@ -255,3 +277,26 @@ func (s *ClusterSummaryScope) IsOneTimeSync() bool {
 func (s *ClusterSummaryScope) IsDryRunSync() bool {
 	return s.ClusterSummary.Spec.ClusterProfileSpec.SyncMode == configv1alpha1.SyncModeDryRun
 }
+
+// SetNextSyncTime sets the time held changes will be evaluated again.
+func (s *ClusterSummaryScope) SetNextSyncTime(nextSyncTime *metav1.Time) {
+	s.ClusterSummary.Status.NextSyncTime = nextSyncTime
+}
+
+// SetObservedGeneration sets ClusterSummary Status ObservedGeneration to current ClusterSummary generation.
+func (s *ClusterSummaryScope) SetObservedGeneration() {
+	s.ClusterSummary.Status.ObservedGeneration = s.ClusterSummary.Generation
+}
+
+// SetCondition sets a condition. ObservedGeneration is set to ClusterSummary current generation.
+func (s *ClusterSummaryScope) SetCondition(conditionType string, status metav1.ConditionStatus,
+	reason, message string) {
+
+	meta.SetStatusCondition(&s.ClusterSummary.Status.Conditions, metav1.Condition{
+		Type:               conditionType,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: s.ClusterSummary.Generation,
+	})
+}
@@ -22,6 +22,7 @@ import (
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
@@ -467,4 +468,52 @@ var _ = Describe("ClusterSummaryScope", func() {
 		clusterSummary.Spec.ClusterProfileSpec.SyncMode = configv1alpha1.SyncModeOneTime
 		Expect(scope.IsDryRunSync()).To(BeFalse())
 	})
+	It("SetNextSyncTime sets ClusterSummary.Status.NextSyncTime", func() {
+		params := scope.ClusterSummaryScopeParams{
+			Client:         c,
+			ClusterProfile: clusterProfile,
+			ClusterSummary: clusterSummary,
+			Logger:         klogr.New(),
+		}
+
+		scope, err := scope.NewClusterSummaryScope(params)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(scope).ToNot(BeNil())
+
+		nextSyncTime := metav1.NewTime(time.Now().Add(time.Hour))
+		scope.SetNextSyncTime(&nextSyncTime)
+		Expect(clusterSummary.Status.NextSyncTime).ToNot(BeNil())
+		Expect(*clusterSummary.Status.NextSyncTime).To(Equal(nextSyncTime))
+
+		scope.SetNextSyncTime(nil)
+		Expect(clusterSummary.Status.NextSyncTime).To(BeNil())
+	})
+
+	It("SetCondition sets condition with ClusterSummary generation", func() {
+		clusterSummary.Generation = 3
+		params := scope.ClusterSummaryScopeParams{
+			Client:         c,
+			ClusterProfile: clusterProfile,
+			ClusterSummary: clusterSummary,
+			Logger:         klogr.New(),
+		}
+
+		scope, err := scope.NewClusterSummaryScope(params)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(scope).ToNot(BeNil())
+
+		scope.SetCondition(configv1alpha1.PausedCondition, metav1.ConditionTrue, configv1alpha1.PausedReason, "")
+		condition := meta.FindStatusCondition(clusterSummary.Status.Conditions, configv1alpha1.PausedCondition)
+		Expect(condition).ToNot(BeNil())
+		Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+		Expect(condition.ObservedGeneration).To(Equal(int64(3)))
+
+		scope.SetCondition(configv1alpha1.PausedCondition, metav1.ConditionFalse, configv1alpha1.NotPausedReason, "")
+		Expect(len(clusterSummary.Status.Conditions)).To(Equal(1))
+		Expect(meta.IsStatusConditionFalse(clusterSummary.Status.Conditions,
+			configv1alpha1.PausedCondition)).To(BeTrue())
+
+		scope.SetObservedGeneration()
+		Expect(clusterSummary.Status.ObservedGeneration).To(Equal(int64(3)))
+	})
 })